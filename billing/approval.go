@@ -0,0 +1,165 @@
+package billing
+
+import (
+	"auth-api/config"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrApprovalNotPending = errors.New("approval request is no longer pending")
+	ErrApprovalExpired    = errors.New("approval request has expired")
+	ErrSelfApproval       = errors.New("approval must be reviewed by a different user than the requester")
+	ErrNotApprovalMaker   = errors.New("only the requester can cancel an approval request")
+)
+
+// NeedsApproval - Perubahan saldo dengan nilai absolut di atas threshold harus disetujui user lain
+func NeedsApproval(cfg *config.Config, amount models.Money) bool {
+	threshold := models.Money(cfg.Approval.BalanceThreshold)
+	return threshold > 0 && amount.Abs() >= threshold
+}
+
+// RequestBalanceApproval - Simpan perubahan saldo sebagai request pending
+func RequestBalanceApproval(db *gorm.DB, cfg *config.Config, approval *models.BalanceApproval) error {
+	approval.Status = "pending"
+	approval.ExpiresAt = time.Now().Add(cfg.Approval.TTL)
+	return db.Create(approval).Error
+}
+
+// FindApprovalForUpdate - Ambil approval dengan row lock agar tidak di-review dua kali bersamaan
+func FindApprovalForUpdate(tx *gorm.DB, approvalID uint64) (*models.BalanceApproval, error) {
+	var approval models.BalanceApproval
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&approval, approvalID).Error; err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+func checkReviewable(approval *models.BalanceApproval, checkerID uint, now time.Time) error {
+	if approval.Status != "pending" {
+		return ErrApprovalNotPending
+	}
+	if !now.Before(approval.ExpiresAt) {
+		return ErrApprovalExpired
+	}
+	if approval.RequestedBy == checkerID {
+		return ErrSelfApproval
+	}
+	return nil
+}
+
+// ApproveBalanceChange - Checker menyetujui request, perubahan saldo langsung dieksekusi atas nama maker.
+// Posisi saldo dicek ulang saat eksekusi karena bisa berubah sejak request dibuat.
+func ApproveBalanceChange(tx *gorm.DB, approval *models.BalanceApproval, checkerID uint, note string) (*BalanceChange, error) {
+	now := time.Now()
+	if err := checkReviewable(approval, checkerID, now); err != nil {
+		return nil, err
+	}
+
+	actor := BalanceActor{RequestedBy: approval.RequestedBy, ApprovedBy: &checkerID, ApprovalID: &approval.ID}
+
+	var change *BalanceChange
+	var err error
+	if approval.Type == "adjustment" {
+		change, err = AdjustBalance(tx, approval.CustomerID, approval.Amount, approval.Notes, actor)
+	} else {
+		change, err = DeductBalance(tx, approval.CustomerID, approval.Amount, approval.Notes, actor)
+	}
+	if err != nil {
+		return change, err
+	}
+
+	approval.Status = "approved"
+	approval.ReviewedBy = &checkerID
+	approval.ReviewNote = note
+	approval.ReviewedAt = &now
+	approval.JournalEntryID = &change.Entry.ID
+	return change, tx.Save(approval).Error
+}
+
+// RejectBalanceChange - Checker menolak request, saldo tidak berubah
+func RejectBalanceChange(tx *gorm.DB, approval *models.BalanceApproval, checkerID uint, note string) error {
+	now := time.Now()
+	if err := checkReviewable(approval, checkerID, now); err != nil {
+		return err
+	}
+
+	approval.Status = "rejected"
+	approval.ReviewedBy = &checkerID
+	approval.ReviewNote = note
+	approval.ReviewedAt = &now
+	return tx.Save(approval).Error
+}
+
+// CancelBalanceChange - Maker membatalkan request miliknya yang masih pending
+func CancelBalanceChange(tx *gorm.DB, approval *models.BalanceApproval, userID uint) error {
+	if approval.Status != "pending" {
+		return ErrApprovalNotPending
+	}
+	if approval.RequestedBy != userID {
+		return ErrNotApprovalMaker
+	}
+
+	now := time.Now()
+	approval.Status = "cancelled"
+	approval.ReviewedAt = &now
+	return tx.Save(approval).Error
+}
+
+// ExpireApprovals - Tandai request pending yang sudah lewat batas waktu
+func ExpireApprovals(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Model(&models.BalanceApproval{}).
+		Where("status = ? AND expires_at <= ?", "pending", now).
+		Updates(map[string]interface{}{"status": "expired", "updated_at": now})
+	return result.RowsAffected, result.Error
+}
+
+// NotifyApprovers - Email ke semua finance/admin aktif selain maker
+func NotifyApprovers(cfg *config.Config, db *gorm.DB, approval models.BalanceApproval) {
+	var customer models.Customer
+	if err := db.First(&customer, approval.CustomerID).Error; err != nil {
+		log.Printf("⚠️ Approval %d: failed to load customer: %v", approval.ID, err)
+		return
+	}
+	var maker models.User
+	db.First(&maker, approval.RequestedBy)
+
+	var approvers []models.User
+	err := db.Where("role IN ? AND status = ? AND id <> ? AND anonymized_at IS NULL", []string{"finance", "admin"}, "active", approval.RequestedBy).
+		Find(&approvers).Error
+	if err != nil {
+		log.Printf("⚠️ Approval %d: failed to load approvers: %v", approval.ID, err)
+		return
+	}
+
+	reason := "Nominal di atas batas approval"
+	if approval.Reason == "overdraft" {
+		reason = "Melebihi credit limit customer"
+	}
+
+	for _, approver := range approvers {
+		data := utils.ApprovalRequestEmailData{
+			Name:         approver.Name,
+			ApprovalID:   approval.ID,
+			CustomerCode: customer.CustomerCode,
+			CompanyName:  customer.CompanyName,
+			Type:         approval.Type,
+			Amount:       utils.FormatRupiah(int64(approval.Amount)),
+			Reason:       reason,
+			RequestedBy:  maker.Name,
+			Notes:        approval.Notes,
+			ExpiresAt:    approval.ExpiresAt.Format("02 Jan 2006 15:04 MST"),
+			ReviewURL:    fmt.Sprintf("%s/billapi/v2/approvals/%d", cfg.Server.BaseURL, approval.ID),
+		}
+		if err := utils.SendApprovalRequestEmail(cfg, approver.Email, data); err != nil {
+			log.Printf("⚠️ Approval %d: failed to notify %s: %v", approval.ID, approver.Email, err)
+		}
+	}
+}
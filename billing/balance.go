@@ -0,0 +1,130 @@
+package billing
+
+import (
+	"auth-api/ledger"
+	"auth-api/models"
+	"auth-api/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BalanceActor - Maker dan checker sebuah perubahan saldo. ApprovedBy nil jika tidak melalui approval.
+type BalanceActor struct {
+	RequestedBy uint
+	ApprovedBy  *uint
+	ApprovalID  *uint
+}
+
+// BalanceChange - Hasil perubahan saldo customer
+type BalanceChange struct {
+	Customer   models.Customer
+	OldBalance models.Money
+	NewBalance models.Money
+	NetAmount  models.Money
+	TaxAmount  models.Money
+	TaxRateBps int
+	Credit     CreditCheck
+	Entry      *models.JournalEntry
+}
+
+func (a BalanceActor) historyFields(changes map[string]interface{}) map[string]interface{} {
+	if a.ApprovedBy != nil {
+		changes["requested_by"] = a.RequestedBy
+		changes["approved_by"] = *a.ApprovedBy
+		changes["approval_id"] = a.ApprovalID
+	}
+	return changes
+}
+
+// lockCustomer - Row customer dikunci (SELECT ... FOR UPDATE) sampai transaksi selesai
+func lockCustomer(tx *gorm.DB, customerID uint) (models.Customer, error) {
+	var customer models.Customer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, customerID).Error
+	return customer, err
+}
+
+// DeductBalance - Pemakaian saldo (nominal termasuk PPN). Journal entry, cache balance dan history
+// ditulis dalam transaksi yang sama. Overdraft di atas credit limit hanya lolos jika sudah di-approve.
+// Pada error credit limit, BalanceChange tetap dikembalikan agar pemanggil bisa menampilkan posisinya.
+func DeductBalance(tx *gorm.DB, customerID uint, amount models.Money, notes string, actor BalanceActor) (*BalanceChange, error) {
+	customer, err := lockCustomer(tx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Status == "terminated" {
+		return nil, ErrCustomerTerminated
+	}
+
+	change := &BalanceChange{Customer: customer, OldBalance: customer.Balance}
+	change.Credit, err = CheckDeduction(customer, amount, actor.ApprovedBy != nil)
+	if err != nil {
+		return change, err
+	}
+	change.NewBalance = change.Credit.NewBalance
+
+	change.TaxRateBps, err = CustomerTaxRateAt(tx, customer, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	change.NetAmount, change.TaxAmount = SplitInclusive(amount, change.TaxRateBps)
+
+	change.Entry, err = ledger.Deduct(tx, customer.ID, amount, change.TaxAmount, notes, actor.RequestedBy)
+	if err != nil {
+		return nil, err
+	}
+	change.Customer.Balance = change.NewBalance
+
+	history := models.CustomerHistory{
+		CustomerID: customer.ID,
+		Action:     "balance_update",
+		Changes: utils.ToJSON(actor.historyFields(map[string]interface{}{
+			"type":             "deduct",
+			"amount":           amount,
+			"net_amount":       change.NetAmount,
+			"tax_amount":       change.TaxAmount,
+			"tax_rate_bps":     change.TaxRateBps,
+			"old_balance":      change.OldBalance,
+			"new_balance":      change.NewBalance,
+			"credit_used":      change.Credit.CreditUsed,
+			"overdraft":        change.Credit.Overdraft,
+			"notes":            notes,
+			"journal_entry_id": change.Entry.ID,
+		})),
+		ChangedBy: actor.RequestedBy,
+		CreatedAt: time.Now(),
+	}
+	return change, tx.Create(&history).Error
+}
+
+// AdjustBalance - Koreksi saldo manual (delta positif menambah saldo) beserta history
+func AdjustBalance(tx *gorm.DB, customerID uint, delta models.Money, notes string, actor BalanceActor) (*BalanceChange, error) {
+	customer, err := lockCustomer(tx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &BalanceChange{Customer: customer, OldBalance: customer.Balance, NewBalance: customer.Balance + delta, NetAmount: delta}
+	change.Entry, err = ledger.Adjust(tx, customer.ID, delta, "adjustment", notes, actor.RequestedBy)
+	if err != nil {
+		return nil, err
+	}
+	change.Customer.Balance = change.NewBalance
+
+	history := models.CustomerHistory{
+		CustomerID: customer.ID,
+		Action:     "balance_update",
+		Changes: utils.ToJSON(actor.historyFields(map[string]interface{}{
+			"type":             "adjustment",
+			"amount":           delta,
+			"old_balance":      change.OldBalance,
+			"new_balance":      change.NewBalance,
+			"notes":            notes,
+			"journal_entry_id": change.Entry.ID,
+		})),
+		ChangedBy: actor.RequestedBy,
+		CreatedAt: time.Now(),
+	}
+	return change, tx.Create(&history).Error
+}
@@ -0,0 +1,192 @@
+package billing
+
+import (
+	"auth-api/config"
+	"auth-api/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Aksi operasi massal customer
+const (
+	BulkActionSetStatus = "set_status"
+	BulkActionReassign  = "reassign"
+	BulkActionAddTag    = "add_tag"
+	BulkActionDelete    = "delete"
+)
+
+var (
+	ErrBulkForbidden       = errors.New("forbidden")
+	ErrBulkCustomerMissing = errors.New("customer not found")
+	ErrBulkUnknownAction   = errors.New("unknown bulk action")
+)
+
+// BulkParams - Parameter aksi, disimpan sebagai JSON di CustomerBulkJob.Params
+type BulkParams struct {
+	Status string `json:"status,omitempty"`  // set_status
+	Reason string `json:"reason,omitempty"`  // set_status
+	UserID uint   `json:"user_id,omitempty"` // reassign
+	Tag    string `json:"tag,omitempty"`     // add_tag
+}
+
+// CheckBulkPermission - Aturan role per aksi, sama dengan UpdateCustomer dan DeleteCustomer
+func CheckBulkPermission(role, action string) error {
+	switch action {
+	case BulkActionSetStatus:
+		if role != "finance" && role != "admin" {
+			return fmt.Errorf("%w: only finance and admin can change customer status", ErrBulkForbidden)
+		}
+	case BulkActionReassign:
+		if role != "admin" {
+			return fmt.Errorf("%w: only admin can reassign customers", ErrBulkForbidden)
+		}
+	case BulkActionDelete:
+		if role != "admin" {
+			return fmt.Errorf("%w: only admin can delete customers", ErrBulkForbidden)
+		}
+	case BulkActionAddTag:
+		// Semua role, customer hanya untuk customer miliknya (dicek per item)
+	default:
+		return fmt.Errorf("%w: %s", ErrBulkUnknownAction, action)
+	}
+	return nil
+}
+
+// RunPendingBulkJobs - Jalankan job pending secara berurutan. Job running yang macet (worker mati)
+// dikembalikan ke pending dan dilanjutkan dari item yang belum diproses.
+func RunPendingBulkJobs(cfg *config.Config, db *gorm.DB) {
+	err := db.Model(&models.CustomerBulkJob{}).
+		Where("status = ? AND updated_at < ?", "running", time.Now().Add(-cfg.Bulk.StaleAfter)).
+		Update("status", "pending").Error
+	if err != nil {
+		log.Printf("⚠️ Bulk: failed to requeue stale jobs: %v", err)
+	}
+
+	var jobs []models.CustomerBulkJob
+	if err := db.Where("status = ?", "pending").Order("id ASC").Limit(10).Find(&jobs).Error; err != nil {
+		log.Printf("⚠️ Bulk: failed to fetch pending jobs: %v", err)
+		return
+	}
+
+	for i := range jobs {
+		if err := RunBulkJob(db, &jobs[i]); err != nil {
+			log.Printf("⚠️ Bulk job %d failed: %v", jobs[i].ID, err)
+		}
+	}
+}
+
+// RunBulkJob - Klaim job lalu proses item satu per satu, masing-masing dalam transaksinya sendiri
+func RunBulkJob(db *gorm.DB, job *models.CustomerBulkJob) error {
+	now := time.Now()
+	result := db.Model(&models.CustomerBulkJob{}).
+		Where("id = ? AND status = ?", job.ID, "pending").
+		Updates(map[string]interface{}{"status": "running", "started_at": gorm.Expr("COALESCE(started_at, ?)", now)})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	job.Status = "running"
+
+	var params BulkParams
+	if err := json.Unmarshal([]byte(job.Params), &params); err != nil {
+		db.Model(job).Updates(map[string]interface{}{"status": "failed", "error": err.Error()})
+		return err
+	}
+
+	for {
+		var items []models.CustomerBulkItem
+		if err := db.Where("job_id = ? AND status = ?", job.ID, "pending").Order("id ASC").Limit(100).Find(&items).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			break
+		}
+
+		for i := range items {
+			if err := runBulkItem(db, job, params, &items[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	completedAt := time.Now()
+	return db.Model(job).Updates(map[string]interface{}{"status": "completed", "completed_at": completedAt}).Error
+}
+
+// runBulkItem - Hasil item dan counter job disimpan dalam transaksi yang sama dengan perubahannya,
+// sehingga item tidak diproses dua kali jika job dilanjutkan.
+func runBulkItem(db *gorm.DB, job *models.CustomerBulkJob, params BulkParams, item *models.CustomerBulkItem) error {
+	var changed bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		changed, err = applyBulkAction(tx, job, params, item.CustomerID)
+		if err != nil {
+			return err
+		}
+		status, counter := "succeeded", "succeeded"
+		if !changed {
+			status, counter = "skipped", "skipped"
+		}
+		return finishBulkItem(tx, job, item, status, counter, "")
+	})
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	if len(message) > 255 {
+		message = message[:255]
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		return finishBulkItem(tx, job, item, "failed", "failed", message)
+	})
+}
+
+func finishBulkItem(tx *gorm.DB, job *models.CustomerBulkJob, item *models.CustomerBulkItem, status, counter, message string) error {
+	now := time.Now()
+	result := tx.Model(&models.CustomerBulkItem{}).
+		Where("id = ? AND status = ?", item.ID, "pending").
+		Updates(map[string]interface{}{"status": status, "error": message, "processed_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	return tx.Model(job).Update(counter, gorm.Expr(counter+" + 1")).Error
+}
+
+// applyBulkAction - Jalankan aksi untuk satu customer. false jika customer sudah dalam kondisi yang diminta.
+func applyBulkAction(tx *gorm.DB, job *models.CustomerBulkJob, params BulkParams, customerID uint) (bool, error) {
+	var customer models.Customer
+	if err := tx.First(&customer, customerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrBulkCustomerMissing
+		}
+		return false, err
+	}
+
+	// Customer hanya bisa mengubah customer miliknya sendiri
+	if job.RequestedRole == "customer" && customer.UserID != job.RequestedBy {
+		return false, fmt.Errorf("%w: you can only update your own customers", ErrBulkForbidden)
+	}
+
+	switch job.Action {
+	case BulkActionSetStatus:
+		if customer.Status == params.Status {
+			return false, nil
+		}
+		return true, ChangeCustomerStatus(tx, &customer, params.Status, params.Reason, StatusSourceManual, job.RequestedBy)
+	case BulkActionReassign:
+		return ReassignCustomer(tx, &customer, params.UserID, job.RequestedBy)
+	case BulkActionAddTag:
+		return AddCustomerTag(tx, customer.ID, params.Tag, job.RequestedBy)
+	case BulkActionDelete:
+		return true, DeleteCustomer(tx, &customer, job.RequestedBy)
+	}
+	return false, fmt.Errorf("%w: %s", ErrBulkUnknownAction, job.Action)
+}
@@ -0,0 +1,147 @@
+package billing
+
+import (
+	"auth-api/config"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kebijakan saat pemotongan saldo melebihi credit limit
+const (
+	OverdraftHardStop        = "hard_stop"
+	OverdraftRequireApproval = "require_approval"
+	OverdraftAllowWithAlert  = "allow_with_alert"
+)
+
+var (
+	ErrCreditLimitExceeded       = errors.New("insufficient balance and credit limit")
+	ErrOverdraftApprovalRequired = errors.New("deduction exceeds credit limit and requires approval")
+)
+
+// CreditCheck - Posisi customer jika pemotongan saldo diteruskan
+type CreditCheck struct {
+	NewBalance models.Money `json:"new_balance"`
+	CreditUsed models.Money `json:"credit_used"` // saldo negatif yang ditanggung credit line
+	Overdraft  models.Money `json:"overdraft"`   // bagian yang melebihi credit limit
+	Alert      bool         `json:"alert"`
+}
+
+// CheckDeduction - Saldo boleh turun sampai -CreditLimit. Selebihnya mengikuti OverdraftPolicy:
+// hard_stop ditolak, require_approval hanya jika sudah disetujui, allow_with_alert diteruskan dengan notifikasi.
+func CheckDeduction(customer models.Customer, amount models.Money, approved bool) (CreditCheck, error) {
+	check := CreditCheck{NewBalance: customer.Balance - amount}
+	if check.NewBalance < 0 {
+		check.CreditUsed = -check.NewBalance
+	}
+	if check.CreditUsed <= customer.CreditLimit {
+		return check, nil
+	}
+
+	check.Overdraft = check.CreditUsed - customer.CreditLimit
+	switch customer.OverdraftPolicy {
+	case OverdraftAllowWithAlert:
+		check.Alert = true
+		return check, nil
+	case OverdraftRequireApproval:
+		if approved {
+			return check, nil
+		}
+		return check, ErrOverdraftApprovalRequired
+	}
+	return check, ErrCreditLimitExceeded
+}
+
+// CreditUtilization - Persentase credit line yang terpakai (0 jika customer tidak punya credit limit)
+func CreditUtilization(used, limit models.Money) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return math.Round(float64(used)*10000/float64(limit)) / 100
+}
+
+func balanceAlertData(customer models.Customer) utils.BalanceAlertEmailData {
+	data := utils.BalanceAlertEmailData{
+		Name:         customer.ContactName,
+		CustomerCode: customer.CustomerCode,
+		CompanyName:  customer.CompanyName,
+		Balance:      utils.FormatRupiah(int64(customer.Balance)),
+		CreditLimit:  utils.FormatRupiah(int64(customer.CreditLimit)),
+	}
+	if data.Name == "" {
+		data.Name = customer.CompanyName
+	}
+	if customer.LowBalanceThreshold != nil {
+		data.Threshold = utils.FormatRupiah(int64(*customer.LowBalanceThreshold))
+	}
+	return data
+}
+
+// alertRecipients - Email customer (jika ada) dan salinan ke tim finance
+func alertRecipients(cfg *config.Config, customer models.Customer) []string {
+	var recipients []string
+	if customer.Email != "" {
+		recipients = append(recipients, customer.Email)
+	}
+	if cfg.Credit.AlertEmail != "" && cfg.Credit.AlertEmail != customer.Email {
+		recipients = append(recipients, cfg.Credit.AlertEmail)
+	}
+	return recipients
+}
+
+// NotifyOverdraft - Alert ke finance untuk pemotongan yang melewati credit limit (kebijakan allow_with_alert)
+func NotifyOverdraft(cfg *config.Config, customer models.Customer, check CreditCheck) {
+	if cfg.Credit.AlertEmail == "" {
+		return
+	}
+	customer.Balance = check.NewBalance
+	data := balanceAlertData(customer)
+	data.Name = "Tim Finance"
+	data.Overdraft = utils.FormatRupiah(int64(check.Overdraft))
+
+	if err := utils.SendOverdraftAlertEmail(cfg, cfg.Credit.AlertEmail, data); err != nil {
+		log.Printf("⚠️ Failed to send overdraft alert for customer %d: %v", customer.ID, err)
+	}
+}
+
+// CheckLowBalance - Kirim notifikasi sekali saat saldo turun di bawah low-water mark.
+// Penanda di-reset setelah saldo kembali di atas batas sehingga penurunan berikutnya diberi tahu lagi.
+func CheckLowBalance(cfg *config.Config, db *gorm.DB, customerID uint) error {
+	var customer models.Customer
+	if err := db.First(&customer, customerID).Error; err != nil {
+		return err
+	}
+
+	below := customer.LowBalanceThreshold != nil && customer.Balance < *customer.LowBalanceThreshold
+	if !below {
+		if customer.LowBalanceAlertedAt == nil {
+			return nil
+		}
+		return db.Model(&models.Customer{}).Where("id = ?", customer.ID).
+			UpdateColumn("low_balance_alerted_at", nil).Error
+	}
+	if customer.LowBalanceAlertedAt != nil {
+		return nil
+	}
+
+	// Klaim penanda dulu agar request dan worker tidak mengirim email yang sama
+	result := db.Model(&models.Customer{}).
+		Where("id = ? AND low_balance_alerted_at IS NULL", customer.ID).
+		UpdateColumn("low_balance_alerted_at", time.Now())
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	data := balanceAlertData(customer)
+	for _, to := range alertRecipients(cfg, customer) {
+		if err := utils.SendLowBalanceEmail(cfg, to, data); err != nil {
+			log.Printf("⚠️ Failed to send low balance alert for customer %d to %s: %v", customer.ID, to, err)
+		}
+	}
+	return nil
+}
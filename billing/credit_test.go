@@ -0,0 +1,49 @@
+package billing
+
+import (
+	"auth-api/models"
+	"errors"
+	"testing"
+)
+
+func TestCheckDeduction(t *testing.T) {
+	tests := []struct {
+		name      string
+		balance   models.Money
+		limit     models.Money
+		policy    string
+		amount    models.Money
+		approved  bool
+		err       error
+		used      models.Money
+		overdraft models.Money
+		alert     bool
+	}{
+		{"within balance", 5000, 0, OverdraftHardStop, 5000, false, nil, 0, 0, false},
+		{"exactly at credit limit", 0, 1000, OverdraftHardStop, 1000, false, nil, 1000, 0, false},
+		{"at limit needs no approval", 0, 1000, OverdraftRequireApproval, 1000, false, nil, 1000, 0, false},
+		{"at limit sends no alert", 0, 1000, OverdraftAllowWithAlert, 1000, false, nil, 1000, 0, false},
+		{"one cent over, hard stop", 0, 1000, OverdraftHardStop, 1001, false, ErrCreditLimitExceeded, 1001, 1, false},
+		{"one cent over, hard stop ignores approval", 0, 1000, OverdraftHardStop, 1001, true, ErrCreditLimitExceeded, 1001, 1, false},
+		{"one cent over, not approved", 0, 1000, OverdraftRequireApproval, 1001, false, ErrOverdraftApprovalRequired, 1001, 1, false},
+		{"one cent over, approved", 0, 1000, OverdraftRequireApproval, 1001, true, nil, 1001, 1, false},
+		{"one cent over, alert", 0, 1000, OverdraftAllowWithAlert, 1001, false, nil, 1001, 1, true},
+		{"no credit limit", 500, 0, OverdraftHardStop, 501, false, ErrCreditLimitExceeded, 1, 1, false},
+		{"already over limit", -1500, 1000, OverdraftAllowWithAlert, 100, false, nil, 1600, 600, true},
+		{"unknown policy is a hard stop", 0, 1000, "", 1001, true, ErrCreditLimitExceeded, 1001, 1, false},
+	}
+	for _, tt := range tests {
+		customer := models.Customer{Balance: tt.balance, CreditLimit: tt.limit, OverdraftPolicy: tt.policy}
+		check, err := CheckDeduction(customer, tt.amount, tt.approved)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+		}
+		if want := tt.balance - tt.amount; check.NewBalance != want {
+			t.Errorf("%s: NewBalance = %d, want %d", tt.name, check.NewBalance, want)
+		}
+		if check.CreditUsed != tt.used || check.Overdraft != tt.overdraft || check.Alert != tt.alert {
+			t.Errorf("%s: CreditUsed/Overdraft/Alert = %d/%d/%v, want %d/%d/%v", tt.name,
+				check.CreditUsed, check.Overdraft, check.Alert, tt.used, tt.overdraft, tt.alert)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package billing
+
+import (
+	"auth-api/models"
+	"auth-api/utils"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCustomerOwnerNotFound = errors.New("new owner not found")
+	ErrTagRequired           = errors.New("tag is required")
+	ErrCustomerConflict      = errors.New("customer has been modified by another request")
+	ErrCustomerNotDeleted    = errors.New("customer is not deleted")
+	ErrCustomerPurged        = errors.New("customer data has been purged and cannot be restored")
+)
+
+// piiKeys - Field berisi data pribadi, dihapus dari customer dan history saat purge
+var piiKeys = map[string]bool{
+	"company_name":      true,
+	"contact_name":      true,
+	"email":             true,
+	"phone":             true,
+	"address":           true,
+	"npwp":              true,
+	"tax_exempt_reason": true,
+}
+
+// DeleteCustomer - Soft delete customer dan catat history "delete". Data keuangan tetap utuh
+// dan customer masih bisa di-restore sampai di-purge.
+func DeleteCustomer(tx *gorm.DB, customer *models.Customer, userID uint) error {
+	history := models.CustomerHistory{
+		CustomerID: customer.ID,
+		Action:     "delete",
+		Changes: utils.ToJSON(map[string]interface{}{
+			"customer_code": customer.CustomerCode,
+			"company_name":  customer.CompanyName,
+		}),
+		ChangedBy: userID,
+		CreatedAt: time.Now(),
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+	return tx.Delete(customer).Error
+}
+
+// RestoreCustomer - Kembalikan customer yang di-soft delete dan belum di-purge
+func RestoreCustomer(tx *gorm.DB, customer *models.Customer, userID uint) error {
+	if !customer.DeletedAt.Valid {
+		return ErrCustomerNotDeleted
+	}
+	if customer.PurgedAt != nil {
+		return ErrCustomerPurged
+	}
+
+	deletedAt := customer.DeletedAt.Time
+	result := tx.Unscoped().Model(&models.Customer{}).
+		Where("id = ? AND version = ? AND deleted_at IS NOT NULL", customer.ID, customer.Version).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCustomerConflict
+	}
+	customer.DeletedAt = gorm.DeletedAt{}
+	customer.Version++
+
+	history := models.CustomerHistory{
+		CustomerID: customer.ID,
+		Action:     "restore",
+		Changes: utils.ToJSON(map[string]interface{}{
+			"customer_code": customer.CustomerCode,
+			"deleted_at":    deletedAt,
+		}),
+		ChangedBy: userID,
+		CreatedAt: time.Now(),
+	}
+	return tx.Create(&history).Error
+}
+
+// PurgeCustomer - Anonimkan PII customer yang sudah dihapus, termasuk yang tercatat di history.
+// customer_code, saldo, ledger, invoice dan payment tidak diubah agar riwayat keuangan tetap lengkap.
+func PurgeCustomer(tx *gorm.DB, customer *models.Customer, now time.Time) error {
+	result := tx.Unscoped().Model(&models.Customer{}).
+		Where("id = ? AND deleted_at IS NOT NULL AND purged_at IS NULL", customer.ID).
+		Updates(map[string]interface{}{
+			"company_name":      "Deleted Customer",
+			"contact_name":      "",
+			"email":             "",
+			"phone":             "",
+			"address":           "",
+			"npwp":              "",
+			"tax_exempt_reason": "",
+			"purged_at":         now,
+			"version":           gorm.Expr("version + 1"),
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	var history []models.CustomerHistory
+	if err := tx.Where("customer_id = ?", customer.ID).Find(&history).Error; err != nil {
+		return err
+	}
+	for _, h := range history {
+		if h.Changes == "" {
+			continue
+		}
+		var changes interface{}
+		if err := json.Unmarshal([]byte(h.Changes), &changes); err != nil {
+			continue
+		}
+		if !redactPII(changes) {
+			continue
+		}
+		if err := tx.Model(&models.CustomerHistory{}).Where("id = ?", h.ID).Update("changes", utils.ToJSON(changes)).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("customer_id = ?", customer.ID).Delete(&models.CustomerTag{}).Error; err != nil {
+		return err
+	}
+
+	purge := models.CustomerHistory{
+		CustomerID: customer.ID,
+		Action:     "purge",
+		Changes:    utils.ToJSON(map[string]interface{}{"customer_code": customer.CustomerCode, "reason": "retention"}),
+		ChangedBy:  0,
+		CreatedAt:  now,
+	}
+	return tx.Create(&purge).Error
+}
+
+// redactPII - Ganti nilai field PII (di level manapun, mis. old/new) dengan "[redacted]". true jika ada yang diganti.
+func redactPII(value interface{}) bool {
+	redacted := false
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if piiKeys[key] {
+				if inner != nil && inner != "" {
+					v[key] = "[redacted]"
+					redacted = true
+				}
+				continue
+			}
+			if redactPII(inner) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, inner := range v {
+			if redactPII(inner) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+// ReassignCustomer - Pindahkan kepemilikan customer ke user lain. false jika owner sudah sama.
+func ReassignCustomer(tx *gorm.DB, customer *models.Customer, newUserID, userID uint) (bool, error) {
+	if customer.UserID == newUserID {
+		return false, nil
+	}
+
+	var owner models.User
+	if err := tx.Select("id").Where("id = ? AND anonymized_at IS NULL", newUserID).First(&owner).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrCustomerOwnerNotFound
+		}
+		return false, err
+	}
+
+	result := tx.Model(&models.Customer{}).
+		Where("id = ? AND version = ?", customer.ID, customer.Version).
+		Updates(map[string]interface{}{"user_id": newUserID, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, ErrCustomerConflict
+	}
+
+	changes := map[string]interface{}{
+		"old": map[string]interface{}{"user_id": customer.UserID},
+		"new": map[string]interface{}{"user_id": newUserID},
+	}
+	customer.UserID = newUserID
+	customer.Version++
+
+	history := models.CustomerHistory{
+		CustomerID: customer.ID,
+		Action:     "update",
+		Changes:    utils.ToJSON(changes),
+		ChangedBy:  userID,
+		CreatedAt:  time.Now(),
+	}
+	return true, tx.Create(&history).Error
+}
+
+// NormalizeTag - Tag disimpan huruf kecil tanpa spasi di ujung
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// CustomerTags - Tag milik customer, urut abjad
+func CustomerTags(tx *gorm.DB, customerID uint) ([]string, error) {
+	tags := []string{}
+	err := tx.Model(&models.CustomerTag{}).Where("customer_id = ?", customerID).Order("tag").Pluck("tag", &tags).Error
+	return tags, err
+}
+
+// AddCustomerTag - Tambah tag ke customer. false jika tag sudah ada.
+func AddCustomerTag(tx *gorm.DB, customerID uint, tag string, userID uint) (bool, error) {
+	tag = NormalizeTag(tag)
+	if tag == "" {
+		return false, ErrTagRequired
+	}
+
+	oldTags, err := CustomerTags(tx, customerID)
+	if err != nil {
+		return false, err
+	}
+	for _, existing := range oldTags {
+		if existing == tag {
+			return false, nil
+		}
+	}
+
+	if err := tx.Create(&models.CustomerTag{CustomerID: customerID, Tag: tag, CreatedBy: userID, CreatedAt: time.Now()}).Error; err != nil {
+		return false, err
+	}
+	newTags, err := CustomerTags(tx, customerID)
+	if err != nil {
+		return false, err
+	}
+
+	changes := map[string]interface{}{
+		"old": map[string]interface{}{"tags": oldTags},
+		"new": map[string]interface{}{"tags": newTags},
+	}
+	history := models.CustomerHistory{
+		CustomerID: customerID,
+		Action:     "update",
+		Changes:    utils.ToJSON(changes),
+		ChangedBy:  userID,
+		CreatedAt:  time.Now(),
+	}
+	return true, tx.Create(&history).Error
+}
@@ -0,0 +1,344 @@
+package billing
+
+import (
+	"auth-api/config"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDunningPauseReasonRequired = errors.New("reason is required to pause dunning")
+	ErrDunningNotPaused           = errors.New("dunning is not paused for this customer")
+)
+
+// DunningNotice - Tahap dunning yang baru dijalankan, emailnya dikirim setelah transaksi commit
+type DunningNotice struct {
+	Customer    models.Customer
+	Step        config.DunningStep
+	DaysOverdue int
+	AmountDue   models.Money
+	Overdraft   models.Money
+	Invoices    []models.Invoice
+	Suspended   bool
+}
+
+// dunningPosition - Tunggakan customer saat ini: invoice issued yang lewat jatuh tempo dan saldo di bawah credit limit
+type dunningPosition struct {
+	Since     *time.Time
+	AmountDue models.Money
+	Overdraft models.Money
+	Invoices  []models.Invoice
+}
+
+// DaysOverdue - Hari sejak tunggakan pertama dimulai
+func DaysOverdue(since, now time.Time) int {
+	days := int(startOfDay(now).Sub(startOfDay(since)).Hours() / 24)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// NextDunningStep - Tahap berikutnya setelah stage, nil jika semua tahap sudah dijalankan
+func NextDunningStep(cfg *config.Config, stage int) *config.DunningStep {
+	if stage < 0 || stage >= len(cfg.Dunning.Steps) {
+		return nil
+	}
+	return &cfg.Dunning.Steps[stage]
+}
+
+// dueStage - Jumlah tahap yang sudah jatuh waktu untuk tunggakan berumur days hari
+func dueStage(cfg *config.Config, days int) int {
+	stage := 0
+	for i, step := range cfg.Dunning.Steps {
+		if step.AfterDays <= days {
+			stage = i + 1
+		}
+	}
+	return stage
+}
+
+// dunningPausedAt - Apakah dunning customer sedang di-pause (mis. dispute)
+func dunningPausedAt(tx *gorm.DB, customerID uint, now time.Time) (bool, error) {
+	var state models.DunningState
+	err := tx.Where("customer_id = ?", customerID).Take(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return state.IsPausedAt(now), nil
+}
+
+func findDunningState(tx *gorm.DB, customerID uint) (models.DunningState, bool, error) {
+	state := models.DunningState{CustomerID: customerID}
+	err := tx.Where("customer_id = ?", customerID).Take(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return state, false, nil
+	}
+	return state, err == nil, err
+}
+
+// saveDunningState - Upsert, state belum tentu sudah ada di database
+func saveDunningState(tx *gorm.DB, state *models.DunningState) error {
+	return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(state).Error
+}
+
+func loadDunningPosition(tx *gorm.DB, customer models.Customer, state *models.DunningState, now time.Time) (dunningPosition, error) {
+	var position dunningPosition
+	err := tx.Where("customer_id = ? AND status = ? AND due_date < ?", customer.ID, "issued", startOfDay(now)).
+		Order("due_date ASC").Find(&position.Invoices).Error
+	if err != nil {
+		return position, err
+	}
+	for _, invoice := range position.Invoices {
+		position.AmountDue += invoice.OutstandingAmount()
+	}
+	if len(position.Invoices) > 0 {
+		position.Since = &position.Invoices[0].DueDate
+	}
+
+	// Saldo di bawah credit limit dihitung sejak pertama kali terlihat oleh worker
+	if customer.Balance < -customer.CreditLimit {
+		position.Overdraft = -customer.Balance - customer.CreditLimit
+		position.AmountDue += position.Overdraft
+		if state.OverdrawnSince == nil {
+			state.OverdrawnSince = &now
+		}
+		if position.Since == nil || state.OverdrawnSince.Before(*position.Since) {
+			position.Since = state.OverdrawnSince
+		}
+	} else {
+		state.OverdrawnSince = nil
+	}
+	return position, nil
+}
+
+func dunningHistory(tx *gorm.DB, customerID uint, changes map[string]interface{}, userID uint) error {
+	history := models.CustomerHistory{
+		CustomerID: customerID,
+		Action:     "dunning",
+		Changes:    utils.ToJSON(changes),
+		ChangedBy:  userID,
+		CreatedAt:  time.Now(),
+	}
+	return tx.Create(&history).Error
+}
+
+// RunDunning - Evaluasi satu customer terhadap jadwal dunning. Hanya tahap tertinggi yang sudah jatuh waktu
+// yang dijalankan, sehingga customer yang terlambat masuk jadwal tidak menerima beberapa email sekaligus.
+// Mengembalikan notice jika ada tahap baru yang harus dikirim ke customer.
+func RunDunning(tx *gorm.DB, cfg *config.Config, customerID uint, now time.Time) (*DunningNotice, error) {
+	customer, err := lockCustomer(tx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	state, exists, err := findDunningState(tx, customer.ID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Status == "terminated" {
+		if exists {
+			return nil, tx.Delete(&state).Error
+		}
+		return nil, nil
+	}
+
+	position, err := loadDunningPosition(tx, customer, &state, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tunggakan lunas: jadwal di-reset, state yang di-pause tetap disimpan sampai di-resume
+	if position.Since == nil {
+		if !exists {
+			return nil, nil
+		}
+		if state.Stage > 0 {
+			err := dunningHistory(tx, customer.ID, map[string]interface{}{
+				"event":      "cleared",
+				"last_stage": state.StageName,
+			}, 0)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !state.Paused {
+			return nil, tx.Delete(&state).Error
+		}
+		state.Stage = 0
+		state.StageName = ""
+		state.OverdueSince = nil
+		state.AmountDue = 0
+		return nil, saveDunningState(tx, &state)
+	}
+
+	state.OverdueSince = position.Since
+	state.AmountDue = position.AmountDue
+
+	if state.Paused && !state.IsPausedAt(now) {
+		state.Paused = false
+		state.PauseReason = ""
+		state.PausedUntil = nil
+		state.PausedBy = nil
+		if err := dunningHistory(tx, customer.ID, map[string]interface{}{"event": "resumed", "reason": "Masa pause berakhir"}, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	days := DaysOverdue(*position.Since, now)
+	target := dueStage(cfg, days)
+	if state.Paused || target <= state.Stage {
+		return nil, saveDunningState(tx, &state)
+	}
+
+	step := cfg.Dunning.Steps[target-1]
+	notice := &DunningNotice{
+		Customer:    customer,
+		Step:        step,
+		DaysOverdue: days,
+		AmountDue:   position.AmountDue,
+		Overdraft:   position.Overdraft,
+		Invoices:    position.Invoices,
+	}
+	if step.Suspend && customer.Status == "active" {
+		reason := "Dunning " + step.Name + ": tunggakan " + utils.FormatRupiah(int64(position.AmountDue)) + " belum dibayar"
+		if err := ChangeCustomerStatus(tx, &notice.Customer, "suspended", reason, StatusSourceDunning, 0); err != nil {
+			return nil, err
+		}
+		notice.Suspended = true
+	}
+
+	skipped := []string{}
+	for _, s := range cfg.Dunning.Steps[state.Stage : target-1] {
+		skipped = append(skipped, s.Name)
+	}
+
+	state.Stage = target
+	state.StageName = step.Name
+	state.LastStepAt = &now
+	if err := saveDunningState(tx, &state); err != nil {
+		return nil, err
+	}
+
+	return notice, dunningHistory(tx, customer.ID, map[string]interface{}{
+		"event":         "step",
+		"step":          step.Name,
+		"stage":         target,
+		"days_overdue":  days,
+		"amount_due":    position.AmountDue,
+		"overdraft":     position.Overdraft,
+		"invoice_count": len(position.Invoices),
+		"skipped_steps": skipped,
+		"suspended":     notice.Suspended,
+		"email_to":      customer.Email,
+	}, 0)
+}
+
+// SendDunningNotice - Email tahap dunning ke customer
+func SendDunningNotice(cfg *config.Config, notice *DunningNotice) {
+	customer := notice.Customer
+	if customer.Email == "" {
+		log.Printf("⚠️ Dunning: customer %d has no email, %s notice not sent", customer.ID, notice.Step.Name)
+		return
+	}
+
+	data := utils.DunningEmailData{
+		Name:         customer.ContactName,
+		Step:         notice.Step.Name,
+		CustomerCode: customer.CustomerCode,
+		CompanyName:  customer.CompanyName,
+		DaysOverdue:  notice.DaysOverdue,
+		AmountDue:    utils.FormatRupiah(int64(notice.AmountDue)),
+		Sender:       cfg.Company.Email,
+	}
+	if data.Name == "" {
+		data.Name = customer.CompanyName
+	}
+	if notice.Overdraft > 0 {
+		data.Overdraft = utils.FormatRupiah(int64(notice.Overdraft))
+	}
+	for _, invoice := range notice.Invoices {
+		line := utils.DunningInvoiceLine{
+			DueDate:     invoice.DueDate.Format("02 Jan 2006"),
+			Outstanding: utils.FormatRupiah(int64(invoice.OutstandingAmount())),
+		}
+		if invoice.InvoiceNumber != nil {
+			line.Number = *invoice.InvoiceNumber
+		}
+		data.Invoices = append(data.Invoices, line)
+	}
+
+	if err := utils.SendDunningEmail(cfg, customer.Email, data); err != nil {
+		log.Printf("⚠️ Dunning: failed to send %s notice to customer %d: %v", notice.Step.Name, customer.ID, err)
+	}
+}
+
+// PauseDunning - Hentikan eskalasi untuk customer, mis. selama tagihan sedang di-dispute.
+// until nil berarti pause berlaku sampai di-resume manual.
+func PauseDunning(tx *gorm.DB, customerID uint, reason string, until *time.Time, userID uint) (*models.DunningState, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrDunningPauseReasonRequired
+	}
+	customer, err := lockCustomer(tx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if customer.Status == "terminated" {
+		return nil, ErrCustomerTerminated
+	}
+	state, _, err := findDunningState(tx, customer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	state.Paused = true
+	state.PauseReason = reason
+	state.PausedUntil = until
+	state.PausedBy = &userID
+	if err := saveDunningState(tx, &state); err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{"event": "paused", "reason": reason, "stage": state.StageName}
+	if until != nil {
+		changes["until"] = until.Format("2006-01-02")
+	}
+	return &state, dunningHistory(tx, customer.ID, changes, userID)
+}
+
+// ResumeDunning - Lanjutkan jadwal dunning. Tahap yang jatuh waktu selama pause dijalankan pada putaran worker berikutnya.
+func ResumeDunning(tx *gorm.DB, customerID uint, userID uint) (*models.DunningState, error) {
+	if _, err := lockCustomer(tx, customerID); err != nil {
+		return nil, err
+	}
+	state, exists, err := findDunningState(tx, customerID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists || !state.Paused {
+		return nil, ErrDunningNotPaused
+	}
+
+	reason := state.PauseReason
+	state.Paused = false
+	state.PauseReason = ""
+	state.PausedUntil = nil
+	state.PausedBy = nil
+	if err := saveDunningState(tx, &state); err != nil {
+		return nil, err
+	}
+	return &state, dunningHistory(tx, customerID, map[string]interface{}{
+		"event":        "resumed",
+		"pause_reason": reason,
+	}, userID)
+}
@@ -0,0 +1,198 @@
+package billing
+
+import (
+	"auth-api/ledger"
+	"auth-api/models"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvoiceNotDraft    = errors.New("only draft invoices can be modified")
+	ErrInvoiceNotIssued   = errors.New("only issued invoices can be paid")
+	ErrInvoiceNotVoidable = errors.New("only draft or issued invoices without payments can be voided")
+	ErrInvoiceEmpty       = errors.New("invoice must have at least one line")
+	ErrAmountTooLarge     = errors.New("line amount or invoice total exceeds the maximum amount")
+)
+
+// LineInput adalah data mentah satu item invoice sebelum dihitung
+type LineInput struct {
+	Description string
+	Quantity    float64
+	UnitPrice   models.Money
+	TaxRateBps  int
+}
+
+// quantityScale - Quantity disimpan decimal(15,4), dihitung sebagai integer 1/10000
+const quantityScale = 10000
+
+// maxQuantity - Nilai terbesar kolom decimal(15,4)
+const maxQuantity = 99999999999.9999
+
+// BuildLines - Hitung amount dan pajak per line (dibulatkan ke sen per line) serta total invoice.
+// Gagal jika quantity, amount line atau total tidak muat di kolom decimal.
+func BuildLines(inputs []LineInput) (lines []models.InvoiceLine, subtotal, taxTotal models.Money, err error) {
+	for _, input := range inputs {
+		if input.Quantity > maxQuantity {
+			return nil, 0, 0, ErrAmountTooLarge
+		}
+		qty := int64(math.Round(input.Quantity * quantityScale))
+		amount, ok := input.UnitPrice.MulRatioChecked(qty, quantityScale)
+		if !ok {
+			return nil, 0, 0, ErrAmountTooLarge
+		}
+		tax, ok := amount.MulRatioChecked(int64(input.TaxRateBps), 10000)
+		if !ok {
+			return nil, 0, 0, ErrAmountTooLarge
+		}
+
+		lines = append(lines, models.InvoiceLine{
+			Description: input.Description,
+			Quantity:    float64(qty) / quantityScale,
+			UnitPrice:   input.UnitPrice,
+			Amount:      amount,
+			TaxRateBps:  input.TaxRateBps,
+			TaxAmount:   tax,
+		})
+		// Tiap nilai sudah <= MaxMoney, jadi penjumlahan di bawah tidak bisa overflow int64
+		subtotal += amount
+		taxTotal += tax
+		if subtotal+taxTotal > models.MaxMoney {
+			return nil, 0, 0, ErrAmountTooLarge
+		}
+	}
+	return lines, subtotal, taxTotal, nil
+}
+
+// ApplyLines - Ganti line invoice dan hitung ulang totalnya
+func ApplyLines(invoice *models.Invoice, inputs []LineInput) error {
+	if len(inputs) == 0 {
+		return ErrInvoiceEmpty
+	}
+	lines, subtotal, taxTotal, err := BuildLines(inputs)
+	if err != nil {
+		return err
+	}
+	invoice.Lines = lines
+	invoice.Subtotal = subtotal
+	invoice.TaxTotal = taxTotal
+	invoice.Total = subtotal + taxTotal
+	return nil
+}
+
+// NextInvoiceNumber - Nomor berurutan per tahun, mis. INV/2026/000001.
+// Row sequence dikunci sehingga aman dipanggil paralel di dalam transaksi.
+func NextInvoiceNumber(tx *gorm.DB, prefix string, year int) (string, error) {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.InvoiceSequence{Year: year}).Error; err != nil {
+		return "", err
+	}
+
+	var seq models.InvoiceSequence
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seq, "year = ?", year).Error; err != nil {
+		return "", err
+	}
+
+	seq.LastNumber++
+	if err := tx.Model(&models.InvoiceSequence{}).Where("year = ?", year).Update("last_number", seq.LastNumber).Error; err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%d/%06d", prefix, year, seq.LastNumber), nil
+}
+
+// Issue - Beri nomor invoice lalu catat piutang: debit piutang, credit pendapatan dan PPN
+func Issue(tx *gorm.DB, invoice *models.Invoice, prefix string, userID uint) error {
+	if invoice.Status != "draft" {
+		return ErrInvoiceNotDraft
+	}
+	if len(invoice.Lines) == 0 {
+		return ErrInvoiceEmpty
+	}
+
+	now := time.Now()
+	number, err := NextInvoiceNumber(tx, prefix, now.Year())
+	if err != nil {
+		return err
+	}
+
+	if invoice.Total > 0 {
+		customerID := invoice.CustomerID
+		entry := &models.JournalEntry{
+			CustomerID:  invoice.CustomerID,
+			Type:        "invoice",
+			Description: fmt.Sprintf("Invoice %s issued", number),
+			Reference:   number,
+			CreatedBy:   userID,
+			Lines: []models.JournalLine{
+				{Account: ledger.AccountReceivable, CustomerID: &customerID, Debit: invoice.Total},
+				{Account: ledger.AccountRevenue, Credit: invoice.Subtotal},
+			},
+		}
+		if invoice.TaxTotal > 0 {
+			entry.Lines = append(entry.Lines, models.JournalLine{Account: ledger.AccountTaxPayable, Credit: invoice.TaxTotal})
+		}
+		if err := ledger.Post(tx, entry); err != nil {
+			return err
+		}
+	}
+
+	invoice.InvoiceNumber = &number
+	invoice.Status = "issued"
+	invoice.IssueDate = &now
+	invoice.IssuedAt = &now
+	invoice.IssuedBy = &userID
+
+	return tx.Model(invoice).Select("invoice_number", "status", "issue_date", "issued_at", "issued_by").Updates(invoice).Error
+}
+
+// Void - Batalkan invoice. Invoice issued dibalik jurnalnya, invoice yang sudah dibayar tidak bisa di-void.
+func Void(tx *gorm.DB, invoice *models.Invoice, reason string, userID uint) error {
+	if (invoice.Status != "draft" && invoice.Status != "issued") || invoice.AmountPaid > 0 {
+		return ErrInvoiceNotVoidable
+	}
+
+	if invoice.Status == "issued" && invoice.Total > 0 {
+		customerID := invoice.CustomerID
+		entry := &models.JournalEntry{
+			CustomerID:  invoice.CustomerID,
+			Type:        "invoice_void",
+			Description: fmt.Sprintf("Invoice %s voided: %s", *invoice.InvoiceNumber, reason),
+			Reference:   *invoice.InvoiceNumber,
+			CreatedBy:   userID,
+			Lines: []models.JournalLine{
+				{Account: ledger.AccountRevenue, Debit: invoice.Subtotal},
+				{Account: ledger.AccountReceivable, CustomerID: &customerID, Credit: invoice.Total},
+			},
+		}
+		if invoice.TaxTotal > 0 {
+			entry.Lines = append(entry.Lines, models.JournalLine{Account: ledger.AccountTaxPayable, Debit: invoice.TaxTotal})
+		}
+		if err := ledger.Post(tx, entry); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	invoice.Status = "void"
+	invoice.VoidReason = reason
+	invoice.VoidedAt = &now
+	invoice.VoidedBy = &userID
+
+	return tx.Model(invoice).Select("status", "void_reason", "voided_at", "voided_by").Updates(invoice).Error
+}
+
+// MarkPaid - Tambahkan pembayaran ke invoice, status jadi paid jika sudah lunas
+func MarkPaid(tx *gorm.DB, invoice *models.Invoice, amount models.Money) error {
+	invoice.AmountPaid += amount
+	if invoice.AmountPaid >= invoice.Total {
+		now := time.Now()
+		invoice.Status = "paid"
+		invoice.PaidAt = &now
+	}
+	return tx.Model(invoice).Select("amount_paid", "status", "paid_at").Updates(invoice).Error
+}
@@ -0,0 +1,39 @@
+package billing
+
+import (
+	"auth-api/models"
+	"errors"
+	"testing"
+)
+
+func TestBuildLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		inputs   []LineInput
+		subtotal models.Money
+		taxTotal models.Money
+		err      error
+	}{
+		{"PPN rounded per line", []LineInput{{"A", 1, 50, 1100}, {"B", 1, 50, 1100}}, 100, 12, nil},
+		{"fractional quantity", []LineInput{{"Usage", 1.5, 333, 0}}, 500, 0, nil},
+		{"max quantity", []LineInput{{"A", maxQuantity, 1, 0}}, 100000000000, 0, nil},
+		{"quantity over decimal(15,4)", []LineInput{{"A", maxQuantity * 10, 1, 0}}, 0, 0, ErrAmountTooLarge},
+		{"line amount overflows", []LineInput{{"A", 99999999999, models.MaxMoney, 0}}, 0, 0, ErrAmountTooLarge},
+		{"tax pushes total over max", []LineInput{{"A", 1, models.MaxMoney, 1100}}, 0, 0, ErrAmountTooLarge},
+		{"sum of lines over max", []LineInput{{"A", 1, models.MaxMoney, 0}, {"B", 1, 1, 0}}, 0, 0, ErrAmountTooLarge},
+	}
+	for _, tt := range tests {
+		lines, subtotal, taxTotal, err := BuildLines(tt.inputs)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(lines) != len(tt.inputs) || subtotal != tt.subtotal || taxTotal != tt.taxTotal {
+			t.Errorf("%s: %d lines, subtotal %d, tax %d, want %d lines, subtotal %d, tax %d",
+				tt.name, len(lines), subtotal, taxTotal, len(tt.inputs), tt.subtotal, tt.taxTotal)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package billing
+
+import (
+	"auth-api/ledger"
+	"auth-api/models"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAllocationExceedsPayment = errors.New("allocations exceed the available payment amount")
+	ErrAllocationExceedsInvoice = errors.New("allocation exceeds the invoice amount due")
+	ErrInvoiceCustomerMismatch  = errors.New("invoice belongs to a different customer")
+	ErrNothingToAllocate        = errors.New("payment has no unapplied credit to allocate")
+)
+
+// AllocationInput - Permintaan alokasi sejumlah uang ke satu invoice
+type AllocationInput struct {
+	InvoiceID uint
+	Amount    models.Money
+}
+
+type allocation struct {
+	invoice *models.Invoice
+	amount  models.Money
+}
+
+// PaymentReference - Reference journal entry untuk payment
+func PaymentReference(payment *models.Payment) string {
+	return fmt.Sprintf("PAY-%06d", payment.ID)
+}
+
+// resolveAllocations - Kunci invoice tujuan dan validasi alokasi. Jika auto, sisa uang
+// dialokasikan ke invoice issued dengan due date paling lama terlebih dahulu.
+func resolveAllocations(tx *gorm.DB, customerID uint, available models.Money, inputs []AllocationInput, auto bool) ([]allocation, models.Money, error) {
+	var allocations []allocation
+	var total models.Money
+
+	if auto {
+		var invoices []models.Invoice
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("customer_id = ? AND status = ?", customerID, "issued").
+			Order("due_date ASC, id ASC").
+			Find(&invoices).Error
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for i := range invoices {
+			remaining := available - total
+			if remaining <= 0 {
+				break
+			}
+			amount := invoices[i].OutstandingAmount()
+			if amount > remaining {
+				amount = remaining
+			}
+			if amount <= 0 {
+				continue
+			}
+			allocations = append(allocations, allocation{invoice: &invoices[i], amount: amount})
+			total += amount
+		}
+		return allocations, total, nil
+	}
+
+	invoices := make(map[uint]*models.Invoice)
+	pending := make(map[uint]models.Money)
+	for _, input := range inputs {
+		invoice, ok := invoices[input.InvoiceID]
+		if !ok {
+			invoice = &models.Invoice{}
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(invoice, input.InvoiceID).Error; err != nil {
+				return nil, 0, err
+			}
+			invoices[input.InvoiceID] = invoice
+		}
+
+		if invoice.CustomerID != customerID {
+			return nil, 0, ErrInvoiceCustomerMismatch
+		}
+		if invoice.Status != "issued" {
+			return nil, 0, ErrInvoiceNotIssued
+		}
+
+		pending[invoice.ID] += input.Amount
+		if pending[invoice.ID] > invoice.OutstandingAmount() {
+			return nil, 0, ErrAllocationExceedsInvoice
+		}
+
+		allocations = append(allocations, allocation{invoice: invoice, amount: input.Amount})
+		total += input.Amount
+	}
+
+	if total > available {
+		return nil, 0, ErrAllocationExceedsPayment
+	}
+	return allocations, total, nil
+}
+
+// receivableLines - Line credit piutang per invoice yang dilunasi
+func receivableLines(customerID uint, allocations []allocation) []models.JournalLine {
+	var lines []models.JournalLine
+	for _, a := range allocations {
+		lines = append(lines, models.JournalLine{Account: ledger.AccountReceivable, CustomerID: &customerID, Credit: a.amount})
+	}
+	return lines
+}
+
+func applyAllocations(tx *gorm.DB, payment *models.Payment, allocations []allocation, entryID, userID uint) error {
+	for _, a := range allocations {
+		record := models.PaymentAllocation{
+			PaymentID:      payment.ID,
+			InvoiceID:      a.invoice.ID,
+			Amount:         a.amount,
+			JournalEntryID: entryID,
+			CreatedBy:      userID,
+			CreatedAt:      time.Now(),
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		payment.Allocations = append(payment.Allocations, record)
+
+		if err := MarkPaid(tx, a.invoice, a.amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordPayment - Catat uang masuk: debit kas, credit piutang untuk bagian yang dialokasikan
+// dan credit saldo customer untuk sisanya (unapplied credit).
+func RecordPayment(tx *gorm.DB, payment *models.Payment, inputs []AllocationInput, auto bool, userID uint) (*models.JournalEntry, error) {
+	allocations, allocated, err := resolveAllocations(tx, payment.CustomerID, payment.Amount, inputs, auto)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.UnappliedAmount = payment.Amount - allocated
+	payment.CreatedBy = userID
+	if err := tx.Create(payment).Error; err != nil {
+		return nil, err
+	}
+
+	customerID := payment.CustomerID
+	lines := []models.JournalLine{{Account: ledger.AccountCash, Debit: payment.Amount}}
+	lines = append(lines, receivableLines(customerID, allocations)...)
+	if payment.UnappliedAmount > 0 {
+		lines = append(lines, models.JournalLine{Account: ledger.AccountCustomerBalance, CustomerID: &customerID, Credit: payment.UnappliedAmount})
+	}
+
+	entry := &models.JournalEntry{
+		CustomerID:  customerID,
+		Type:        "payment",
+		Description: fmt.Sprintf("Payment via %s %s", payment.Method, payment.Reference),
+		Reference:   PaymentReference(payment),
+		CreatedBy:   userID,
+		Lines:       lines,
+	}
+	if err := ledger.Post(tx, entry); err != nil {
+		return nil, err
+	}
+
+	payment.JournalEntryID = entry.ID
+	if err := tx.Model(payment).Update("journal_entry_id", entry.ID).Error; err != nil {
+		return nil, err
+	}
+
+	return entry, applyAllocations(tx, payment, allocations, entry.ID, userID)
+}
+
+// AllocatePayment - Pakai sisa kredit sebuah payment untuk melunasi invoice: debit saldo customer, credit piutang.
+// Payment dan customer harus sudah dikunci oleh caller.
+func AllocatePayment(tx *gorm.DB, payment *models.Payment, customer *models.Customer, inputs []AllocationInput, auto bool, userID uint) (*models.JournalEntry, error) {
+	// Kredit bisa saja sudah terpakai untuk deduct, jadi dibatasi saldo customer saat ini
+	available := payment.UnappliedAmount
+	if customer.Balance < available {
+		available = customer.Balance
+	}
+	if available <= 0 {
+		return nil, ErrNothingToAllocate
+	}
+
+	allocations, allocated, err := resolveAllocations(tx, payment.CustomerID, available, inputs, auto)
+	if err != nil {
+		return nil, err
+	}
+	if allocated == 0 {
+		return nil, ErrNothingToAllocate
+	}
+
+	customerID := payment.CustomerID
+	lines := []models.JournalLine{{Account: ledger.AccountCustomerBalance, CustomerID: &customerID, Debit: allocated}}
+	lines = append(lines, receivableLines(customerID, allocations)...)
+
+	entry := &models.JournalEntry{
+		CustomerID:  customerID,
+		Type:        "payment_allocation",
+		Description: fmt.Sprintf("Credit from payment %s applied to invoices", PaymentReference(payment)),
+		Reference:   PaymentReference(payment),
+		CreatedBy:   userID,
+		Lines:       lines,
+	}
+	if err := ledger.Post(tx, entry); err != nil {
+		return nil, err
+	}
+
+	payment.UnappliedAmount -= allocated
+	if err := tx.Model(payment).Update("unapplied_amount", payment.UnappliedAmount).Error; err != nil {
+		return nil, err
+	}
+	customer.Balance -= allocated
+
+	return entry, applyAllocations(tx, payment, allocations, entry.ID, userID)
+}
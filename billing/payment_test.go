@@ -0,0 +1,238 @@
+package billing
+
+import (
+	"auth-api/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB - gorm di atas sqlmock, query yang diharapkan didaftarkan oleh masing-masing test
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{SkipDefaultTransaction: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+type invoiceRow struct {
+	id, customerID uint
+	status         string
+	total, paid    string
+}
+
+func invoiceRows(rows ...invoiceRow) *sqlmock.Rows {
+	result := sqlmock.NewRows([]string{"id", "customer_id", "status", "due_date", "total", "amount_paid"})
+	due := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i, row := range rows {
+		result.AddRow(row.id, row.customerID, row.status, due.AddDate(0, 0, i), row.total, row.paid)
+	}
+	return result
+}
+
+type allocated struct {
+	invoiceID uint
+	amount    models.Money
+}
+
+func allocationsOf(allocations []allocation) []allocated {
+	var result []allocated
+	for _, a := range allocations {
+		result = append(result, allocated{a.invoice.ID, a.amount})
+	}
+	return result
+}
+
+func equalAllocations(got, want []allocated) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+const lockIssuedInvoices = "SELECT \\* FROM `invoices` WHERE customer_id = \\? AND status = \\? ORDER BY due_date ASC, id ASC FOR UPDATE"
+const lockInvoice = "SELECT \\* FROM `invoices` WHERE `invoices`.`id` = \\? .*FOR UPDATE"
+
+func TestResolveAllocationsAuto(t *testing.T) {
+	tests := []struct {
+		name      string
+		available models.Money
+		invoices  []invoiceRow
+		want      []allocated
+		total     models.Money
+	}{
+		{
+			"oldest due date first, last one partially",
+			15000,
+			[]invoiceRow{{1, 7, "issued", "100.00", "20.00"}, {2, 7, "issued", "50.00", "0.00"}, {3, 7, "issued", "70.00", "0.00"}},
+			[]allocated{{1, 8000}, {2, 5000}, {3, 2000}},
+			15000,
+		},
+		{
+			"payment larger than all invoices",
+			50000,
+			[]invoiceRow{{1, 7, "issued", "100.00", "0.00"}, {2, 7, "issued", "50.00", "49.99"}},
+			[]allocated{{1, 10000}, {2, 1}},
+			10001,
+		},
+		{
+			"fully paid invoice is skipped",
+			5000,
+			[]invoiceRow{{1, 7, "issued", "100.00", "100.00"}, {2, 7, "issued", "80.00", "0.00"}},
+			[]allocated{{2, 5000}},
+			5000,
+		},
+		{"no open invoices", 5000, nil, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			mock.ExpectQuery(lockIssuedInvoices).WithArgs(7, "issued").WillReturnRows(invoiceRows(tt.invoices...))
+
+			allocations, total, err := resolveAllocations(db, 7, tt.available, nil, true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := allocationsOf(allocations); !equalAllocations(got, tt.want) {
+				t.Errorf("allocations = %v, want %v", got, tt.want)
+			}
+			if total != tt.total {
+				t.Errorf("total = %d, want %d", total, tt.total)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestResolveAllocationsManual(t *testing.T) {
+	tests := []struct {
+		name      string
+		available models.Money
+		inputs    []AllocationInput
+		invoices  []invoiceRow // dikembalikan berurutan, satu query per invoice yang berbeda
+		want      []allocated
+		err       error
+	}{
+		{
+			"two invoices",
+			10000,
+			[]AllocationInput{{1, 3000}, {2, 5000}},
+			[]invoiceRow{{1, 7, "issued", "30.00", "0.00"}, {2, 7, "issued", "80.00", "10.00"}},
+			[]allocated{{1, 3000}, {2, 5000}},
+			nil,
+		},
+		{
+			"same invoice twice is locked once and summed",
+			10000,
+			[]AllocationInput{{1, 2000}, {1, 1000}},
+			[]invoiceRow{{1, 7, "issued", "30.00", "0.00"}},
+			[]allocated{{1, 2000}, {1, 1000}},
+			nil,
+		},
+		{
+			"same invoice twice over the amount due",
+			10000,
+			[]AllocationInput{{1, 2000}, {1, 1001}},
+			[]invoiceRow{{1, 7, "issued", "30.00", "0.00"}},
+			nil,
+			ErrAllocationExceedsInvoice,
+		},
+		{
+			"more than the amount due",
+			10000,
+			[]AllocationInput{{1, 2001}},
+			[]invoiceRow{{1, 7, "issued", "30.00", "10.00"}},
+			nil,
+			ErrAllocationExceedsInvoice,
+		},
+		{
+			"more than the payment",
+			5000,
+			[]AllocationInput{{1, 3000}, {2, 2001}},
+			[]invoiceRow{{1, 7, "issued", "30.00", "0.00"}, {2, 7, "issued", "80.00", "0.00"}},
+			nil,
+			ErrAllocationExceedsPayment,
+		},
+		{
+			"invoice of another customer",
+			5000,
+			[]AllocationInput{{1, 1000}},
+			[]invoiceRow{{1, 8, "issued", "30.00", "0.00"}},
+			nil,
+			ErrInvoiceCustomerMismatch,
+		},
+		{
+			"draft invoice",
+			5000,
+			[]AllocationInput{{1, 1000}},
+			[]invoiceRow{{1, 7, "draft", "30.00", "0.00"}},
+			nil,
+			ErrInvoiceNotIssued,
+		},
+		{
+			"paid invoice",
+			5000,
+			[]AllocationInput{{1, 1000}},
+			[]invoiceRow{{1, 7, "paid", "30.00", "30.00"}},
+			nil,
+			ErrInvoiceNotIssued,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			for _, row := range tt.invoices {
+				mock.ExpectQuery(lockInvoice).WillReturnRows(invoiceRows(row))
+			}
+
+			allocations, total, err := resolveAllocations(db, 7, tt.available, tt.inputs, false)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+			if got := allocationsOf(allocations); !equalAllocations(got, tt.want) {
+				t.Errorf("allocations = %v, want %v", got, tt.want)
+			}
+			var want models.Money
+			for _, a := range tt.want {
+				want += a.amount
+			}
+			if total != want {
+				t.Errorf("total = %d, want %d", total, want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestResolveAllocationsInvoiceNotFound(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(lockInvoice).WillReturnRows(invoiceRows())
+
+	_, _, err := resolveAllocations(db, 7, 5000, []AllocationInput{{99, 1000}}, false)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("error = %v, want %v", err, gorm.ErrRecordNotFound)
+	}
+}
@@ -0,0 +1,307 @@
+package billing
+
+import (
+	"auth-api/config"
+	"auth-api/models"
+	"auth-api/utils"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Lebar area cetak A4 portrait dengan margin 15mm
+const pageWidth = 180.0
+
+var invoiceStatusLabel = map[string]string{
+	"draft":  "DRAFT",
+	"issued": "BELUM LUNAS",
+	"paid":   "LUNAS",
+	"void":   "DIBATALKAN",
+}
+
+type pdfDocument struct {
+	*fpdf.Fpdf
+	tr func(string) string
+}
+
+func rupiah(m models.Money) string {
+	return utils.FormatRupiah(int64(m))
+}
+
+// newPDFDocument - Dokumen A4 dengan kop perusahaan dan nomor halaman di footer
+func newPDFDocument(cfg *config.Config, title string) *pdfDocument {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	doc := &pdfDocument{Fpdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.SetTitle(title, true)
+	pdf.SetAuthor(cfg.Company.Name, true)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 10, doc.tr(fmt.Sprintf("%s - Halaman %d dari {nb}", title, pdf.PageNo())), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+	doc.letterhead(cfg, title)
+	return doc
+}
+
+// letterhead - Logo (opsional), nama, alamat dan NPWP perusahaan di kiri, judul dokumen di kanan
+func (d *pdfDocument) letterhead(cfg *config.Config, title string) {
+	x := 15.0
+	if cfg.Company.LogoPath != "" {
+		if _, err := os.Stat(cfg.Company.LogoPath); err == nil {
+			d.ImageOptions(cfg.Company.LogoPath, 15, 15, 0, 18, false, fpdf.ImageOptions{ReadDpi: true}, 0, "")
+			x = 38
+		}
+	}
+
+	d.SetXY(x, 15)
+	d.SetFont("Helvetica", "B", 14)
+	d.SetTextColor(20, 60, 120)
+	d.CellFormat(110, 7, d.tr(cfg.Company.Name), "", 2, "L", false, 0, "")
+
+	d.SetFont("Helvetica", "", 8.5)
+	d.SetTextColor(60, 60, 60)
+	d.MultiCell(110, 4, d.tr(cfg.Company.Address), "", "L", false)
+	d.SetX(x)
+	d.CellFormat(110, 4, d.tr("NPWP: "+utils.FormatNPWP(cfg.Company.NPWP)), "", 2, "L", false, 0, "")
+	d.CellFormat(110, 4, d.tr(fmt.Sprintf("Telp: %s | Email: %s", cfg.Company.Phone, cfg.Company.Email)), "", 0, "L", false, 0, "")
+
+	d.SetXY(125, 15)
+	d.SetFont("Helvetica", "B", 18)
+	d.SetTextColor(20, 60, 120)
+	d.CellFormat(70, 10, d.tr(title), "", 0, "R", false, 0, "")
+
+	d.SetDrawColor(20, 60, 120)
+	d.SetLineWidth(0.6)
+	d.Line(15, 38, 195, 38)
+	d.SetLineWidth(0.2)
+	d.SetTextColor(0, 0, 0)
+	d.SetY(42)
+}
+
+// keyValue - Baris label: nilai kecil, dipakai untuk info dokumen di sisi kanan
+func (d *pdfDocument) keyValue(x float64, label, value string) {
+	d.SetX(x)
+	d.SetFont("Helvetica", "", 9)
+	d.CellFormat(30, 5, d.tr(label), "", 0, "L", false, 0, "")
+	d.SetFont("Helvetica", "B", 9)
+	d.CellFormat(45, 5, d.tr(value), "", 1, "R", false, 0, "")
+}
+
+// customerBlock - Identitas customer (ditagihkan kepada)
+func (d *pdfDocument) customerBlock(customer models.Customer, y float64) {
+	d.SetXY(15, y)
+	d.SetFont("Helvetica", "B", 9)
+	d.SetTextColor(100, 100, 100)
+	d.CellFormat(95, 5, d.tr("Kepada:"), "", 2, "L", false, 0, "")
+	d.SetTextColor(0, 0, 0)
+	d.SetFont("Helvetica", "B", 10)
+	d.CellFormat(95, 5, d.tr(customer.CompanyName), "", 2, "L", false, 0, "")
+	d.SetFont("Helvetica", "", 9)
+	if customer.ContactName != "" {
+		d.CellFormat(95, 4.5, d.tr("u.p. "+customer.ContactName), "", 2, "L", false, 0, "")
+	}
+	if customer.Address != "" {
+		d.MultiCell(95, 4.5, d.tr(customer.Address), "", "L", false)
+	}
+	if customer.NPWP != "" {
+		d.CellFormat(95, 4.5, d.tr("NPWP: "+utils.FormatNPWP(customer.NPWP)), "", 2, "L", false, 0, "")
+	}
+	d.CellFormat(95, 4.5, d.tr("Kode Customer: "+customer.CustomerCode), "", 2, "L", false, 0, "")
+}
+
+// tableHeader - Header tabel dengan latar berwarna
+func (d *pdfDocument) tableHeader(widths []float64, headers []string, aligns []string) {
+	d.SetFont("Helvetica", "B", 8.5)
+	d.SetFillColor(20, 60, 120)
+	d.SetTextColor(255, 255, 255)
+	for i, header := range headers {
+		d.CellFormat(widths[i], 7, d.tr(header), "1", 0, aligns[i], true, 0, "")
+	}
+	d.Ln(-1)
+	d.SetTextColor(0, 0, 0)
+	d.SetFont("Helvetica", "", 8.5)
+}
+
+// totalRow - Baris ringkasan rata kanan di bawah tabel
+func (d *pdfDocument) totalRow(label, value string, bold bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	d.SetFont("Helvetica", style, 9)
+	d.SetX(15 + pageWidth - 90)
+	d.CellFormat(50, 6, d.tr(label), "", 0, "L", false, 0, "")
+	d.CellFormat(40, 6, d.tr(value), "", 1, "R", false, 0, "")
+}
+
+func (d *pdfDocument) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderInvoicePDF - Cetak invoice beserta line item dan ringkasan pembayaran
+func RenderInvoicePDF(cfg *config.Config, invoice models.Invoice, customer models.Customer) ([]byte, error) {
+	number := "DRAFT"
+	if invoice.InvoiceNumber != nil {
+		number = *invoice.InvoiceNumber
+	}
+
+	doc := newPDFDocument(cfg, "INVOICE")
+
+	top := doc.GetY()
+	doc.customerBlock(customer, top)
+	bottom := doc.GetY()
+
+	doc.SetY(top)
+	doc.keyValue(120, "No. Invoice", number)
+	if invoice.IssueDate != nil {
+		doc.keyValue(120, "Tanggal", utils.FormatTanggal(*invoice.IssueDate))
+	}
+	doc.keyValue(120, "Jatuh Tempo", utils.FormatTanggal(invoice.DueDate))
+	doc.keyValue(120, "Status", invoiceStatusLabel[invoice.Status])
+	if doc.GetY() > bottom {
+		bottom = doc.GetY()
+	}
+	doc.SetY(bottom + 6)
+
+	widths := []float64{10, 70, 18, 32, 15, 35}
+	aligns := []string{"C", "L", "R", "R", "R", "R"}
+	doc.tableHeader(widths, []string{"No", "Deskripsi", "Qty", "Harga Satuan", "PPN", "Jumlah"}, aligns)
+	for i, line := range invoice.Lines {
+		values := []string{
+			strconv.Itoa(i + 1),
+			line.Description,
+			utils.FormatDecimalID(line.Quantity),
+			rupiah(line.UnitPrice),
+			utils.FormatDecimalID(float64(line.TaxRateBps)/100) + "%",
+			rupiah(line.Amount),
+		}
+		for j, value := range values {
+			doc.CellFormat(widths[j], 6.5, doc.tr(value), "1", 0, aligns[j], false, 0, "")
+		}
+		doc.Ln(-1)
+	}
+	doc.Ln(3)
+
+	doc.totalRow("Subtotal", rupiah(invoice.Subtotal), false)
+	doc.totalRow("PPN", rupiah(invoice.TaxTotal), false)
+	doc.totalRow("Total", rupiah(invoice.Total), true)
+	if invoice.AmountPaid > 0 {
+		doc.totalRow("Telah Dibayar", rupiah(invoice.AmountPaid), false)
+	}
+	if invoice.Status == "issued" {
+		doc.totalRow("Sisa Tagihan", rupiah(invoice.OutstandingAmount()), true)
+	}
+
+	if invoice.Notes != "" {
+		doc.Ln(6)
+		doc.SetFont("Helvetica", "B", 9)
+		doc.CellFormat(0, 5, doc.tr("Catatan:"), "", 1, "L", false, 0, "")
+		doc.SetFont("Helvetica", "", 9)
+		doc.MultiCell(0, 4.5, doc.tr(invoice.Notes), "", "L", false)
+	}
+	if invoice.Status == "void" && invoice.VoidReason != "" {
+		doc.Ln(4)
+		doc.SetFont("Helvetica", "B", 9)
+		doc.SetTextColor(180, 30, 30)
+		doc.MultiCell(0, 4.5, doc.tr("Invoice ini dibatalkan: "+invoice.VoidReason), "", "L", false)
+		doc.SetTextColor(0, 0, 0)
+	}
+
+	return doc.bytes()
+}
+
+// RenderStatementPDF - Cetak rekening koran customer: saldo awal, mutasi dan saldo akhir
+func RenderStatementPDF(cfg *config.Config, statement *Statement) ([]byte, error) {
+	doc := newPDFDocument(cfg, "STATEMENT")
+
+	top := doc.GetY()
+	doc.customerBlock(statement.Customer, top)
+	bottom := doc.GetY()
+
+	doc.SetY(top)
+	doc.keyValue(120, "Periode Dari", utils.FormatTanggal(statement.From))
+	doc.keyValue(120, "Sampai", utils.FormatTanggal(statement.To))
+	doc.keyValue(120, "Dicetak", utils.FormatTanggal(statement.GeneratedAt))
+	doc.keyValue(120, "Saldo Awal", rupiah(statement.OpeningBalance))
+	doc.keyValue(120, "Saldo Akhir", rupiah(statement.ClosingBalance))
+	if doc.GetY() > bottom {
+		bottom = doc.GetY()
+	}
+	doc.SetY(bottom + 6)
+
+	widths := []float64{22, 62, 32, 32, 32}
+	aligns := []string{"L", "L", "R", "R", "R"}
+	header := func() {
+		doc.tableHeader(widths, []string{"Tanggal", "Keterangan", "Debit", "Kredit", "Saldo"}, aligns)
+	}
+	header()
+
+	doc.SetFont("Helvetica", "I", 8.5)
+	doc.CellFormat(widths[0]+widths[1]+widths[2]+widths[3], 6.5, doc.tr("Saldo awal"), "1", 0, "L", false, 0, "")
+	doc.CellFormat(widths[4], 6.5, doc.tr(rupiah(statement.OpeningBalance)), "1", 1, "R", false, 0, "")
+	doc.SetFont("Helvetica", "", 8.5)
+
+	_, pageHeight := doc.GetPageSize()
+	for _, line := range statement.Lines {
+		// Ulangi header tabel di halaman baru
+		if doc.GetY()+6.5 > pageHeight-20 {
+			doc.AddPage()
+			header()
+		}
+
+		description := line.Description
+		if description == "" {
+			description = line.Type
+		}
+		if line.Reference != "" {
+			description += " (" + line.Reference + ")"
+		}
+		if runes := []rune(description); len(runes) > 48 {
+			description = string(runes[:45]) + "..."
+		}
+
+		debit, credit := "", ""
+		if line.Debit > 0 {
+			debit = rupiah(line.Debit)
+		}
+		if line.Credit > 0 {
+			credit = rupiah(line.Credit)
+		}
+
+		values := []string{line.Date.Format("02/01/2006"), description, debit, credit, rupiah(line.Balance)}
+		for i, value := range values {
+			doc.CellFormat(widths[i], 6.5, doc.tr(value), "1", 0, aligns[i], false, 0, "")
+		}
+		doc.Ln(-1)
+	}
+	if len(statement.Lines) == 0 {
+		doc.CellFormat(pageWidth, 6.5, doc.tr("Tidak ada mutasi pada periode ini"), "1", 1, "C", false, 0, "")
+	}
+	doc.Ln(3)
+
+	doc.totalRow("Total Debit", rupiah(statement.TotalDebit), false)
+	doc.totalRow("Total Kredit", rupiah(statement.TotalCredit), false)
+	doc.totalRow("Saldo Akhir", rupiah(statement.ClosingBalance), true)
+
+	doc.Ln(6)
+	doc.SetFont("Helvetica", "I", 8)
+	doc.SetTextColor(100, 100, 100)
+	doc.MultiCell(0, 4, doc.tr("Saldo positif menunjukkan kredit/deposit customer, saldo negatif menunjukkan tagihan yang belum dibayar. "+
+		"Dokumen ini dibuat secara otomatis dan sah tanpa tanda tangan."), "", "L", false)
+
+	return doc.bytes()
+}
@@ -0,0 +1,310 @@
+package billing
+
+import (
+	"auth-api/ledger"
+	"auth-api/models"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AgingBuckets - Batas atas (hari lewat jatuh tempo) tiap bucket AR aging, bucket terakhir tanpa batas
+var AgingBuckets = []struct {
+	Label  string
+	MaxDay int
+}{
+	{"0-30", 30},
+	{"31-60", 60},
+	{"61-90", 90},
+	{"90+", -1},
+}
+
+// AgingBucket - Total piutang dalam satu bucket umur
+type AgingBucket struct {
+	Label    string       `json:"label"`
+	Invoices int          `json:"invoices"`
+	Amount   models.Money `json:"amount"`
+}
+
+// AgingCustomerRow - Piutang satu customer per bucket, urutan Buckets sama dengan AgingBuckets
+type AgingCustomerRow struct {
+	CustomerID   uint           `json:"customer_id"`
+	CustomerCode string         `json:"customer_code"`
+	CompanyName  string         `json:"company_name"`
+	Buckets      []models.Money `json:"buckets"`
+	Total        models.Money   `json:"total"`
+}
+
+// AgingReport - AR aging per tanggal AsOf
+type AgingReport struct {
+	AsOf      time.Time          `json:"as_of"`
+	Total     models.Money       `json:"total"`
+	Buckets   []AgingBucket      `json:"buckets"`
+	Customers []AgingCustomerRow `json:"customers"`
+}
+
+// agingBucket - Index bucket untuk invoice yang lewat jatuh tempo days hari (belum jatuh tempo masuk bucket pertama)
+func agingBucket(days int) int {
+	for i, bucket := range AgingBuckets {
+		if bucket.MaxDay < 0 || days <= bucket.MaxDay {
+			return i
+		}
+	}
+	return len(AgingBuckets) - 1
+}
+
+// agingInvoice - Invoice beserta total alokasi pembayaran sampai tanggal laporan
+type agingInvoice struct {
+	ID         uint
+	CustomerID uint
+	DueDate    time.Time
+	Total      models.Money
+	Paid       models.Money
+}
+
+// BuildAgingReport - Sisa tagihan per akhir hari asOf untuk invoice yang terbit sampai asOf (dan sejak from
+// jika diisi), dikelompokkan berdasarkan umur dari due_date. Sisa tagihan dihitung ulang dari alokasi
+// pembayaran sampai asOf, sehingga invoice yang dilunasi atau di-void setelah asOf tetap terhitung.
+func BuildAgingReport(db *gorm.DB, from *time.Time, asOf time.Time) (*AgingReport, error) {
+	asOf = startOfDay(asOf)
+	end := asOf.AddDate(0, 0, 1)
+	report := &AgingReport{AsOf: asOf, Buckets: make([]AgingBucket, len(AgingBuckets))}
+	for i, bucket := range AgingBuckets {
+		report.Buckets[i].Label = bucket.Label
+	}
+
+	// Invoice hanya bisa di-void sebelum ada pembayaran, jadi void setelah asOf berarti masih terbuka penuh saat itu
+	query := db.Table("invoices").
+		Select("invoices.id, invoices.customer_id, invoices.due_date, invoices.total, COALESCE(SUM(payment_allocations.amount), 0) AS paid").
+		Joins("LEFT JOIN payment_allocations ON payment_allocations.invoice_id = invoices.id AND payment_allocations.created_at < ?", end).
+		Where("invoices.issue_date < ?", end).
+		Where("(invoices.status IN ? OR (invoices.status = ? AND invoices.voided_at >= ?))", []string{"issued", "paid"}, "void", end)
+	if from != nil {
+		query = query.Where("invoices.issue_date >= ?", startOfDay(*from))
+	}
+	var invoices []agingInvoice
+	err := query.Group("invoices.id, invoices.customer_id, invoices.due_date, invoices.total").
+		Having("invoices.total > COALESCE(SUM(payment_allocations.amount), 0)").
+		Order("invoices.customer_id ASC").
+		Scan(&invoices).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(map[uint]*AgingCustomerRow)
+	var ids []uint
+	for _, invoice := range invoices {
+		outstanding := invoice.Total - invoice.Paid
+		if outstanding <= 0 {
+			continue
+		}
+		i := agingBucket(DaysOverdue(invoice.DueDate, asOf))
+
+		row, ok := rows[invoice.CustomerID]
+		if !ok {
+			row = &AgingCustomerRow{CustomerID: invoice.CustomerID, Buckets: make([]models.Money, len(AgingBuckets))}
+			rows[invoice.CustomerID] = row
+			ids = append(ids, invoice.CustomerID)
+		}
+		row.Buckets[i] += outstanding
+		row.Total += outstanding
+		report.Buckets[i].Invoices++
+		report.Buckets[i].Amount += outstanding
+		report.Total += outstanding
+	}
+
+	if len(ids) > 0 {
+		var customers []models.Customer
+		if err := db.Select("id", "customer_code", "company_name").Where("id IN ?", ids).Find(&customers).Error; err != nil {
+			return nil, err
+		}
+		for _, customer := range customers {
+			rows[customer.ID].CustomerCode = customer.CustomerCode
+			rows[customer.ID].CompanyName = customer.CompanyName
+		}
+	}
+
+	report.Customers = make([]AgingCustomerRow, 0, len(ids))
+	for _, id := range ids {
+		report.Customers = append(report.Customers, *rows[id])
+	}
+	return report, nil
+}
+
+// customerBalanceLines - Journal line akun saldo customer beserta entry-nya, dibatasi waktu posting entry
+func customerBalanceLines(db *gorm.DB, start, end time.Time) *gorm.DB {
+	return db.Table("journal_lines").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Where("journal_lines.account = ? AND journal_entries.created_at >= ? AND journal_entries.created_at < ?",
+			ledger.AccountCustomerBalance, start, end)
+}
+
+// Perubahan saldo customer dari satu journal line (credit menambah saldo)
+const lineDelta = "(journal_lines.credit - journal_lines.debit)"
+
+// BalanceMovementRow - Pergerakan saldo customer dalam satu periode (hari atau bulan)
+type BalanceMovementRow struct {
+	Period      string       `json:"period"`
+	Deposits    models.Money `json:"deposits"`    // payment / deposit yang menambah saldo
+	Deducts     models.Money `json:"deducts"`     // pemakaian saldo, bernilai positif
+	Allocations models.Money `json:"allocations"` // saldo kredit yang dialokasikan ke invoice
+	Adjustments models.Money `json:"adjustments"` // koreksi manual (bertanda)
+	NetChange   models.Money `json:"net_change"`
+	Changes     int64        `json:"changes"`
+}
+
+// periodFormat - Format DATE_FORMAT MySQL untuk interval day/month
+func periodFormat(interval string) string {
+	if interval == "month" {
+		return "%Y-%m"
+	}
+	return "%Y-%m-%d"
+}
+
+// BuildBalanceMovements - Rekap pergerakan saldo per hari/bulan untuk periode from..to (inklusif).
+// Net change dari journal saldo customer; deposit dan alokasi dari payment, termasuk yang langsung teralokasi penuh.
+func BuildBalanceMovements(db *gorm.DB, from, to time.Time, interval string) ([]BalanceMovementRow, error) {
+	start, end := startOfDay(from), startOfDay(to).AddDate(0, 0, 1)
+	format := periodFormat(interval)
+
+	// Entry payment sudah terwakili oleh tabel payments dan payment_allocations
+	var journal []BalanceMovementRow
+	err := customerBalanceLines(db, start, end).
+		Select(`DATE_FORMAT(journal_entries.created_at, ?) AS period,
+			COALESCE(SUM(CASE WHEN journal_entries.type = 'deposit' THEN `+lineDelta+` ELSE 0 END), 0) AS deposits,
+			COALESCE(SUM(CASE WHEN journal_entries.type IN ('deduct', 'subscription') THEN -`+lineDelta+` ELSE 0 END), 0) AS deducts,
+			COALESCE(SUM(CASE WHEN journal_entries.type NOT IN ('deposit', 'payment', 'payment_allocation', 'deduct', 'subscription') THEN `+lineDelta+` ELSE 0 END), 0) AS adjustments,
+			COALESCE(SUM(`+lineDelta+`), 0) AS net_change,
+			COUNT(DISTINCT CASE WHEN journal_entries.type <> 'payment' THEN journal_entries.id END) AS changes`, format).
+		Group("period").
+		Scan(&journal).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []BalanceMovementRow
+	err = db.Table("payments").
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Select("DATE_FORMAT(created_at, ?) AS period, COALESCE(SUM(amount), 0) AS deposits, COUNT(*) AS changes", format).
+		Group("period").
+		Scan(&payments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Alokasi mengurangi saldo kredit, bernilai negatif seperti pada net change
+	var allocations []BalanceMovementRow
+	err = db.Table("payment_allocations").
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Select("DATE_FORMAT(created_at, ?) AS period, -COALESCE(SUM(amount), 0) AS allocations", format).
+		Group("period").
+		Scan(&allocations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byPeriod := map[string]*BalanceMovementRow{}
+	for _, source := range [][]BalanceMovementRow{journal, payments, allocations} {
+		for _, row := range source {
+			total, ok := byPeriod[row.Period]
+			if !ok {
+				total = &BalanceMovementRow{Period: row.Period}
+				byPeriod[row.Period] = total
+			}
+			total.Deposits += row.Deposits
+			total.Deducts += row.Deducts
+			total.Allocations += row.Allocations
+			total.Adjustments += row.Adjustments
+			total.NetChange += row.NetChange
+			total.Changes += row.Changes
+		}
+	}
+
+	rows := make([]BalanceMovementRow, 0, len(byPeriod))
+	for _, row := range byPeriod {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Period < rows[j].Period })
+	return rows, nil
+}
+
+// TopCustomerRow - Customer dengan saldo terbesar/terkecil beserta perubahan saldonya dalam periode
+type TopCustomerRow struct {
+	CustomerID   uint         `json:"customer_id"`
+	CustomerCode string       `json:"customer_code"`
+	CompanyName  string       `json:"company_name"`
+	Status       string       `json:"status"`
+	Balance      models.Money `json:"balance"`
+	CreditLimit  models.Money `json:"credit_limit"`
+	PeriodChange models.Money `json:"period_change"`
+}
+
+// BuildTopCustomers - Customer non-terminated diurutkan berdasarkan saldo saat ini.
+// ascending = true menampilkan saldo paling negatif lebih dulu.
+func BuildTopCustomers(db *gorm.DB, from, to time.Time, limit int, ascending bool) ([]TopCustomerRow, error) {
+	start, end := startOfDay(from), startOfDay(to).AddDate(0, 0, 1)
+
+	movements := customerBalanceLines(db, start, end).
+		Select("journal_lines.customer_id, SUM(" + lineDelta + ") AS period_change").
+		Group("journal_lines.customer_id")
+
+	order := "customers.balance DESC"
+	if ascending {
+		order = "customers.balance ASC"
+	}
+
+	var rows []TopCustomerRow
+	err := db.Table("customers").
+		Select(`customers.id AS customer_id, customers.customer_code, customers.company_name, customers.status,
+			customers.balance, customers.credit_limit, COALESCE(movements.period_change, 0) AS period_change`).
+		Joins("LEFT JOIN (?) AS movements ON movements.customer_id = customers.id", movements).
+		Where("customers.status <> ? AND customers.deleted_at IS NULL", "terminated").
+		Order(order).
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// FinanceUserTotalRow - Total deposit dan deduct yang dicatat oleh satu user finance/admin
+type FinanceUserTotalRow struct {
+	UserID       uint         `json:"user_id"`
+	Name         string       `json:"name"`
+	Email        string       `json:"email"`
+	Role         string       `json:"role"`
+	DepositCount int64        `json:"deposit_count"`
+	Deposits     models.Money `json:"deposits"`
+	DeductCount  int64        `json:"deduct_count"`
+	Deducts      models.Money `json:"deducts"`
+}
+
+// BuildFinanceUserTotals - Total deposit/deduct per user finance/admin dalam periode from..to (inklusif).
+// Deposit dari payment yang dicatat user tersebut, deduct dari journal entry deduct yang dibuatnya.
+func BuildFinanceUserTotals(db *gorm.DB, from, to time.Time) ([]FinanceUserTotalRow, error) {
+	start, end := startOfDay(from), startOfDay(to).AddDate(0, 0, 1)
+
+	payments := db.Table("payments").
+		Select("created_by AS user_id, COUNT(*) AS deposit_count, SUM(amount) AS deposits").
+		Where("created_at >= ? AND created_at < ?", start, end).
+		Group("created_by")
+
+	deducts := customerBalanceLines(db, start, end).
+		Where("journal_entries.type = ?", "deduct").
+		Select("journal_entries.created_by AS user_id, COUNT(DISTINCT journal_entries.id) AS deduct_count, SUM(-" + lineDelta + ") AS deducts").
+		Group("journal_entries.created_by")
+
+	var rows []FinanceUserTotalRow
+	err := db.Table("users").
+		Joins("LEFT JOIN (?) AS payment_totals ON payment_totals.user_id = users.id", payments).
+		Joins("LEFT JOIN (?) AS deduct_totals ON deduct_totals.user_id = users.id", deducts).
+		Where("users.role IN ?", []string{"finance", "admin"}).
+		Where("payment_totals.user_id IS NOT NULL OR deduct_totals.user_id IS NOT NULL").
+		Select(`users.id AS user_id, users.name, users.email, users.role,
+			COALESCE(payment_totals.deposit_count, 0) AS deposit_count,
+			COALESCE(payment_totals.deposits, 0) AS deposits,
+			COALESCE(deduct_totals.deduct_count, 0) AS deduct_count,
+			COALESCE(deduct_totals.deducts, 0) AS deducts`).
+		Order("deposits DESC").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,91 @@
+package billing
+
+import (
+	"auth-api/ledger"
+	"auth-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StatementLine adalah satu mutasi pada statement. Debit mengurangi posisi customer
+// (tagihan/pemakaian), credit menambah (pembayaran/deposit).
+type StatementLine struct {
+	Date        time.Time
+	Type        string
+	Description string
+	Reference   string
+	Debit       models.Money
+	Credit      models.Money
+	Balance     models.Money
+}
+
+// Statement adalah rekening koran customer untuk satu periode.
+// Balance positif berarti customer punya kredit, negatif berarti masih ada tagihan.
+type Statement struct {
+	Customer       models.Customer
+	From           time.Time
+	To             time.Time
+	OpeningBalance models.Money
+	TotalDebit     models.Money
+	TotalCredit    models.Money
+	ClosingBalance models.Money
+	Lines          []StatementLine
+	GeneratedAt    time.Time
+}
+
+// BuildStatement - Susun statement dari ledger untuk periode from..to (tanggal, inklusif)
+func BuildStatement(db *gorm.DB, customer models.Customer, from, to time.Time) (*Statement, error) {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location()).AddDate(0, 0, 1)
+
+	opening, err := ledger.PositionBefore(db, customer.ID, start)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []models.JournalEntry
+	err = db.Preload("Lines").
+		Where("customer_id = ? AND created_at >= ? AND created_at < ?", customer.ID, start, end).
+		Order("created_at ASC, id ASC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &Statement{
+		Customer:       customer,
+		From:           start,
+		To:             end.AddDate(0, 0, -1),
+		OpeningBalance: opening,
+		GeneratedAt:    time.Now(),
+	}
+
+	balance := opening
+	for _, entry := range entries {
+		net := ledger.EntryPositionNet(entry, customer.ID)
+		if net == 0 {
+			continue
+		}
+		balance += net
+
+		line := StatementLine{
+			Date:        entry.CreatedAt,
+			Type:        entry.Type,
+			Description: entry.Description,
+			Reference:   entry.Reference,
+			Balance:     balance,
+		}
+		if net < 0 {
+			line.Debit = -net
+			statement.TotalDebit += -net
+		} else {
+			line.Credit = net
+			statement.TotalCredit += net
+		}
+		statement.Lines = append(statement.Lines, line)
+	}
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
@@ -0,0 +1,171 @@
+package billing
+
+import (
+	"auth-api/config"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Sumber perubahan status customer, dicatat di history status_change
+const (
+	StatusSourceManual  = "manual"
+	StatusSourcePolicy  = "policy"
+	StatusSourceDunning = "dunning"
+)
+
+var (
+	ErrInvalidStatusTransition = errors.New("customer status transition is not allowed")
+	ErrStatusReasonRequired    = errors.New("status_reason is required when changing customer status")
+	ErrStatusConflict          = errors.New("customer status has been changed by another request")
+	ErrCustomerTerminated      = errors.New("customer is terminated")
+)
+
+// customerTransitions - Transisi status yang diizinkan. terminated adalah status akhir.
+var customerTransitions = map[string][]string{
+	"active":    {"suspended", "terminated"},
+	"suspended": {"active", "terminated"},
+}
+
+// CanTransition - Apakah status boleh berubah dari from ke to
+func CanTransition(from, to string) bool {
+	for _, next := range customerTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeCustomerStatus - Ubah status sesuai aturan transisi dan catat history status_change.
+// Update hanya berhasil jika status di database masih sama dengan yang dibaca.
+func ChangeCustomerStatus(tx *gorm.DB, customer *models.Customer, to, reason, source string, userID uint) error {
+	from := customer.Status
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, from, to)
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrStatusReasonRequired
+	}
+
+	now := time.Now()
+	autoSuspended := source != StatusSourceManual && to == "suspended"
+	result := tx.Model(&models.Customer{}).
+		Where("id = ? AND status = ?", customer.ID, from).
+		Updates(map[string]interface{}{
+			"status":            to,
+			"status_reason":     reason,
+			"status_changed_at": now,
+			"auto_suspended":    autoSuspended,
+			"version":           gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStatusConflict
+	}
+
+	customer.Status = to
+	customer.StatusReason = reason
+	customer.StatusChangedAt = &now
+	customer.AutoSuspended = autoSuspended
+	customer.Version++
+
+	history := models.CustomerHistory{
+		CustomerID: customer.ID,
+		Action:     "status_change",
+		Changes: utils.ToJSON(map[string]interface{}{
+			"from":   from,
+			"to":     to,
+			"reason": reason,
+			"source": source,
+		}),
+		ChangedBy: userID,
+		CreatedAt: now,
+	}
+	return tx.Create(&history).Error
+}
+
+// startOfDay - Jam 00:00 pada tanggal t, untuk dibandingkan dengan kolom date (due_date)
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// statusViolation - Alasan customer harus tetap/menjadi suspended, "" jika tidak ada pelanggaran.
+// Saldo dianggap melanggar jika turun melewati credit limit + toleransi, termasuk overdraft yang di-approve.
+// Invoice dihitung menunggak jika lewat jatuh tempo lebih dari overdueDays hari (negatif = tidak dicek).
+func statusViolation(tx *gorm.DB, cfg *config.Config, customer models.Customer, now time.Time, overdueDays int) (string, error) {
+	floor := -(customer.CreditLimit + models.Money(cfg.Lifecycle.NegativeBalanceGrace))
+	if customer.Balance < floor {
+		return fmt.Sprintf("Saldo %s melewati credit limit %s", utils.FormatRupiah(int64(customer.Balance)), utils.FormatRupiah(int64(customer.CreditLimit))), nil
+	}
+	if overdueDays < 0 {
+		return "", nil
+	}
+
+	var overdue int64
+	err := tx.Model(&models.Invoice{}).
+		Where("customer_id = ? AND status = ? AND due_date < ?", customer.ID, "issued", startOfDay(now).AddDate(0, 0, -overdueDays)).
+		Count(&overdue).Error
+	if err != nil {
+		return "", err
+	}
+	if overdue == 0 {
+		return "", nil
+	}
+	if overdueDays == 0 {
+		return fmt.Sprintf("%d invoice lewat jatuh tempo", overdue), nil
+	}
+	return fmt.Sprintf("%d invoice lewat jatuh tempo lebih dari %d hari", overdue, overdueDays), nil
+}
+
+// EvaluateCustomerStatus - Suspend customer aktif yang melanggar policy, dan aktifkan kembali customer
+// yang disuspend otomatis (policy atau dunning) setelah seluruh tunggakannya lunas. Suspend manual tidak
+// pernah diubah otomatis. Mengembalikan status baru, atau "" jika tidak ada perubahan.
+func EvaluateCustomerStatus(tx *gorm.DB, cfg *config.Config, customerID uint, now time.Time) (string, error) {
+	if !cfg.Lifecycle.AutoSuspend {
+		return "", nil
+	}
+
+	customer, err := lockCustomer(tx, customerID)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case customer.Status == "active":
+		// Invoice milik customer yang dunning-nya di-pause (dispute) tidak menjadi alasan suspend
+		overdueDays := cfg.Lifecycle.OverdueDays
+		paused, err := dunningPausedAt(tx, customer.ID, now)
+		if err != nil {
+			return "", err
+		}
+		if !cfg.Lifecycle.SuspendOnOverdue || paused {
+			overdueDays = -1
+		} else if overdueDays < 0 {
+			overdueDays = 0
+		}
+
+		violation, err := statusViolation(tx, cfg, customer, now, overdueDays)
+		if err != nil || violation == "" {
+			return "", err
+		}
+		return "suspended", ChangeCustomerStatus(tx, &customer, "suspended", violation, StatusSourcePolicy, 0)
+
+	case customer.Status == "suspended" && customer.AutoSuspended:
+		// Aktif kembali hanya jika tidak ada lagi invoice yang lewat jatuh tempo sama sekali
+		violation, err := statusViolation(tx, cfg, customer, now, 0)
+		if err != nil || violation != "" {
+			return "", err
+		}
+		return "active", ChangeCustomerStatus(tx, &customer, "active", "Tunggakan sudah dilunasi", StatusSourcePolicy, 0)
+	}
+	return "", nil
+}
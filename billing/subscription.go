@@ -0,0 +1,447 @@
+package billing
+
+import (
+	"auth-api/config"
+	"auth-api/database"
+	"auth-api/ledger"
+	"auth-api/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPlanInactive            = errors.New("plan is not active")
+	ErrSamePlan                = errors.New("subscription is already on this plan")
+	ErrSubscriptionNotActive   = errors.New("subscription is not active")
+	ErrSubscriptionNotPaused   = errors.New("subscription is not paused")
+	ErrSubscriptionCancelled   = errors.New("subscription is already cancelled")
+	ErrSubscriptionLocked      = errors.New("subscription is being processed, please retry")
+	ErrSubscriptionIntervalMix = errors.New("cannot change between plans with different billing intervals")
+)
+
+// LockSubscription - Redis lock per subscription agar scheduler dan API tidak menagih bersamaan
+func LockSubscription(subscriptionID uint) (func(), error) {
+	name := fmt.Sprintf("subscription:%d", subscriptionID)
+	token, err := database.AcquireLock(name, 2*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, ErrSubscriptionLocked
+	}
+	return func() { database.ReleaseLock(name, token) }, nil
+}
+
+// FindSubscriptionForUpdate - Ambil subscription beserta plan dengan row lock
+func FindSubscriptionForUpdate(tx *gorm.DB, subscriptionID uint) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&subscription, subscriptionID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.First(&subscription.Plan, subscription.PlanID).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// chargeRequest - Satu tagihan subscription yang akan dibuat
+type chargeRequest struct {
+	key         string
+	kind        string
+	plan        models.Plan
+	periodStart time.Time
+	periodEnd   time.Time
+	line        LineInput
+}
+
+// charge - Tagih customer: potong saldo jika billing method balance dan saldonya cukup,
+// selain itu dibuatkan invoice yang langsung di-issue. Aman dipanggil ulang dengan key yang sama.
+func charge(tx *gorm.DB, cfg *config.Config, subscription *models.Subscription, req chargeRequest, userID uint) (*models.SubscriptionCharge, error) {
+	var existing models.SubscriptionCharge
+	if err := tx.Where("charge_key = ?", req.key).First(&existing).Error; err == nil {
+		return &existing, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var customer models.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, subscription.CustomerID).Error; err != nil {
+		return nil, err
+	}
+	// Tarif diambil per periode agar perubahan PPN bertanggal ikut berlaku untuk perpanjangan.
+	// Customer bebas PPN tidak dikenakan pajak walau plan punya tarif.
+	if customer.IsTaxExemptAt(req.periodStart) {
+		req.line.TaxRateBps = 0
+	} else {
+		rate, err := planTaxRateAt(tx, req.plan, req.periodStart)
+		if err != nil {
+			return nil, err
+		}
+		req.line.TaxRateBps = rate
+	}
+
+	lines, subtotal, taxTotal, err := BuildLines([]LineInput{req.line})
+	if err != nil {
+		return nil, err
+	}
+	record := &models.SubscriptionCharge{
+		SubscriptionID: subscription.ID,
+		ChargeKey:      req.key,
+		Kind:           req.kind,
+		PlanID:         req.plan.ID,
+		PeriodStart:    req.periodStart,
+		PeriodEnd:      req.periodEnd,
+		Amount:         subtotal + taxTotal,
+		CreatedAt:      time.Now(),
+	}
+
+	if record.Amount > 0 {
+		paidFromBalance := false
+		if subscription.BillingMethod == "balance" {
+			// Credit line ikut dipakai, tapi tidak pernah melewati credit limit
+			if customer.AvailableCredit() >= record.Amount {
+				entry, err := deductCharge(tx, subscription.CustomerID, req.line.Description, req.key, subtotal, taxTotal, userID)
+				if err != nil {
+					return nil, err
+				}
+				record.JournalEntryID = &entry.ID
+				paidFromBalance = true
+			}
+		}
+
+		// Saldo tidak cukup tetap ditagihkan lewat invoice
+		if !paidFromBalance {
+			invoice := models.Invoice{
+				CustomerID: subscription.CustomerID,
+				Status:     "draft",
+				DueDate:    time.Now().AddDate(0, 0, cfg.Billing.PaymentTermDays),
+				Notes:      fmt.Sprintf("Subscription #%d (%s)", subscription.ID, req.plan.Name),
+				CreatedBy:  userID,
+				Subtotal:   subtotal,
+				TaxTotal:   taxTotal,
+				Total:      subtotal + taxTotal,
+				Lines:      lines,
+			}
+			if err := tx.Create(&invoice).Error; err != nil {
+				return nil, err
+			}
+			if err := Issue(tx, &invoice, cfg.Billing.InvoicePrefix, userID); err != nil {
+				return nil, err
+			}
+			record.InvoiceID = &invoice.ID
+		}
+	}
+
+	if err := tx.Create(record).Error; err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// deductCharge - Potong saldo customer: debit saldo customer, credit pendapatan dan PPN
+func deductCharge(tx *gorm.DB, customerID uint, description, reference string, subtotal, taxTotal models.Money, userID uint) (*models.JournalEntry, error) {
+	entry := &models.JournalEntry{
+		CustomerID:  customerID,
+		Type:        "subscription",
+		Description: description,
+		Reference:   reference,
+		CreatedBy:   userID,
+		Lines: []models.JournalLine{
+			{Account: ledger.AccountCustomerBalance, CustomerID: &customerID, Debit: subtotal + taxTotal},
+			{Account: ledger.AccountRevenue, Credit: subtotal},
+		},
+	}
+	if taxTotal > 0 {
+		entry.Lines = append(entry.Lines, models.JournalLine{Account: ledger.AccountTaxPayable, Credit: taxTotal})
+	}
+	return entry, ledger.Post(tx, entry)
+}
+
+// planTaxRateAt - Tarif khusus plan jika diisi, selain itu tarif PPN yang berlaku pada tanggal at
+func planTaxRateAt(tx *gorm.DB, plan models.Plan, at time.Time) (int, error) {
+	if plan.TaxRateBps != nil {
+		return *plan.TaxRateBps, nil
+	}
+	return PPNRateAt(tx, at)
+}
+
+func renewalRequest(subscription *models.Subscription, plan models.Plan, start, end time.Time) chargeRequest {
+	return chargeRequest{
+		key:         fmt.Sprintf("sub:%d:renewal:%s", subscription.ID, start.Format("20060102150405")),
+		kind:        "renewal",
+		plan:        plan,
+		periodStart: start,
+		periodEnd:   end,
+		line: LineInput{
+			Description: fmt.Sprintf("%s (%s - %s)", plan.Name, start.Format("02/01/2006"), end.Format("02/01/2006")),
+			Quantity:    1,
+			UnitPrice:   plan.Price,
+		},
+	}
+}
+
+// StartSubscription - Buat subscription baru dan tagih periode pertama
+func StartSubscription(tx *gorm.DB, cfg *config.Config, subscription *models.Subscription, plan models.Plan, userID uint) error {
+	if !plan.IsActive {
+		return ErrPlanInactive
+	}
+
+	now := time.Now()
+	subscription.PlanID = plan.ID
+	subscription.Status = "active"
+	subscription.CurrentPeriodStart = now
+	subscription.CurrentPeriodEnd = plan.NextPeriodEnd(now, now)
+	subscription.BillingAnchor = &now
+	subscription.CreatedBy = userID
+	if err := tx.Omit("Plan").Create(subscription).Error; err != nil {
+		return err
+	}
+	subscription.Plan = plan
+
+	_, err := charge(tx, cfg, subscription, renewalRequest(subscription, plan, subscription.CurrentPeriodStart, subscription.CurrentPeriodEnd), userID)
+	return err
+}
+
+// RenewDue - Tagih periode yang sudah jatuh tempo (termasuk yang terlewat saat server mati).
+// Subscription harus sudah dikunci dan Plan ter-load.
+func RenewDue(tx *gorm.DB, cfg *config.Config, subscription *models.Subscription, now time.Time) (int, error) {
+	if subscription.Status != "active" {
+		return 0, nil
+	}
+
+	anchor := subscription.Anchor()
+
+	// Worker tidak menagih customer yang disuspend, sehingga periodenya bisa tertinggal jauh.
+	// Periode di atas batas catch-up dilewati tanpa ditagih, bukan ditagih mundur sekaligus.
+	if limit := cfg.Billing.MaxCatchUpPeriods; limit > 0 && !subscription.CancelAtPeriodEnd {
+		missed := 0
+		for end := subscription.CurrentPeriodEnd; !end.After(now); end = subscription.Plan.NextPeriodEnd(anchor, end) {
+			missed++
+		}
+		if skip := missed - limit; skip > 0 {
+			for i := 0; i < skip; i++ {
+				start := subscription.CurrentPeriodEnd
+				end := subscription.Plan.NextPeriodEnd(anchor, start)
+				if err := recordSkippedPeriod(tx, subscription, start, end); err != nil {
+					return 0, err
+				}
+				subscription.CurrentPeriodStart = start
+				subscription.CurrentPeriodEnd = end
+			}
+			log.Printf("⚠️ Subscription %d: %d missed period(s) skipped without charge, billing resumes from %s",
+				subscription.ID, skip, subscription.CurrentPeriodEnd.Format("2006-01-02"))
+		}
+	}
+
+	renewed := 0
+	for !subscription.CurrentPeriodEnd.After(now) {
+		if subscription.CancelAtPeriodEnd {
+			subscription.Status = "cancelled"
+			subscription.CancelledAt = &subscription.CurrentPeriodEnd
+			break
+		}
+
+		start := subscription.CurrentPeriodEnd
+		end := subscription.Plan.NextPeriodEnd(anchor, start)
+		if _, err := charge(tx, cfg, subscription, renewalRequest(subscription, subscription.Plan, start, end), subscription.CreatedBy); err != nil {
+			return renewed, err
+		}
+		subscription.CurrentPeriodStart = start
+		subscription.CurrentPeriodEnd = end
+		renewed++
+	}
+
+	return renewed, tx.Model(subscription).
+		Select("status", "cancelled_at", "current_period_start", "current_period_end").
+		Updates(subscription).Error
+}
+
+// recordSkippedPeriod - Catat periode yang dilewati tanpa tagihan (amount 0) agar bisa diaudit finance
+func recordSkippedPeriod(tx *gorm.DB, subscription *models.Subscription, start, end time.Time) error {
+	record := models.SubscriptionCharge{
+		SubscriptionID: subscription.ID,
+		ChargeKey:      fmt.Sprintf("sub:%d:skipped:%s", subscription.ID, start.Format("20060102150405")),
+		Kind:           "skipped",
+		PlanID:         subscription.PlanID,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		CreatedAt:      time.Now(),
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error
+}
+
+// ChangePlan - Ganti plan di tengah periode. Sisa periode plan lama dikreditkan dan sisa periode
+// plan baru ditagihkan (prorata per jam); hanya selisihnya yang dicatat.
+func ChangePlan(tx *gorm.DB, cfg *config.Config, subscription *models.Subscription, newPlan models.Plan, userID uint) (*models.SubscriptionCharge, error) {
+	if subscription.Status != "active" {
+		return nil, ErrSubscriptionNotActive
+	}
+	if !newPlan.IsActive {
+		return nil, ErrPlanInactive
+	}
+	if newPlan.ID == subscription.PlanID {
+		return nil, ErrSamePlan
+	}
+	if newPlan.BillingInterval != subscription.Plan.BillingInterval {
+		return nil, ErrSubscriptionIntervalMix
+	}
+
+	now := time.Now()
+	oldPlan := subscription.Plan
+	unusedOld := prorate(oldPlan.Price, subscription.CurrentPeriodStart, subscription.CurrentPeriodEnd, now)
+	remainingNew := prorate(newPlan.Price, subscription.CurrentPeriodStart, subscription.CurrentPeriodEnd, now)
+
+	var record *models.SubscriptionCharge
+	if unusedOld != remainingNew {
+		key := fmt.Sprintf("sub:%d:change:%d", subscription.ID, now.UnixNano())
+
+		var err error
+		if remainingNew > unusedOld {
+			record, err = charge(tx, cfg, subscription, chargeRequest{
+				key:         key,
+				kind:        "proration",
+				plan:        newPlan,
+				periodStart: now,
+				periodEnd:   subscription.CurrentPeriodEnd,
+				line: LineInput{
+					Description: fmt.Sprintf("Prorata %s -> %s (s.d. %s)", oldPlan.Name, newPlan.Name, subscription.CurrentPeriodEnd.Format("02/01/2006")),
+					Quantity:    1,
+					UnitPrice:   remainingNew - unusedOld,
+				},
+			}, userID)
+		} else if unusedOld > remainingNew {
+			record, err = creditProration(tx, subscription, oldPlan, newPlan, unusedOld-remainingNew, key, now, userID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	subscription.PlanID = newPlan.ID
+	subscription.Plan = newPlan
+	return record, tx.Model(subscription).Update("plan_id", newPlan.ID).Error
+}
+
+// prorate - Bagian price untuk sisa periode start..end dihitung dari now, per jam penuh
+func prorate(price models.Money, start, end, now time.Time) models.Money {
+	total := int64(end.Sub(start) / time.Hour)
+	remaining := int64(end.Sub(now) / time.Hour)
+	if total <= 0 || remaining <= 0 {
+		return 0
+	}
+	if remaining > total {
+		remaining = total
+	}
+	return price.MulRatio(remaining, total)
+}
+
+// creditProration - Downgrade: kembalikan selisih (beserta PPN yang sudah ditagih) ke saldo customer
+func creditProration(tx *gorm.DB, subscription *models.Subscription, oldPlan, newPlan models.Plan, amount models.Money, key string, now time.Time, userID uint) (*models.SubscriptionCharge, error) {
+	customerID := subscription.CustomerID
+
+	var customer models.Customer
+	if err := tx.First(&customer, customerID).Error; err != nil {
+		return nil, err
+	}
+	// PPN dikembalikan dengan tarif yang dipakai saat periode berjalan ditagih
+	var tax models.Money
+	if !customer.IsTaxExemptAt(now) {
+		rate, err := planTaxRateAt(tx, oldPlan, subscription.CurrentPeriodStart)
+		if err != nil {
+			return nil, err
+		}
+		tax = amount.MulRatio(int64(rate), 10000)
+	}
+
+	entry := &models.JournalEntry{
+		CustomerID:  customerID,
+		Type:        "proration_credit",
+		Description: fmt.Sprintf("Prorata kredit %s -> %s", oldPlan.Name, newPlan.Name),
+		Reference:   key,
+		CreatedBy:   userID,
+		Lines: []models.JournalLine{
+			{Account: ledger.AccountRevenue, Debit: amount},
+			{Account: ledger.AccountCustomerBalance, CustomerID: &customerID, Credit: amount + tax},
+		},
+	}
+	if tax > 0 {
+		entry.Lines = append(entry.Lines, models.JournalLine{Account: ledger.AccountTaxPayable, Debit: tax})
+	}
+	if err := ledger.Post(tx, entry); err != nil {
+		return nil, err
+	}
+
+	record := &models.SubscriptionCharge{
+		SubscriptionID: subscription.ID,
+		ChargeKey:      key,
+		Kind:           "proration_credit",
+		PlanID:         newPlan.ID,
+		PeriodStart:    now,
+		PeriodEnd:      subscription.CurrentPeriodEnd,
+		Amount:         amount + tax,
+		JournalEntryID: &entry.ID,
+		CreatedAt:      now,
+	}
+	return record, tx.Create(record).Error
+}
+
+// PauseSubscription - Hentikan penagihan sementara, periode yang sudah dibayar tetap berjalan
+func PauseSubscription(tx *gorm.DB, subscription *models.Subscription) error {
+	if subscription.Status != "active" {
+		return ErrSubscriptionNotActive
+	}
+	now := time.Now()
+	subscription.Status = "paused"
+	subscription.PausedAt = &now
+	return tx.Model(subscription).Select("status", "paused_at").Updates(subscription).Error
+}
+
+// ResumeSubscription - Aktifkan kembali. Jika periode lama sudah habis, periode baru dimulai sekarang dan langsung ditagih.
+func ResumeSubscription(tx *gorm.DB, cfg *config.Config, subscription *models.Subscription, userID uint) error {
+	if subscription.Status != "paused" {
+		return ErrSubscriptionNotPaused
+	}
+	if !subscription.Plan.IsActive {
+		return ErrPlanInactive
+	}
+
+	now := time.Now()
+	subscription.Status = "active"
+	subscription.PausedAt = nil
+	if !subscription.CurrentPeriodEnd.After(now) {
+		// Periode baru dimulai sekarang, tanggal tagih berikutnya mengikuti tanggal resume
+		subscription.CurrentPeriodStart = now
+		subscription.CurrentPeriodEnd = subscription.Plan.NextPeriodEnd(now, now)
+		subscription.BillingAnchor = &now
+		req := renewalRequest(subscription, subscription.Plan, subscription.CurrentPeriodStart, subscription.CurrentPeriodEnd)
+		if _, err := charge(tx, cfg, subscription, req, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(subscription).
+		Select("status", "paused_at", "current_period_start", "current_period_end", "billing_anchor").
+		Updates(subscription).Error
+}
+
+// CancelSubscription - Batalkan langsung (tanpa refund) atau di akhir periode berjalan
+func CancelSubscription(tx *gorm.DB, subscription *models.Subscription, immediate bool) error {
+	if subscription.Status == "cancelled" {
+		return ErrSubscriptionCancelled
+	}
+
+	if immediate || subscription.Status == "paused" {
+		now := time.Now()
+		subscription.Status = "cancelled"
+		subscription.CancelledAt = &now
+	} else {
+		subscription.CancelAtPeriodEnd = true
+	}
+
+	return tx.Model(subscription).Select("status", "cancelled_at", "cancel_at_period_end").Updates(subscription).Error
+}
@@ -0,0 +1,36 @@
+package billing
+
+import (
+	"auth-api/models"
+	"testing"
+	"time"
+)
+
+func TestProrate(t *testing.T) {
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2025, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	// Periode dari anchor tanggal 31: 31 Jan - 28 Feb (672 jam), 28 Feb - 31 Mar (744 jam)
+	janEnd, febEnd, marEnd := at(time.January, 31, 0, 0), at(time.February, 28, 0, 0), at(time.March, 31, 0, 0)
+
+	tests := []struct {
+		name       string
+		price      models.Money
+		start, end time.Time
+		now        time.Time
+		want       models.Money
+	}{
+		{"half of clamped february", 300000, janEnd, febEnd, at(time.February, 14, 0, 0), 150000},
+		{"long march period", 100000, febEnd, marEnd, at(time.March, 16, 0, 0), 48387},
+		{"partial hour is not counted", 300000, janEnd, febEnd, febEnd.Add(-90 * time.Minute), 446},
+		{"before period start", 300000, janEnd, febEnd, janEnd.Add(-time.Hour), 300000},
+		{"at period end", 300000, janEnd, febEnd, febEnd, 0},
+		{"after period end", 300000, janEnd, febEnd, febEnd.Add(time.Hour), 0},
+		{"empty period", 300000, janEnd, janEnd, janEnd, 0},
+	}
+	for _, tt := range tests {
+		if got := prorate(tt.price, tt.start, tt.end, tt.now); got != tt.want {
+			t.Errorf("%s: prorate = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
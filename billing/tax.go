@@ -0,0 +1,142 @@
+package billing
+
+import (
+	"auth-api/ledger"
+	"auth-api/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaxCodePPN - Kode tarif Pajak Pertambahan Nilai
+const TaxCodePPN = "PPN"
+
+var ErrNoTaxRate = errors.New("no PPN rate is effective for the given date")
+
+// defaultPPNRates - Tarif PPN umum (UU HPP): 10% sebelum April 2022, 11% sesudahnya.
+// Tarif baru ditambahkan lewat API, bukan dengan mengubah daftar ini.
+var defaultPPNRates = []models.TaxRate{
+	{Code: TaxCodePPN, RateBps: 1000, EffectiveFrom: time.Date(1985, 4, 1, 0, 0, 0, 0, time.Local), Description: "PPN 10% (UU 8/1983)"},
+	{Code: TaxCodePPN, RateBps: 1100, EffectiveFrom: time.Date(2022, 4, 1, 0, 0, 0, 0, time.Local), Description: "PPN 11% (UU 7/2021 HPP)"},
+}
+
+// SeedTaxRates - Isi tarif PPN default jika tabel masih kosong
+func SeedTaxRates(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.TaxRate{}).Where("code = ?", TaxCodePPN).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	rates := make([]models.TaxRate, len(defaultPPNRates))
+	copy(rates, defaultPPNRates)
+	return db.Create(&rates).Error
+}
+
+// PPNRateAt - Tarif PPN (basis poin) yang berlaku pada tanggal tertentu
+func PPNRateAt(db *gorm.DB, at time.Time) (int, error) {
+	var rate models.TaxRate
+	err := db.Where("code = ? AND effective_from <= ?", TaxCodePPN, at).
+		Order("effective_from DESC").
+		First(&rate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, ErrNoTaxRate
+	}
+	return rate.RateBps, err
+}
+
+// CustomerTaxRateAt - Tarif PPN untuk customer, 0 jika customer sedang bebas PPN
+func CustomerTaxRateAt(db *gorm.DB, customer models.Customer, at time.Time) (int, error) {
+	if customer.IsTaxExemptAt(at) {
+		return 0, nil
+	}
+	return PPNRateAt(db, at)
+}
+
+// SplitInclusive - Pisahkan nominal yang sudah termasuk PPN menjadi DPP dan PPN
+func SplitInclusive(gross models.Money, rateBps int) (dpp, tax models.Money) {
+	if rateBps <= 0 {
+		return gross, 0
+	}
+	dpp = gross.MulRatio(10000, int64(10000+rateBps))
+	return dpp, gross - dpp
+}
+
+// TaxLedgerRow - DPP (pendapatan) dan PPN keluaran per jenis transaksi di ledger
+type TaxLedgerRow struct {
+	EntryType string       `json:"entry_type"`
+	DPP       models.Money `json:"dpp"`
+	PPN       models.Money `json:"ppn"`
+}
+
+// TaxRateRow - Rekap baris invoice per tarif PPN
+type TaxRateRow struct {
+	TaxRateBps   int          `json:"tax_rate_bps"`
+	DPP          models.Money `json:"dpp"`
+	PPN          models.Money `json:"ppn"`
+	InvoiceCount int64        `json:"invoice_count"`
+}
+
+// TaxSummary - Rekap PPN keluaran untuk satu periode
+type TaxSummary struct {
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	TotalDPP    models.Money   `json:"total_dpp"`
+	TotalPPN    models.Money   `json:"total_ppn"`
+	ExemptSales models.Money   `json:"exempt_sales"` // DPP baris invoice bertarif 0%
+	Ledger      []TaxLedgerRow `json:"ledger"`
+	Invoices    []TaxRateRow   `json:"invoices"`
+}
+
+// BuildTaxSummary - Rekap PPN periode from..to (tanggal, inklusif). Total diambil dari ledger
+// sehingga void dan prorata kredit ikut mengurangi; rincian invoice per tarif berdasarkan issue_date.
+func BuildTaxSummary(db *gorm.DB, from, to time.Time) (*TaxSummary, error) {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location()).AddDate(0, 0, 1)
+
+	summary := &TaxSummary{From: start, To: end.AddDate(0, 0, -1)}
+
+	err := db.Table("journal_lines").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Where("journal_lines.account IN ? AND journal_entries.created_at >= ? AND journal_entries.created_at < ?",
+			[]string{ledger.AccountRevenue, ledger.AccountTaxPayable}, start, end).
+		Select(`journal_entries.type AS entry_type,
+			COALESCE(SUM(CASE WHEN journal_lines.account = ? THEN journal_lines.credit - journal_lines.debit ELSE 0 END), 0) AS dpp,
+			COALESCE(SUM(CASE WHEN journal_lines.account = ? THEN journal_lines.credit - journal_lines.debit ELSE 0 END), 0) AS ppn`,
+			ledger.AccountRevenue, ledger.AccountTaxPayable).
+		Group("journal_entries.type").
+		Order("journal_entries.type").
+		Scan(&summary.Ledger).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range summary.Ledger {
+		summary.TotalDPP += row.DPP
+		summary.TotalPPN += row.PPN
+	}
+
+	err = db.Table("invoice_lines").
+		Joins("JOIN invoices ON invoices.id = invoice_lines.invoice_id").
+		Where("invoices.status IN ? AND invoices.issue_date >= ? AND invoices.issue_date < ?",
+			[]string{"issued", "paid"}, start, end).
+		Select(`invoice_lines.tax_rate_bps,
+			COALESCE(SUM(invoice_lines.amount), 0) AS dpp,
+			COALESCE(SUM(invoice_lines.tax_amount), 0) AS ppn,
+			COUNT(DISTINCT invoices.id) AS invoice_count`).
+		Group("invoice_lines.tax_rate_bps").
+		Order("invoice_lines.tax_rate_bps").
+		Scan(&summary.Invoices).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range summary.Invoices {
+		if row.TaxRateBps == 0 {
+			summary.ExemptSales += row.DPP
+		}
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,146 @@
+package billing
+
+import (
+	"auth-api/models"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UsageEvent adalah satu event pemakaian yang sudah divalidasi, disimpan di buffer Redis sebagai JSON
+type UsageEvent struct {
+	EventID    string    `json:"event_id"`
+	CustomerID uint      `json:"customer_id"`
+	Metric     string    `json:"metric"`
+	Quantity   float64   `json:"quantity"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// UsageTotal - Total pemakaian satu metric dalam satu periode
+type UsageTotal struct {
+	Metric     string  `json:"metric"`
+	Quantity   float64 `json:"quantity"`
+	EventCount int64   `json:"event_count"`
+}
+
+// UsagePoint - Total pemakaian satu metric dalam satu bucket waktu (jam/hari/bulan)
+type UsagePoint struct {
+	Period     string  `json:"period"`
+	Metric     string  `json:"metric"`
+	Quantity   float64 `json:"quantity"`
+	EventCount int64   `json:"event_count"`
+}
+
+type usageBucket struct {
+	customerID uint
+	metric     string
+	hour       time.Time
+}
+
+// roundQuantity - Sesuaikan dengan skala kolom decimal(20,6)
+func roundQuantity(q float64) float64 {
+	return math.Round(q*1e6) / 1e6
+}
+
+// RollupUsageBatch - Jumlahkan event per customer/metric/jam lalu tambahkan ke aggregate MySQL.
+// Batch yang sudah pernah di-rollup dilewati (dicatat di usage_rollup_batches dalam transaksi yang sama).
+func RollupUsageBatch(db *gorm.DB, batchID string, payloads []string) (int, error) {
+	buckets := make(map[usageBucket]*models.UsageAggregate)
+	for _, payload := range payloads {
+		var event UsageEvent
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			continue
+		}
+
+		key := usageBucket{customerID: event.CustomerID, metric: event.Metric, hour: event.Timestamp.Truncate(time.Hour)}
+		aggregate, ok := buckets[key]
+		if !ok {
+			aggregate = &models.UsageAggregate{CustomerID: key.customerID, Metric: key.metric, PeriodStart: key.hour}
+			buckets[key] = aggregate
+		}
+		aggregate.Quantity += event.Quantity
+		aggregate.EventCount++
+	}
+
+	aggregates := make([]models.UsageAggregate, 0, len(buckets))
+	now := time.Now()
+	for _, aggregate := range buckets {
+		aggregate.Quantity = roundQuantity(aggregate.Quantity)
+		aggregate.UpdatedAt = now
+		aggregates = append(aggregates, *aggregate)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		marker := models.UsageRollupBatch{BatchID: batchID, EventCount: len(payloads), ProcessedAt: now}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&marker)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errBatchAlreadyApplied
+		}
+		if len(aggregates) == 0 {
+			return nil
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "customer_id"}, {Name: "metric"}, {Name: "period_start"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"quantity":    gorm.Expr("quantity + VALUES(quantity)"),
+				"event_count": gorm.Expr("event_count + VALUES(event_count)"),
+				"updated_at":  now,
+			}),
+		}).CreateInBatches(aggregates, 500).Error
+	})
+	if errors.Is(err, errBatchAlreadyApplied) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(payloads), nil
+}
+
+var errBatchAlreadyApplied = errors.New("usage batch already applied")
+
+// UsageTotals - Total pemakaian per metric untuk customer pada periode [from, to).
+// Dipakai juga untuk menyusun line invoice usage-based.
+func UsageTotals(db *gorm.DB, customerID uint, from, to time.Time) ([]UsageTotal, error) {
+	var totals []UsageTotal
+	err := db.Model(&models.UsageAggregate{}).
+		Select("metric, SUM(quantity) AS quantity, SUM(event_count) AS event_count").
+		Where("customer_id = ? AND period_start >= ? AND period_start < ?", customerID, from, to).
+		Group("metric").
+		Order("metric ASC").
+		Scan(&totals).Error
+	return totals, err
+}
+
+var usageGroupFormats = map[string]string{
+	"hour":  "%Y-%m-%d %H:00",
+	"day":   "%Y-%m-%d",
+	"month": "%Y-%m",
+}
+
+// UsageSeries - Pemakaian per bucket waktu, groupBy salah satu dari hour, day, month
+func UsageSeries(db *gorm.DB, customerID uint, from, to time.Time, groupBy, metric string) ([]UsagePoint, error) {
+	format, ok := usageGroupFormats[groupBy]
+	if !ok {
+		format = usageGroupFormats["day"]
+	}
+
+	query := db.Model(&models.UsageAggregate{}).
+		Select("DATE_FORMAT(period_start, ?) AS period, metric, SUM(quantity) AS quantity, SUM(event_count) AS event_count", format).
+		Where("customer_id = ? AND period_start >= ? AND period_start < ?", customerID, from, to)
+	if metric != "" {
+		query = query.Where("metric = ?", metric)
+	}
+
+	var points []UsagePoint
+	err := query.Group("period, metric").Order("period ASC, metric ASC").Scan(&points).Error
+	return points, err
+}
@@ -2,6 +2,13 @@ package config
 
 import "time"
 
+// DunningStep - Satu tahap dunning, dijalankan AfterDays hari setelah tunggakan dimulai
+type DunningStep struct {
+	Name      string // reminder, warning, suspension
+	AfterDays int
+	Suspend   bool // suspend customer pada tahap ini
+}
+
 type Config struct {
 	MySQL struct {
 		Host     string
@@ -17,14 +24,20 @@ type Config struct {
 		DB       int
 	}
 	JWT struct {
-		Secret string
-		Expiry time.Duration
+		Secret              string
+		Expiry              time.Duration
+		ImpersonationExpiry time.Duration
 	}
 	Security struct {
 		MaxLoginAttempts int
 		BlockDuration    time.Duration
 		OTPExpiry        time.Duration
 		OTPLength        int
+
+		EmailChangeCancelExpiry time.Duration
+
+		GeoIPDBPath              string
+		ImpossibleTravelSpeedKmh float64
 	}
 	SMTP struct {
 		Host     string
@@ -33,8 +46,75 @@ type Config struct {
 		Password string
 		From     string
 	}
+	Billing struct {
+		InvoicePrefix        string
+		PaymentTermDays      int
+		SubscriptionInterval time.Duration
+		MaxCatchUpPeriods    int // periode terlewat yang ditagih sekaligus, sisanya dilewati (0 = tanpa batas)
+	}
+	Credit struct {
+		AlertEmail    string
+		CheckInterval time.Duration
+	}
+	Approval struct {
+		BalanceThreshold int64 // sen
+		TTL              time.Duration
+		ExpireInterval   time.Duration
+	}
+	Lifecycle struct {
+		AutoSuspend          bool
+		SuspendOnOverdue     bool  // false = hanya saldo vs credit limit yang dicek
+		OverdueDays          int   // invoice lewat jatuh tempo lebih dari N hari (0 = lewat jatuh tempo sama sekali)
+		NegativeBalanceGrace int64 // sen, toleransi saldo di bawah credit limit
+		PolicyInterval       time.Duration
+	}
+	Dunning struct {
+		Steps    []DunningStep // urut berdasarkan AfterDays
+		Interval time.Duration
+	}
+	Company struct {
+		Name     string
+		Address  string
+		NPWP     string
+		Phone    string
+		Email    string
+		LogoPath string
+	}
+	Usage struct {
+		MaxBatchSize   int
+		MaxEventAge    time.Duration
+		DedupeTTL      time.Duration
+		RollupInterval time.Duration
+	}
+	Idempotency struct {
+		TTL time.Duration
+	}
+	Export struct {
+		Dir             string        // direktori file hasil export asinkron
+		TTL             time.Duration // file dihapus setelah lewat TTL sejak selesai
+		PollInterval    time.Duration
+		CleanupInterval time.Duration
+		StaleAfter      time.Duration // job running lebih lama dari ini dianggap gagal (worker mati)
+	}
+	Import struct {
+		MaxFileSize int64 // byte
+		MaxRows     int
+		BatchSize   int // jumlah customer per transaksi
+	}
+	Bulk struct {
+		MaxItems     int // jumlah customer maksimal per operasi massal
+		PollInterval time.Duration
+		StaleAfter   time.Duration // job running tanpa progres selama ini dijalankan ulang
+	}
+	Privacy struct {
+		DeletionGracePeriod time.Duration
+		PurgeInterval       time.Duration
+		SuccessorUserID     uint
+		CustomerRetention   time.Duration // customer yang dihapus dianonimkan setelah masa ini
+	}
 	Server struct {
-		Port string
+		Port    string
+		BaseURL string
 	}
 }
 
@@ -57,12 +137,86 @@ func Load() *Config {
 	// JWT Config
 	cfg.JWT.Secret = "secret1029384756plmnjiuhbVGYTFCXZASDQWERZ"
 	cfg.JWT.Expiry = 24 * time.Hour
+	cfg.JWT.ImpersonationExpiry = 15 * time.Minute
 
 	// Security Config
 	cfg.Security.MaxLoginAttempts = 3
 	cfg.Security.BlockDuration = 10 * time.Minute
 	cfg.Security.OTPExpiry = 5 * time.Minute
 	cfg.Security.OTPLength = 6
+	cfg.Security.EmailChangeCancelExpiry = 7 * 24 * time.Hour
+	cfg.Security.GeoIPDBPath = "./data/GeoLite2-City.mmdb"
+	cfg.Security.ImpossibleTravelSpeedKmh = 900 // kira-kira kecepatan pesawat komersial
+
+	// Billing Config
+	cfg.Billing.InvoicePrefix = "INV"
+	cfg.Billing.PaymentTermDays = 14
+	cfg.Billing.SubscriptionInterval = 5 * time.Minute // cek periode subscription yang jatuh tempo
+	cfg.Billing.MaxCatchUpPeriods = 2
+
+	// Credit Config (credit limit, overdraft dan notifikasi saldo rendah)
+	cfg.Credit.AlertEmail = "finance@billapi.co.id" // salinan alert overdraft dan saldo rendah untuk tim finance
+	cfg.Credit.CheckInterval = 5 * time.Minute
+
+	// Approval Config (maker-checker untuk perubahan saldo besar)
+	cfg.Approval.BalanceThreshold = 10000000 * 100 // Rp 10.000.000, 0 = semua perubahan langsung diproses
+	cfg.Approval.TTL = 48 * time.Hour
+	cfg.Approval.ExpireInterval = 10 * time.Minute
+
+	// Lifecycle Config (auto-suspend customer yang menunggak)
+	cfg.Lifecycle.AutoSuspend = true
+	cfg.Lifecycle.SuspendOnOverdue = true
+	cfg.Lifecycle.OverdueDays = 30
+	cfg.Lifecycle.NegativeBalanceGrace = 0 // langsung suspend begitu saldo melewati credit limit
+	cfg.Lifecycle.PolicyInterval = 15 * time.Minute
+
+	// Dunning Config (penagihan bertahap untuk invoice lewat jatuh tempo dan saldo melewati credit limit)
+	cfg.Dunning.Steps = []DunningStep{
+		{Name: "reminder", AfterDays: 3},
+		{Name: "warning", AfterDays: 10},
+		{Name: "suspension", AfterDays: 30, Suspend: true},
+	}
+	cfg.Dunning.Interval = 1 * time.Hour
+
+	// Company Config (kop invoice dan statement PDF)
+	cfg.Company.Name = "PT Billapi Teknologi Indonesia"
+	cfg.Company.Address = "Jl. Jend. Sudirman Kav. 52-53, Jakarta Selatan 12190"
+	cfg.Company.NPWP = "012345674901000"
+	cfg.Company.Phone = "+62 21 5150 0000"
+	cfg.Company.Email = "finance@billapi.co.id"
+	cfg.Company.LogoPath = "./data/logo.png" // dilewati jika file tidak ada
+
+	// Usage Metering Config (event di-buffer di Redis lalu di-rollup ke MySQL)
+	cfg.Usage.MaxBatchSize = 1000
+	cfg.Usage.MaxEventAge = 72 * time.Hour // event lebih lama dari ini ditolak
+	cfg.Usage.DedupeTTL = 96 * time.Hour   // harus lebih lama dari MaxEventAge agar retry tidak terhitung dua kali
+	cfg.Usage.RollupInterval = 1 * time.Minute
+
+	// Idempotency Config (replay response untuk retry dengan Idempotency-Key yang sama)
+	cfg.Idempotency.TTL = 24 * time.Hour
+
+	// Export Config (export asinkron CSV/XLSX/NDJSON)
+	cfg.Export.Dir = "./data/exports"
+	cfg.Export.TTL = 24 * time.Hour
+	cfg.Export.PollInterval = 10 * time.Second
+	cfg.Export.CleanupInterval = 1 * time.Hour
+	cfg.Export.StaleAfter = 30 * time.Minute
+
+	// Import Config (import customer dari CSV/XLSX)
+	cfg.Import.MaxFileSize = 10 << 20
+	cfg.Import.MaxRows = 5000
+	cfg.Import.BatchSize = 100
+
+	// Bulk Config (operasi massal customer)
+	cfg.Bulk.MaxItems = 1000
+	cfg.Bulk.PollInterval = 5 * time.Second
+	cfg.Bulk.StaleAfter = 10 * time.Minute
+
+	// Privacy Config (UU PDP)
+	cfg.Privacy.DeletionGracePeriod = 14 * 24 * time.Hour
+	cfg.Privacy.PurgeInterval = 1 * time.Hour
+	cfg.Privacy.SuccessorUserID = 0 // 0 = customer tetap terhubung ke user yang dianonimkan
+	cfg.Privacy.CustomerRetention = 90 * 24 * time.Hour
 
 	// SMTP Config (sesuaikan dengan email provider Anda)
 	cfg.SMTP.Host = "smtp.gmail.com"
@@ -78,6 +232,7 @@ func Load() *Config {
 
 	// Server Config
 	cfg.Server.Port = "8199"
+	cfg.Server.BaseURL = "http://localhost:8199"
 
 	return cfg
 }
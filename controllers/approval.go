@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/dto"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ApprovalController struct {
+	cfg *config.Config
+	db  *gorm.DB
+}
+
+func NewApprovalController(cfg *config.Config, db *gorm.DB) *ApprovalController {
+	return &ApprovalController{cfg: cfg, db: db}
+}
+
+func approvalErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return 404
+	case errors.Is(err, billing.ErrSelfApproval),
+		errors.Is(err, billing.ErrNotApprovalMaker):
+		return 403
+	case errors.Is(err, billing.ErrApprovalNotPending),
+		errors.Is(err, billing.ErrApprovalExpired),
+		errors.Is(err, billing.ErrCreditLimitExceeded),
+		errors.Is(err, billing.ErrCustomerTerminated):
+		return 409
+	}
+	return 500
+}
+
+// requestBalanceApproval - Simpan perubahan saldo sebagai request pending lalu beri tahu para approver
+func requestBalanceApproval(c *gin.Context, cfg *config.Config, db *gorm.DB, approval models.BalanceApproval) {
+	var customer models.Customer
+	if err := db.First(&customer, approval.CustomerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Customer not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customer", "error": err.Error()})
+		return
+	}
+
+	if err := billing.RequestBalanceApproval(db, cfg, &approval); err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to create approval request", "error": err.Error()})
+		return
+	}
+
+	go billing.NotifyApprovers(cfg, db, approval)
+
+	utils.SuccessResponse(c, 202, gin.H{
+		"message":  "Balance change requires approval from another finance or admin user",
+		"approval": approval,
+	})
+}
+
+// notifyBalanceChange - Alert overdraft dan cek low-water mark setelah saldo berkurang
+func notifyBalanceChange(cfg *config.Config, db *gorm.DB, change *billing.BalanceChange) {
+	if change.Credit.Alert {
+		go billing.NotifyOverdraft(cfg, change.Customer, change.Credit)
+	}
+	go func(customerID uint) {
+		if err := billing.CheckLowBalance(cfg, db, customerID); err != nil {
+			fmt.Printf("⚠️ Failed to check low balance for customer %d: %v\n", customerID, err)
+		}
+	}(change.Customer.ID)
+}
+
+// GetApprovals - List request approval, default yang masih pending
+func (ac *ApprovalController) GetApprovals(c *gin.Context) {
+	var req dto.ApprovalSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+	if req.Status == "" {
+		req.Status = "pending"
+	}
+
+	query := ac.db.Model(&models.BalanceApproval{}).Where("status = ?", req.Status)
+	if req.CustomerID > 0 {
+		query = query.Where("customer_id = ?", req.CustomerID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var approvals []models.BalanceApproval
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(req.PageSize).Find(&approvals).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch approvals", "error": err.Error()})
+		return
+	}
+
+	totalPage := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"approvals":  approvals,
+		"total":      total,
+		"page":       req.Page,
+		"page_size":  req.PageSize,
+		"total_page": totalPage,
+	})
+}
+
+// GetApprovalByID - Detail request beserta nama maker dan checker
+func (ac *ApprovalController) GetApprovalByID(c *gin.Context) {
+	approvalID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid approval ID"})
+		return
+	}
+
+	var approval models.BalanceApproval
+	if err := ac.db.First(&approval, approvalID).Error; err != nil {
+		utils.ErrorResponse(c, approvalErrorStatus(err), gin.H{"message": "Failed to fetch approval", "error": err.Error()})
+		return
+	}
+
+	var customer models.Customer
+	ac.db.First(&customer, approval.CustomerID)
+
+	var maker models.User
+	ac.db.First(&maker, approval.RequestedBy)
+
+	response := gin.H{
+		"approval":      approval,
+		"customer_code": customer.CustomerCode,
+		"company_name":  customer.CompanyName,
+		"balance":       customer.Balance,
+		"credit_limit":  customer.CreditLimit,
+		"requested_by":  maker.Name,
+		"reviewed_by":   nil,
+	}
+	if approval.ReviewedBy != nil {
+		var checker models.User
+		ac.db.First(&checker, *approval.ReviewedBy)
+		response["reviewed_by"] = checker.Name
+	}
+
+	utils.SuccessResponse(c, 200, response)
+}
+
+// ApproveApproval - Checker menyetujui request dan perubahan saldo langsung dieksekusi
+func (ac *ApprovalController) ApproveApproval(c *gin.Context) {
+	approvalID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid approval ID"})
+		return
+	}
+
+	var req dto.ApprovalReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var approval *models.BalanceApproval
+	var change *billing.BalanceChange
+	err = ac.db.Transaction(func(tx *gorm.DB) error {
+		approval, err = billing.FindApprovalForUpdate(tx, approvalID)
+		if err != nil {
+			return err
+		}
+		change, err = billing.ApproveBalanceChange(tx, approval, userID.(uint), req.Note)
+		return err
+	})
+	if err != nil {
+		message := "Failed to approve balance change"
+		if errors.Is(err, billing.ErrCreditLimitExceeded) {
+			message = "Customer balance is no longer sufficient for this change, reject the request instead"
+		}
+		utils.ErrorResponse(c, approvalErrorStatus(err), gin.H{"message": message, "error": err.Error()})
+		return
+	}
+
+	notifyBalanceChange(ac.cfg, ac.db, change)
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"approval":         approval,
+		"journal_entry_id": change.Entry.ID,
+		"old_balance":      change.OldBalance,
+		"new_balance":      change.NewBalance,
+		"tax_amount":       change.TaxAmount,
+	})
+}
+
+// RejectApproval - Checker menolak request dengan alasan, saldo tidak berubah
+func (ac *ApprovalController) RejectApproval(c *gin.Context) {
+	approvalID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid approval ID"})
+		return
+	}
+
+	var req dto.ApprovalRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var approval *models.BalanceApproval
+	err = ac.db.Transaction(func(tx *gorm.DB) error {
+		approval, err = billing.FindApprovalForUpdate(tx, approvalID)
+		if err != nil {
+			return err
+		}
+		return billing.RejectBalanceChange(tx, approval, userID.(uint), req.Note)
+	})
+	if err != nil {
+		utils.ErrorResponse(c, approvalErrorStatus(err), gin.H{"message": "Failed to reject balance change", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, approval)
+}
+
+// CancelApproval - Maker menarik kembali request miliknya
+func (ac *ApprovalController) CancelApproval(c *gin.Context) {
+	approvalID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid approval ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var approval *models.BalanceApproval
+	err = ac.db.Transaction(func(tx *gorm.DB) error {
+		approval, err = billing.FindApprovalForUpdate(tx, approvalID)
+		if err != nil {
+			return err
+		}
+		return billing.CancelBalanceChange(tx, approval, userID.(uint))
+	})
+	if err != nil {
+		utils.ErrorResponse(c, approvalErrorStatus(err), gin.H{"message": "Failed to cancel approval request", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, approval)
+}
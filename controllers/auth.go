@@ -112,6 +112,7 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 	if blocked {
+		ac.recordLoginAttempt(c, nil, req.Email, "password", false, "blocked", nil)
 		utils.ErrorResponse(c, 429, gin.H{
 			"message": "Account temporarily blocked due to too many failed attempts. Please try again after 10 minutes.",
 		})
@@ -124,6 +125,7 @@ func (ac *AuthController) Login(c *gin.Context) {
 		if err == gorm.ErrRecordNotFound {
 			// Untuk keamanan, tetap increment attempts meski user tidak ditemukan
 			database.IncrementLoginAttempts(req.Email, ac.cfg)
+			ac.recordLoginAttempt(c, nil, req.Email, "password", false, "unknown_user", nil)
 			utils.ErrorResponse(c, 401, gin.H{
 				"message": "Invalid email or password",
 			})
@@ -135,6 +137,7 @@ func (ac *AuthController) Login(c *gin.Context) {
 
 	// Check if user is active
 	if user.Status != "active" {
+		ac.recordLoginAttempt(c, &user.ID, req.Email, "password", false, "inactive", nil)
 		utils.ErrorResponse(c, 401, gin.H{"message": "Account is not active"})
 		return
 	}
@@ -143,6 +146,7 @@ func (ac *AuthController) Login(c *gin.Context) {
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
 		// Increment failed login attempts
 		database.IncrementLoginAttempts(req.Email, ac.cfg)
+		ac.recordLoginAttempt(c, &user.ID, req.Email, "password", false, "invalid_password", nil)
 
 		// Get current attempts
 		attempts, _ := database.CheckLoginAttempts(req.Email, ac.cfg)
@@ -168,9 +172,15 @@ func (ac *AuthController) Login(c *gin.Context) {
 	// Reset login attempts on successful password verification
 	database.ResetLoginAttempts(req.Email)
 
+	// Device baru atau lokasi tidak wajar butuh step-up OTP
+	assessment := ac.assessLogin(c, user)
+
 	// Check if user needs OTP verification
-	if !user.IsVerified {
-		// Generate and send OTP for unverified users
+	if !user.IsVerified || assessment.RequiresStepUp() {
+		// Login belum selesai sampai OTP diverifikasi, jadi tidak dipakai sebagai lokasi login terakhir
+		ac.recordLoginAttempt(c, &user.ID, req.Email, "password", false, "pending_otp", &assessment)
+
+		// Generate and send OTP for unverified users or unrecognized devices
 		otp, err := utils.GenerateOTP(ac.cfg.Security.OTPLength)
 		if err != nil {
 			utils.ErrorResponse(c, 500, gin.H{"message": "Failed to generate OTP"})
@@ -206,9 +216,14 @@ func (ac *AuthController) Login(c *gin.Context) {
 			lastLoginStr = &str
 		}
 
+		message := "OTP has been sent to your email for verification"
+		if user.IsVerified {
+			message = "Login from a new device or location. OTP has been sent to your email for verification"
+		}
+
 		response := gin.H{
 			"requires_otp":   true,
-			"message":        "OTP has been sent to your email for verification",
+			"message":        message,
 			"otp_expires_in": int(ttl.Seconds()),
 			"user": gin.H{
 				"id":          user.ID,
@@ -231,6 +246,9 @@ func (ac *AuthController) Login(c *gin.Context) {
 	user.LastLogin = &now
 	ac.db.Save(&user)
 
+	ac.recordLoginAttempt(c, &user.ID, req.Email, "password", true, "", &assessment)
+	ac.rememberDevice(user, assessment)
+
 	// Generate JWT token
 	token, err := middleware.GenerateToken(user.ID, user.Email, user.Role, ac.cfg)
 	if err != nil {
@@ -290,12 +308,14 @@ func (ac *AuthController) VerifyOTP(c *gin.Context) {
 
 	// Check if OTP exists
 	if storedOTP == "" {
+		ac.recordLoginAttempt(c, &user.ID, req.Email, "otp", false, "otp_expired", nil)
 		utils.ErrorResponse(c, 400, gin.H{"message": "OTP has expired or not found"})
 		return
 	}
 
 	// Verify OTP
 	if storedOTP != req.OTP {
+		ac.recordLoginAttempt(c, &user.ID, req.Email, "otp", false, "invalid_otp", nil)
 		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid OTP"})
 		return
 	}
@@ -303,6 +323,14 @@ func (ac *AuthController) VerifyOTP(c *gin.Context) {
 	// OTP valid, delete from Redis
 	database.DeleteOTP(req.Email)
 
+	// Catat device, kirim alert jika device baru atau lokasi tidak wajar
+	assessment := ac.assessLogin(c, user)
+	ac.recordLoginAttempt(c, &user.ID, req.Email, "otp", true, "", &assessment)
+	ac.rememberDevice(user, assessment)
+	if assessment.ShouldAlert() {
+		go ac.sendLoginAlert(user, assessment)
+	}
+
 	// Update user verification status
 	user.IsVerified = true
 	ac.db.Save(&user)
@@ -600,6 +628,24 @@ func (ac *AuthController) GetProfile(c *gin.Context) {
 		"updated_at":  user.UpdatedAt,
 	}
 
+	if user.DeletionScheduledAt != nil {
+		response["deletion_scheduled_at"] = user.DeletionScheduledAt
+	}
+
+	// Ringkasan keamanan login, detail ada di /profile/logins dan /profile/devices.
+	// pending_otp bukan kegagalan, hanya login yang menunggu OTP.
+	var failedLogins int64
+	ac.db.Model(&models.LoginHistory{}).
+		Where("(user_id = ? OR (user_id IS NULL AND email = ?)) AND success = ? AND failure_reason <> ? AND created_at >= ?",
+			user.ID, user.Email, false, "pending_otp", time.Now().Add(-24*time.Hour)).
+		Count(&failedLogins)
+
+	var knownDevices int64
+	ac.db.Model(&models.KnownDevice{}).Where("user_id = ?", user.ID).Count(&knownDevices)
+
+	response["failed_logins_24h"] = failedLogins
+	response["known_devices"] = knownDevices
+
 	utils.SuccessResponse(c, 200, response)
 }
 
@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"auth-api/billing"
 	"auth-api/config"
 	"auth-api/dto"
+	"auth-api/exports"
+	"auth-api/ledger"
 	"auth-api/models"
 	"auth-api/utils"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -38,32 +42,35 @@ func (cc *CustomerController) CreateCustomer(c *gin.Context) {
 		return
 	}
 
-	// Check if customer code already exists
-	var existingCustomer models.Customer
-	if err := cc.db.Where("customer_code = ?", req.CustomerCode).First(&existingCustomer).Error; err == nil {
-		utils.ErrorResponse(c, 400, gin.H{"message": "Customer code already exists"})
+	userRole, _ := c.Get("role")
+	customer, inputErr := newCustomer(req, userRole, userID.(uint))
+	if inputErr != nil {
+		response := gin.H{"message": inputErr.message}
+		if inputErr.detail != "" {
+			response["error"] = inputErr.detail
+		}
+		utils.ErrorResponse(c, inputErr.status, response)
 		return
 	}
 
-	// Create customer
-	customer := models.Customer{
-		CustomerCode: req.CustomerCode,
-		CompanyName:  req.CompanyName,
-		ContactName:  req.ContactName,
-		Email:        req.Email,
-		Phone:        req.Phone,
-		Address:      req.Address,
-		NPWP:         req.NPWP,
-		Balance:      req.Balance,
-		Status:       req.Status,
-		UserID:       userID.(uint),
-	}
-
-	if customer.Status == "" {
-		customer.Status = "active"
+	// Check if customer code already exists (termasuk customer yang dihapus, kode tetap terpakai sampai di-restore)
+	var existingCustomer models.Customer
+	if err := cc.db.Unscoped().Where("customer_code = ?", req.CustomerCode).First(&existingCustomer).Error; err == nil {
+		if existingCustomer.DeletedAt.Valid {
+			utils.ErrorResponse(c, 400, gin.H{"message": "Customer code belongs to a deleted customer", "customer_id": existingCustomer.ID})
+			return
+		}
+		utils.ErrorResponse(c, 400, gin.H{"message": "Customer code already exists"})
+		return
 	}
 
-	if err := cc.db.Create(&customer).Error; err != nil {
+	var approval *models.BalanceApproval
+	err := cc.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		approval, err = createCustomer(tx, cc.cfg, &customer, req.Balance, userID.(uint))
+		return err
+	})
+	if err != nil {
 		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to create customer", "error": err.Error()})
 		return
 	}
@@ -76,7 +83,7 @@ func (cc *CustomerController) CreateCustomer(c *gin.Context) {
 	history := models.CustomerHistory{
 		CustomerID: customer.ID,
 		Action:     "create",
-		Changes:    fmt.Sprintf(`{"customer_code":"%s","company_name":"%s"}`, customer.CustomerCode, customer.CompanyName),
+		Changes:    utils.ToJSON(createChanges(customer)),
 		ChangedBy:  userID.(uint),
 		CreatedAt:  time.Now(),
 	}
@@ -84,10 +91,116 @@ func (cc *CustomerController) CreateCustomer(c *gin.Context) {
 
 	response := dto.ToCustomerResponse(customer)
 	response.CreatedBy = user.Name
+	if approval != nil {
+		go billing.NotifyApprovers(cc.cfg, cc.db, *approval)
+		response.PendingApproval = approval
+	}
 
 	utils.SuccessResponse(c, 201, response)
 }
 
+// customerInputError - Pelanggaran aturan pembuatan customer beserta status HTTP-nya
+type customerInputError struct {
+	status  int
+	message string
+	detail  string
+}
+
+// newCustomer - Aturan bisnis pembuatan customer di luar binding tag, dipakai CreateCustomer dan import
+func newCustomer(req dto.CustomerCreateRequest, userRole interface{}, userID uint) (models.Customer, *customerInputError) {
+	// NPWP disimpan dalam bentuk digit saja
+	if req.NPWP != "" {
+		npwp, err := utils.ValidateNPWP(req.NPWP)
+		if err != nil {
+			return models.Customer{}, &customerInputError{400, "Invalid NPWP", err.Error()}
+		}
+		req.NPWP = npwp
+	}
+
+	// Pembebasan PPN hanya boleh diatur finance dan admin
+	if (req.TaxExempt || req.TaxExemptReason != "" || req.TaxExemptUntil != "") && userRole != "finance" && userRole != "admin" {
+		return models.Customer{}, &customerInputError{403, "Forbidden: Only finance and admin can set tax exemption", ""}
+	}
+	taxExemptUntil, _ := parseTaxExemptUntil(req.TaxExemptUntil)
+
+	// Saldo awal sama dengan perubahan saldo, hanya boleh diisi finance dan admin
+	if req.Balance != 0 && userRole != "finance" && userRole != "admin" {
+		return models.Customer{}, &customerInputError{403, "Forbidden: Only finance and admin can set opening balance", ""}
+	}
+
+	// Credit line juga hanya diatur finance dan admin
+	if (req.CreditLimit > 0 || req.OverdraftPolicy != "" || req.LowBalanceThreshold != nil) && userRole != "finance" && userRole != "admin" {
+		return models.Customer{}, &customerInputError{403, "Forbidden: Only finance and admin can set credit limit", ""}
+	}
+
+	customer := models.Customer{
+		CustomerCode:        req.CustomerCode,
+		CompanyName:         req.CompanyName,
+		ContactName:         req.ContactName,
+		Email:               req.Email,
+		Phone:               req.Phone,
+		Address:             req.Address,
+		NPWP:                req.NPWP,
+		TaxExempt:           req.TaxExempt,
+		TaxExemptReason:     req.TaxExemptReason,
+		TaxExemptUntil:      taxExemptUntil,
+		CreditLimit:         req.CreditLimit,
+		OverdraftPolicy:     req.OverdraftPolicy,
+		LowBalanceThreshold: req.LowBalanceThreshold,
+		Status:              req.Status,
+		UserID:              userID,
+	}
+
+	if customer.Status == "" {
+		customer.Status = "active"
+	}
+	if customer.Status == "terminated" {
+		return models.Customer{}, &customerInputError{400, "A new customer cannot be created as terminated", ""}
+	}
+	if customer.OverdraftPolicy == "" {
+		customer.OverdraftPolicy = billing.OverdraftHardStop
+	}
+	return customer, nil
+}
+
+// createCustomer - Simpan customer baru. Saldo awal dicatat lewat ledger, bukan langsung ke kolom balance.
+// Saldo awal di atas threshold approval tidak diterapkan dan dikembalikan sebagai request pending.
+func createCustomer(tx *gorm.DB, cfg *config.Config, customer *models.Customer, balance models.Money, userID uint) (*models.BalanceApproval, error) {
+	if err := tx.Create(customer).Error; err != nil {
+		return nil, err
+	}
+	if balance <= 0 {
+		return nil, nil
+	}
+
+	if billing.NeedsApproval(cfg, balance) {
+		approval := &models.BalanceApproval{
+			CustomerID:  customer.ID,
+			Type:        "adjustment",
+			Amount:      balance,
+			Notes:       "Opening balance",
+			Reason:      "threshold",
+			RequestedBy: userID,
+		}
+		return approval, billing.RequestBalanceApproval(tx, cfg, approval)
+	}
+
+	if _, err := ledger.Adjust(tx, customer.ID, balance, "opening_balance", "Opening balance", userID); err != nil {
+		return nil, err
+	}
+	customer.Balance = balance
+	return nil, nil
+}
+
+// createChanges - Isi Changes history "create"
+func createChanges(customer models.Customer) map[string]interface{} {
+	return map[string]interface{}{
+		"customer_code": customer.CustomerCode,
+		"company_name":  customer.CompanyName,
+		"tax_exempt":    customer.TaxExempt,
+	}
+}
+
 // GetCustomers - Mendapatkan list customers dengan pagination dan filter
 func (cc *CustomerController) GetCustomers(c *gin.Context) {
 	var req dto.CustomerSearchRequest
@@ -104,59 +217,16 @@ func (cc *CustomerController) GetCustomers(c *gin.Context) {
 		req.PageSize = 10
 	}
 
-	// Get user info for access control
-	userID, _ := c.Get("user_id")
-	userRole, _ := c.Get("role")
-
-	// Build query
-	query := cc.db.Model(&models.Customer{})
-
-	// Apply role-based access control
-	if userRole == "customer" {
-		// Customer hanya bisa melihat data miliknya sendiri
-		query = query.Where("user_id = ?", userID)
-	} else if userRole == "finance" {
-		// Finance bisa melihat semua kecuali yang di-terminated
-		query = query.Where("status != ?", "terminated")
-	}
-	// Admin bisa melihat semua
-
-	// Apply search filter
-	if req.Search != "" {
-		search := "%" + strings.ToLower(req.Search) + "%"
-		query = query.Where("LOWER(customer_code) LIKE ? OR LOWER(company_name) LIKE ? OR LOWER(contact_name) LIKE ? OR LOWER(email) LIKE ?",
-			search, search, search, search)
-	}
-
-	// Apply status filter
-	if req.Status != "" {
-		query = query.Where("status = ?", req.Status)
-	}
+	// Build query dengan role-based access control serta filter search dan status
+	filter := customerFilter(req)
+	query := exports.FilterCustomers(cc.db.Model(&models.Customer{}), exportScope(c), filter)
 
 	// Get total count
 	var total int64
 	query.Count(&total)
 
 	// Apply sorting
-	sortOrder := "DESC"
-	if req.SortOrder == "asc" {
-		sortOrder = "ASC"
-	}
-
-	validSortFields := map[string]bool{
-		"customer_code": true,
-		"company_name":  true,
-		"balance":       true,
-		"created_at":    true,
-		"updated_at":    true,
-	}
-
-	sortField := "created_at"
-	if validSortFields[req.SortBy] {
-		sortField = req.SortBy
-	}
-
-	query = query.Order(fmt.Sprintf("%s %s", sortField, sortOrder))
+	query = query.Order(exports.CustomerSortOrder(filter))
 
 	// Apply pagination
 	offset := (req.Page - 1) * req.PageSize
@@ -231,6 +301,7 @@ func (cc *CustomerController) GetCustomerByID(c *gin.Context) {
 	response := dto.ToCustomerResponse(customer)
 	response.CreatedBy = customer.User.Name
 
+	c.Header("ETag", customerETag(customer))
 	utils.SuccessResponse(c, 200, response)
 }
 
@@ -274,6 +345,16 @@ func (cc *CustomerController) UpdateCustomer(c *gin.Context) {
 		return
 	}
 
+	// Optimistic concurrency: If-Match harus sama dengan ETag customer saat ini
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && ifMatch != "*" && ifMatch != customerETag(customer) {
+		c.Header("ETag", customerETag(customer))
+		utils.ErrorResponse(c, 412, gin.H{
+			"message":         "Customer has been modified by another request",
+			"current_version": customer.Version,
+		})
+		return
+	}
+
 	// Track changes for history
 	changes := make(map[string]interface{})
 	oldValues := make(map[string]interface{})
@@ -310,29 +391,189 @@ func (cc *CustomerController) UpdateCustomer(c *gin.Context) {
 		customer.Address = req.Address
 	}
 
-	if req.NPWP != "" && req.NPWP != customer.NPWP {
-		oldValues["npwp"] = customer.NPWP
-		newValues["npwp"] = req.NPWP
-		customer.NPWP = req.NPWP
+	if req.NPWP != "" {
+		npwp, err := utils.ValidateNPWP(req.NPWP)
+		if err != nil {
+			utils.ErrorResponse(c, 400, gin.H{"message": "Invalid NPWP", "error": err.Error()})
+			return
+		}
+		if npwp != customer.NPWP {
+			oldValues["npwp"] = customer.NPWP
+			newValues["npwp"] = npwp
+			customer.NPWP = npwp
+		}
+	}
+
+	// Pembebasan PPN hanya boleh diubah finance dan admin
+	if req.TaxExempt != nil || req.TaxExemptReason != nil || req.TaxExemptUntil != nil {
+		if userRole != "finance" && userRole != "admin" {
+			utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: Only finance and admin can update tax exemption"})
+			return
+		}
+	}
+
+	if req.TaxExempt != nil && *req.TaxExempt != customer.TaxExempt {
+		oldValues["tax_exempt"] = customer.TaxExempt
+		newValues["tax_exempt"] = *req.TaxExempt
+		customer.TaxExempt = *req.TaxExempt
 	}
 
-	if req.Balance != 0 && req.Balance != customer.Balance {
-		oldValues["balance"] = customer.Balance
-		newValues["balance"] = req.Balance
-		customer.Balance = req.Balance
+	if req.TaxExemptReason != nil && *req.TaxExemptReason != customer.TaxExemptReason {
+		oldValues["tax_exempt_reason"] = customer.TaxExemptReason
+		newValues["tax_exempt_reason"] = *req.TaxExemptReason
+		customer.TaxExemptReason = *req.TaxExemptReason
 	}
 
+	if req.TaxExemptUntil != nil {
+		until, err := parseTaxExemptUntil(*req.TaxExemptUntil)
+		if err != nil {
+			utils.ErrorResponse(c, 400, gin.H{"message": "Invalid tax_exempt_until, expected YYYY-MM-DD"})
+			return
+		}
+		if !sameDate(until, customer.TaxExemptUntil) {
+			oldValues["tax_exempt_until"] = customer.TaxExemptUntil
+			newValues["tax_exempt_until"] = until
+			customer.TaxExemptUntil = until
+		}
+	}
+
+	// Perubahan status mengikuti state machine, wajib disertai alasan dan dicatat sebagai status_change
+	var statusChange string
 	if req.Status != "" && req.Status != customer.Status {
-		oldValues["status"] = customer.Status
-		newValues["status"] = req.Status
-		customer.Status = req.Status
+		if userRole != "finance" && userRole != "admin" {
+			utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: Only finance and admin can change customer status"})
+			return
+		}
+		if !billing.CanTransition(customer.Status, req.Status) {
+			utils.ErrorResponse(c, 400, gin.H{"message": fmt.Sprintf("Cannot change status from %s to %s", customer.Status, req.Status)})
+			return
+		}
+		if strings.TrimSpace(req.StatusReason) == "" {
+			utils.ErrorResponse(c, 400, gin.H{"message": billing.ErrStatusReasonRequired.Error()})
+			return
+		}
+		statusChange = req.Status
+	}
+
+	// Credit limit, overdraft policy dan low-water mark hanya boleh diubah finance dan admin
+	if req.CreditLimit != nil || req.OverdraftPolicy != "" || req.LowBalanceThreshold != nil || req.ClearLowBalanceThreshold {
+		if userRole != "finance" && userRole != "admin" {
+			utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: Only finance and admin can update credit limit"})
+			return
+		}
+	}
+
+	if req.CreditLimit != nil && *req.CreditLimit != customer.CreditLimit {
+		oldValues["credit_limit"] = customer.CreditLimit
+		newValues["credit_limit"] = *req.CreditLimit
+		customer.CreditLimit = *req.CreditLimit
+	}
+
+	if req.OverdraftPolicy != "" && req.OverdraftPolicy != customer.OverdraftPolicy {
+		oldValues["overdraft_policy"] = customer.OverdraftPolicy
+		newValues["overdraft_policy"] = req.OverdraftPolicy
+		customer.OverdraftPolicy = req.OverdraftPolicy
+	}
+
+	if req.ClearLowBalanceThreshold {
+		if customer.LowBalanceThreshold != nil {
+			oldValues["low_balance_threshold"] = customer.LowBalanceThreshold
+			newValues["low_balance_threshold"] = nil
+			customer.LowBalanceThreshold = nil
+		}
+	} else if req.LowBalanceThreshold != nil && (customer.LowBalanceThreshold == nil || *req.LowBalanceThreshold != *customer.LowBalanceThreshold) {
+		oldValues["low_balance_threshold"] = customer.LowBalanceThreshold
+		newValues["low_balance_threshold"] = *req.LowBalanceThreshold
+		customer.LowBalanceThreshold = req.LowBalanceThreshold
+	}
+
+	// Perubahan balance lewat jalur saldo yang sama dengan endpoint balance, hanya untuk finance dan admin
+	var balanceDelta models.Money
+	if req.Balance != 0 && req.Balance != customer.Balance {
+		if userRole != "finance" && userRole != "admin" {
+			utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: Only finance and admin can update balance"})
+			return
+		}
+		balanceDelta = req.Balance - customer.Balance
+	}
+
+	// Koreksi saldo besar tidak langsung diterapkan, tapi menunggu approval user lain.
+	// Penurunan saldo diajukan sebagai deduct agar tetap melewati cek status dan credit limit saat di-approve.
+	var pendingApproval *models.BalanceApproval
+	if billing.NeedsApproval(cc.cfg, balanceDelta) {
+		pendingApproval = balanceSetApproval(customer.ID, balanceDelta, "threshold", userID.(uint))
+		balanceDelta = 0
 	}
 
-	// Save changes
-	if err := cc.db.Save(&customer).Error; err != nil {
+	// Save changes, hanya berhasil jika version belum berubah sejak dibaca
+	var balanceChange *billing.BalanceChange
+	err = cc.db.Transaction(func(tx *gorm.DB) error {
+		if len(newValues) == 0 && balanceDelta == 0 && statusChange == "" {
+			return nil
+		}
+
+		updates := map[string]interface{}{"version": gorm.Expr("version + 1")}
+		for field, value := range newValues {
+			updates[field] = value
+		}
+		result := tx.Model(&models.Customer{}).
+			Where("id = ? AND version = ?", customer.ID, customer.Version).
+			Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVersionConflict
+		}
+
+		// History balance_update ditulis oleh DeductBalance/AdjustBalance
+		actor := billing.BalanceActor{RequestedBy: userID.(uint)}
+		if balanceDelta < 0 {
+			var err error
+			balanceChange, err = billing.DeductBalance(tx, customer.ID, -balanceDelta, balanceSetNotes, actor)
+			if err == billing.ErrOverdraftApprovalRequired {
+				// Perubahan field lain tetap disimpan, pemotongan saldo menunggu approval
+				pendingApproval = balanceSetApproval(customer.ID, balanceDelta, "overdraft", userID.(uint))
+				balanceChange = nil
+			} else if err != nil {
+				return err
+			}
+		} else if balanceDelta > 0 {
+			var err error
+			if balanceChange, err = billing.AdjustBalance(tx, customer.ID, balanceDelta, balanceSetNotes, actor); err != nil {
+				return err
+			}
+		}
+
+		if statusChange != "" {
+			return billing.ChangeCustomerStatus(tx, &customer, statusChange, req.StatusReason, billing.StatusSourceManual, userID.(uint))
+		}
+		return nil
+	})
+	if err == errVersionConflict || err == billing.ErrStatusConflict {
+		utils.ErrorResponse(c, 412, gin.H{"message": "Customer has been modified by another request"})
+		return
+	}
+	if err == billing.ErrCustomerTerminated {
+		utils.ErrorResponse(c, 409, gin.H{"message": "Cannot deduct balance of a terminated customer"})
+		return
+	}
+	if err == billing.ErrCreditLimitExceeded {
+		utils.ErrorResponse(c, 400, gin.H{
+			"message":          "Insufficient balance",
+			"balance":          customer.Balance,
+			"credit_limit":     customer.CreditLimit,
+			"available_credit": customer.AvailableCredit(),
+		})
+		return
+	}
+	if err != nil {
 		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to update customer", "error": err.Error()})
 		return
 	}
+	if balanceChange != nil {
+		notifyBalanceChange(cc.cfg, cc.db, balanceChange)
+	}
 
 	// Create history record if there were changes
 	if len(oldValues) > 0 {
@@ -349,10 +590,41 @@ func (cc *CustomerController) UpdateCustomer(c *gin.Context) {
 		cc.db.Create(&history)
 	}
 
+	cc.db.First(&customer, customer.ID)
+
 	response := dto.ToCustomerResponse(customer)
+
+	if pendingApproval != nil {
+		if err := billing.RequestBalanceApproval(cc.db, cc.cfg, pendingApproval); err != nil {
+			utils.ErrorResponse(c, 500, gin.H{"message": "Failed to create approval request", "error": err.Error()})
+			return
+		}
+		go billing.NotifyApprovers(cc.cfg, cc.db, *pendingApproval)
+		response.PendingApproval = pendingApproval
+	}
+
+	c.Header("ETag", customerETag(customer))
 	utils.SuccessResponse(c, 200, response)
 }
 
+const balanceSetNotes = "Balance set via customer update"
+
+// balanceSetApproval - Request approval untuk set balance: penurunan sebagai deduct, kenaikan sebagai adjustment
+func balanceSetApproval(customerID uint, delta models.Money, reason string, userID uint) *models.BalanceApproval {
+	approval := &models.BalanceApproval{
+		CustomerID:  customerID,
+		Type:        "adjustment",
+		Amount:      delta,
+		Notes:       balanceSetNotes,
+		Reason:      reason,
+		RequestedBy: userID,
+	}
+	if delta < 0 {
+		approval.Type, approval.Amount = "deduct", -delta
+	}
+	return approval
+}
+
 // DeleteCustomer - Menghapus customer (soft delete)
 func (cc *CustomerController) DeleteCustomer(c *gin.Context) {
 	id := c.Param("id")
@@ -388,18 +660,11 @@ func (cc *CustomerController) DeleteCustomer(c *gin.Context) {
 		return
 	}
 
-	// Create history record before delete
-	history := models.CustomerHistory{
-		CustomerID: customer.ID,
-		Action:     "delete",
-		Changes:    fmt.Sprintf(`{"customer_code":"%s","company_name":"%s"}`, customer.CustomerCode, customer.CompanyName),
-		ChangedBy:  userID.(uint),
-		CreatedAt:  time.Now(),
-	}
-	cc.db.Create(&history)
-
-	// Delete customer
-	if err := cc.db.Delete(&customer).Error; err != nil {
+	// History dicatat dalam transaksi yang sama dengan delete
+	err = cc.db.Transaction(func(tx *gorm.DB) error {
+		return billing.DeleteCustomer(tx, &customer, userID.(uint))
+	})
+	if err != nil {
 		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to delete customer", "error": err.Error()})
 		return
 	}
@@ -410,7 +675,104 @@ func (cc *CustomerController) DeleteCustomer(c *gin.Context) {
 	})
 }
 
-// UpdateCustomerBalance - Update balance customer (deposit/deduct)
+// RestoreCustomer - Kembalikan customer yang dihapus selama belum di-purge (admin)
+func (cc *CustomerController) RestoreCustomer(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid customer ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var customer models.Customer
+	if err := cc.db.Unscoped().First(&customer, customerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Customer not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customer", "error": err.Error()})
+		return
+	}
+
+	err = cc.db.Transaction(func(tx *gorm.DB) error {
+		return billing.RestoreCustomer(tx, &customer, userID.(uint))
+	})
+	switch {
+	case errors.Is(err, billing.ErrCustomerNotDeleted):
+		utils.ErrorResponse(c, 409, gin.H{"message": err.Error()})
+		return
+	case errors.Is(err, billing.ErrCustomerPurged):
+		utils.ErrorResponse(c, 410, gin.H{"message": err.Error()})
+		return
+	case errors.Is(err, billing.ErrCustomerConflict):
+		utils.ErrorResponse(c, 412, gin.H{"message": err.Error()})
+		return
+	case err != nil:
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to restore customer", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, dto.ToCustomerResponse(customer))
+}
+
+// GetDeletedCustomers - List customer yang dihapus beserta jadwal purge-nya (admin)
+func (cc *CustomerController) GetDeletedCustomers(c *gin.Context) {
+	var req dto.CustomerSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+
+	filter := exports.CustomerFilter{Search: req.Search, Status: req.Status, Tag: req.Tag}
+	query := exports.FilterCustomers(cc.db.Unscoped().Model(&models.Customer{}), exports.Scope{Role: "admin"}, filter).
+		Where("deleted_at IS NOT NULL")
+
+	var total int64
+	query.Count(&total)
+
+	var customers []models.Customer
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("deleted_at DESC, id DESC").Offset(offset).Limit(req.PageSize).Find(&customers).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch deleted customers", "error": err.Error()})
+		return
+	}
+
+	type deletedCustomer struct {
+		dto.CustomerResponse
+		PurgeAt *time.Time `json:"purge_at,omitempty"`
+	}
+	responses := make([]deletedCustomer, len(customers))
+	for i, customer := range customers {
+		responses[i].CustomerResponse = dto.ToCustomerResponse(customer)
+		if customer.PurgedAt == nil {
+			purgeAt := customer.DeletedAt.Time.Add(cc.cfg.Privacy.CustomerRetention)
+			responses[i].PurgeAt = &purgeAt
+		}
+	}
+
+	totalPage := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"customers":  responses,
+		"total":      total,
+		"page":       req.Page,
+		"page_size":  req.PageSize,
+		"total_page": totalPage,
+	})
+}
+
+// UpdateCustomerBalance - Potong balance customer (deposit dicatat sebagai payment)
 func (cc *CustomerController) UpdateCustomerBalance(c *gin.Context) {
 	id := c.Param("id")
 	customerID, err := strconv.ParseUint(id, 10, 32)
@@ -440,60 +802,80 @@ func (cc *CustomerController) UpdateCustomerBalance(c *gin.Context) {
 		return
 	}
 
-	// Find customer
-	var customer models.Customer
-	if err := cc.db.First(&customer, customerID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			utils.ErrorResponse(c, 404, gin.H{"message": "Customer not found"})
-			return
-		}
-		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customer", "error": err.Error()})
+	// Nominal di atas threshold harus disetujui finance/admin lain (maker-checker)
+	if billing.NeedsApproval(cc.cfg, req.Amount) {
+		requestBalanceApproval(c, cc.cfg, cc.db, models.BalanceApproval{
+			CustomerID:  uint(customerID),
+			Type:        "deduct",
+			Amount:      req.Amount,
+			Notes:       req.Notes,
+			Reason:      "threshold",
+			RequestedBy: userID.(uint),
+		})
 		return
 	}
 
-	// Calculate new balance
-	oldBalance := customer.Balance
-	var newBalance float64
-
-	if req.Type == "deposit" {
-		newBalance = customer.Balance + req.Amount
-	} else if req.Type == "deduct" {
-		// Check if balance is sufficient for deduction
-		if customer.Balance < req.Amount {
-			utils.ErrorResponse(c, 400, gin.H{"message": "Insufficient balance"})
-			return
-		}
-		newBalance = customer.Balance - req.Amount
+	// Journal entry, cache balance dan history ditulis dalam satu transaksi dengan row lock customer
+	var change *billing.BalanceChange
+	err = cc.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		change, err = billing.DeductBalance(tx, uint(customerID), req.Amount, req.Notes, billing.BalanceActor{RequestedBy: userID.(uint)})
+		return err
+	})
+	if err == gorm.ErrRecordNotFound {
+		utils.ErrorResponse(c, 404, gin.H{"message": "Customer not found"})
+		return
 	}
-
-	// Update balance
-	customer.Balance = newBalance
-	if err := cc.db.Save(&customer).Error; err != nil {
+	if err == billing.ErrCustomerTerminated {
+		utils.ErrorResponse(c, 409, gin.H{"message": "Cannot deduct balance of a terminated customer"})
+		return
+	}
+	if err == billing.ErrCreditLimitExceeded {
+		utils.ErrorResponse(c, 400, gin.H{
+			"message":          "Insufficient balance",
+			"balance":          change.Customer.Balance,
+			"credit_limit":     change.Customer.CreditLimit,
+			"available_credit": change.Customer.AvailableCredit(),
+		})
+		return
+	}
+	// Overdraft policy require_approval: deduction menunggu persetujuan user lain
+	if err == billing.ErrOverdraftApprovalRequired {
+		requestBalanceApproval(c, cc.cfg, cc.db, models.BalanceApproval{
+			CustomerID:  uint(customerID),
+			Type:        "deduct",
+			Amount:      req.Amount,
+			Notes:       req.Notes,
+			Reason:      "overdraft",
+			RequestedBy: userID.(uint),
+		})
+		return
+	}
+	if err != nil {
 		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to update balance", "error": err.Error()})
 		return
 	}
 
-	// Create history record
-	history := models.CustomerHistory{
-		CustomerID: customer.ID,
-		Action:     "balance_update",
-		Changes: fmt.Sprintf(`{"type":"%s","amount":%.2f,"old_balance":%.2f,"new_balance":%.2f,"notes":"%s"}`,
-			req.Type, req.Amount, oldBalance, newBalance, req.Notes),
-		ChangedBy: userID.(uint),
-		CreatedAt: time.Now(),
-	}
-	cc.db.Create(&history)
+	notifyBalanceChange(cc.cfg, cc.db, change)
 
+	customer := change.Customer
 	response := gin.H{
-		"customer_id":   customer.ID,
-		"customer_code": customer.CustomerCode,
-		"company_name":  customer.CompanyName,
-		"old_balance":   oldBalance,
-		"new_balance":   newBalance,
-		"amount":        req.Amount,
-		"type":          req.Type,
-		"notes":         req.Notes,
-		"updated_at":    customer.UpdatedAt,
+		"journal_entry_id": change.Entry.ID,
+		"customer_id":      customer.ID,
+		"customer_code":    customer.CustomerCode,
+		"company_name":     customer.CompanyName,
+		"old_balance":      change.OldBalance,
+		"new_balance":      change.NewBalance,
+		"credit_limit":     customer.CreditLimit,
+		"credit_used":      change.Credit.CreditUsed,
+		"overdraft":        change.Credit.Overdraft,
+		"amount":           req.Amount,
+		"net_amount":       change.NetAmount,
+		"tax_amount":       change.TaxAmount,
+		"tax_rate":         float64(change.TaxRateBps) / 100,
+		"type":             req.Type,
+		"notes":            req.Notes,
+		"updated_at":       customer.UpdatedAt,
 	}
 
 	utils.SuccessResponse(c, 200, response)
@@ -559,8 +941,13 @@ func (cc *CustomerController) GetCustomerHistory(c *gin.Context) {
 	// Get user names for changed_by
 	var historyResponses []gin.H
 	for _, h := range history {
+		// ChangedBy 0 = perubahan otomatis oleh sistem (mis. auto-suspend)
 		var user models.User
-		cc.db.Select("name").First(&user, h.ChangedBy)
+		if h.ChangedBy == 0 {
+			user.Name = "system"
+		} else {
+			cc.db.Select("name").First(&user, h.ChangedBy)
+		}
 
 		historyResponses = append(historyResponses, gin.H{
 			"id":            h.ID,
@@ -605,7 +992,7 @@ func (cc *CustomerController) GetCustomerStats(c *gin.Context) {
 		var activeCustomers int64
 		var suspendedCustomers int64
 		var terminatedCustomers int64
-		var totalBalance float64
+		var totalBalance models.Money
 
 		cc.db.Model(&models.Customer{}).Count(&totalCustomers)
 		cc.db.Model(&models.Customer{}).Where("status = ?", "active").Count(&activeCustomers)
@@ -619,13 +1006,14 @@ func (cc *CustomerController) GetCustomerStats(c *gin.Context) {
 			"suspended_customers":  suspendedCustomers,
 			"terminated_customers": terminatedCustomers,
 			"total_balance":        totalBalance,
-			"average_balance":      totalBalance / float64(totalCustomers),
+			"average_balance":      totalBalance.Div(totalCustomers),
+			"credit":               creditStats(cc.db.Model(&models.Customer{})),
 		}
 	} else if userRole == "customer" {
 		// Customer hanya bisa melihat stats miliknya sendiri
 		var totalCustomers int64
 		var activeCustomers int64
-		var totalBalance float64
+		var totalBalance models.Money
 
 		cc.db.Model(&models.Customer{}).Where("user_id = ?", userID).Count(&totalCustomers)
 		cc.db.Model(&models.Customer{}).Where("user_id = ? AND status = ?", userID, "active").Count(&activeCustomers)
@@ -636,57 +1024,124 @@ func (cc *CustomerController) GetCustomerStats(c *gin.Context) {
 			"total_customers":  totalCustomers,
 			"active_customers": activeCustomers,
 			"total_balance":    totalBalance,
-			"average_balance":  totalBalance / float64(totalCustomers),
+			"average_balance":  totalBalance.Div(totalCustomers),
+			"credit":           creditStats(cc.db.Model(&models.Customer{}).Where("user_id = ?", userID)),
 		}
 	}
 
 	utils.SuccessResponse(c, 200, stats)
 }
 
-// ExportCustomers - Export customers ke CSV
+// customerFilter - Filter list customer, dipakai juga oleh export
+func customerFilter(req dto.CustomerSearchRequest) exports.CustomerFilter {
+	return exports.CustomerFilter{
+		Search:    req.Search,
+		Status:    req.Status,
+		Tag:       req.Tag,
+		SortBy:    req.SortBy,
+		SortOrder: req.SortOrder,
+	}
+}
+
+// ExportCustomers - Export customer (csv, xlsx atau ndjson) secara streaming dengan filter yang sama seperti list customer
 func (cc *CustomerController) ExportCustomers(c *gin.Context) {
-	// Get user info for access control
-	userID, _ := c.Get("user_id")
-	userRole, _ := c.Get("role")
+	var req dto.CustomerExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
 
-	var customers []models.Customer
-	query := cc.db.Preload("User")
+	columns, err := exports.ParseCustomerColumns(req.Columns)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error(), "columns": exports.CustomerColumnKeys()})
+		return
+	}
 
-	if userRole == "customer" {
-		query = query.Where("user_id = ?", userID)
-	} else if userRole == "finance" {
-		query = query.Where("status != ?", "terminated")
+	scope := exportScope(c)
+	filter := customerFilter(req.CustomerSearchRequest)
+	streamExport(c, req.Format, "customers_export", req.BOM, func(w exports.Writer) (int64, error) {
+		return exports.WriteCustomers(cc.db, w, scope, filter, columns)
+	})
+}
+
+// ExportCustomerHistory - Export history satu customer (csv, xlsx atau ndjson)
+func (cc *CustomerController) ExportCustomerHistory(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid customer ID"})
+		return
 	}
 
-	if err := query.Find(&customers).Error; err != nil {
-		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customers for export"})
+	var req dto.CustomerHistoryExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
 		return
 	}
 
-	// Generate CSV
-	csvData := "ID,Customer Code,Company Name,Contact Name,Email,Phone,Address,NPWP,Balance,Status,Created By,Created At\n"
+	var customer models.Customer
+	if err := cc.db.First(&customer, customerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Customer not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customer", "error": err.Error()})
+		return
+	}
 
-	for _, customer := range customers {
-		csvData += fmt.Sprintf("%d,%s,%s,%s,%s,%s,%s,%s,%.2f,%s,%s,%s\n",
-			customer.ID,
-			customer.CustomerCode,
-			customer.CompanyName,
-			customer.ContactName,
-			customer.Email,
-			customer.Phone,
-			strings.ReplaceAll(customer.Address, ",", ";"),
-			customer.NPWP,
-			customer.Balance,
-			customer.Status,
-			customer.User.Name,
-			customer.CreatedAt.Format("2006-01-02 15:04:05"),
-		)
-	}
-
-	// Set response headers for file download
-	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", "attachment; filename=customers_export.csv")
-	c.Header("Content-Length", fmt.Sprintf("%d", len(csvData)))
-
-	c.String(200, csvData)
+	scope := exportScope(c)
+	if scope.Role == "customer" && customer.UserID != scope.UserID {
+		utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: You can only view history of your own customers"})
+		return
+	}
+
+	filter := exports.HistoryFilter{CustomerID: customer.ID, Action: req.Action, From: req.From, To: req.To}
+	streamExport(c, req.Format, fmt.Sprintf("history_%s", customer.CustomerCode), req.BOM, func(w exports.Writer) (int64, error) {
+		return exports.WriteHistory(cc.db, w, scope, filter)
+	})
+}
+
+var errVersionConflict = errors.New("customer version conflict")
+
+// creditStats - Pemakaian credit line: saldo negatif dibandingkan total credit limit
+func creditStats(query *gorm.DB) gin.H {
+	var totalLimit, totalUsed models.Money
+	var usingCredit, overLimit int64
+
+	query.Session(&gorm.Session{}).
+		Select("COALESCE(SUM(credit_limit), 0), COALESCE(SUM(CASE WHEN balance < 0 THEN -balance ELSE 0 END), 0)").
+		Row().Scan(&totalLimit, &totalUsed)
+	query.Session(&gorm.Session{}).Where("balance < 0").Count(&usingCredit)
+	query.Session(&gorm.Session{}).Where("balance < -credit_limit").Count(&overLimit)
+
+	return gin.H{
+		"total_credit_limit":     totalLimit,
+		"total_credit_used":      totalUsed,
+		"credit_utilization":     billing.CreditUtilization(totalUsed, totalLimit),
+		"customers_using_credit": usingCredit,
+		"customers_over_limit":   overLimit,
+	}
+}
+
+// parseTaxExemptUntil - "" berarti pembebasan PPN tanpa batas waktu
+func parseTaxExemptUntil(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	until, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &until, nil
+}
+
+func sameDate(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+// customerETag - ETag berdasarkan version customer, dipakai untuk If-Match
+func customerETag(customer models.Customer) string {
+	return fmt.Sprintf(`"%d-%d"`, customer.ID, customer.Version)
 }
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/dto"
+	"auth-api/exports"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BulkCustomers - Operasi massal (ubah status, pindah owner, tambah tag, hapus) yang dijalankan di background.
+// Daftar customer ditetapkan saat request, hasil per customer bisa dilihat lewat GetBulkJob.
+func (cc *CustomerController) BulkCustomers(c *gin.Context) {
+	var req dto.CustomerBulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	scope := exportScope(c)
+	if err := billing.CheckBulkPermission(scope.Role, req.Action); err != nil {
+		utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden", "error": err.Error()})
+		return
+	}
+
+	if (len(req.IDs) > 0) == (req.Filter != nil) {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Provide either ids or filter"})
+		return
+	}
+
+	params := billing.BulkParams{}
+	switch req.Action {
+	case billing.BulkActionSetStatus:
+		if req.Status == "" {
+			utils.ErrorResponse(c, 400, gin.H{"message": "status is required for set_status"})
+			return
+		}
+		if strings.TrimSpace(req.Reason) == "" {
+			utils.ErrorResponse(c, 400, gin.H{"message": billing.ErrStatusReasonRequired.Error()})
+			return
+		}
+		params.Status, params.Reason = req.Status, req.Reason
+	case billing.BulkActionReassign:
+		var owner models.User
+		if err := cc.db.Select("id").Where("id = ? AND anonymized_at IS NULL", req.UserID).First(&owner).Error; err != nil {
+			utils.ErrorResponse(c, 400, gin.H{"message": "user_id must be an existing user"})
+			return
+		}
+		params.UserID = req.UserID
+	case billing.BulkActionAddTag:
+		params.Tag = billing.NormalizeTag(req.Tag)
+		if params.Tag == "" {
+			utils.ErrorResponse(c, 400, gin.H{"message": billing.ErrTagRequired.Error()})
+			return
+		}
+	}
+
+	ids, err := cc.bulkTargets(scope, req)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to resolve customers", "error": err.Error()})
+		return
+	}
+	if len(ids) == 0 {
+		utils.ErrorResponse(c, 400, gin.H{"message": "No customers match the request"})
+		return
+	}
+	if len(ids) > cc.cfg.Bulk.MaxItems {
+		utils.ErrorResponse(c, 400, gin.H{"message": fmt.Sprintf("Bulk operations are limited to %d customers", cc.cfg.Bulk.MaxItems)})
+		return
+	}
+
+	job := models.CustomerBulkJob{
+		Action:        req.Action,
+		Params:        utils.ToJSON(params),
+		Status:        "pending",
+		Total:         len(ids),
+		RequestedBy:   scope.UserID,
+		RequestedRole: scope.Role,
+	}
+	err = cc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		items := make([]models.CustomerBulkItem, len(ids))
+		for i, id := range ids {
+			items[i] = models.CustomerBulkItem{JobID: job.ID, CustomerID: id, Status: "pending"}
+		}
+		return tx.CreateInBatches(items, 500).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to create bulk job", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 202, gin.H{
+		"job":        job,
+		"status_url": fmt.Sprintf("%s/billapi/v2/customers/bulk/%d", cc.cfg.Server.BaseURL, job.ID),
+	})
+}
+
+// bulkTargets - ID customer dari daftar ids (unik, urutan dipertahankan) atau dari filter sesuai scope requester.
+// Customer di luar scope pada daftar ids tetap dimasukkan agar tercatat gagal per item.
+func (cc *CustomerController) bulkTargets(scope exports.Scope, req dto.CustomerBulkRequest) ([]uint, error) {
+	if req.Filter == nil {
+		seen := make(map[uint]bool)
+		ids := []uint{}
+		for _, id := range req.IDs {
+			if id != 0 && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	filter := exports.CustomerFilter{Search: req.Filter.Search, Status: req.Filter.Status, Tag: req.Filter.Tag}
+	var ids []uint
+	err := exports.FilterCustomers(cc.db.Model(&models.Customer{}), scope, filter).
+		Order("id ASC").Limit(cc.cfg.Bulk.MaxItems+1).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// GetBulkJob - Status operasi massal beserta hasil per customer, hanya untuk user yang membuatnya
+func (cc *CustomerController) GetBulkJob(c *gin.Context) {
+	jobID, err := strconv.ParseUint(c.Param("job_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid bulk job ID"})
+		return
+	}
+
+	var req dto.CustomerBulkItemSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var job models.CustomerBulkJob
+	if err := cc.db.Where("id = ? AND requested_by = ?", jobID, userID).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Bulk job not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch bulk job", "error": err.Error()})
+		return
+	}
+
+	query := cc.db.Model(&models.CustomerBulkItem{}).Where("job_id = ?", job.ID)
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var items []models.CustomerBulkItem
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("id ASC").Offset(offset).Limit(req.PageSize).Find(&items).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch bulk job items", "error": err.Error()})
+		return
+	}
+
+	totalPage := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"job":        job,
+		"items":      items,
+		"total":      total,
+		"page":       req.Page,
+		"page_size":  req.PageSize,
+		"total_page": totalPage,
+	})
+}
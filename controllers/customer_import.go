@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/dto"
+	"auth-api/exports"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// importRow - Satu baris file import beserta hasil validasinya
+type importRow struct {
+	line     int
+	req      dto.CustomerCreateRequest
+	customer models.Customer
+	errors   []string
+}
+
+// importRowError - Baris yang gagal, untuk laporan import
+type importRowError struct {
+	Row          int      `json:"row"`
+	CustomerCode string   `json:"customer_code"`
+	Errors       []string `json:"errors"`
+}
+
+// importFields - Kolom file import (header sama dengan field JSON CustomerCreateRequest)
+var importFields = map[string]func(req *dto.CustomerCreateRequest, value string) error{
+	"customer_code": func(req *dto.CustomerCreateRequest, value string) error { req.CustomerCode = value; return nil },
+	"company_name":  func(req *dto.CustomerCreateRequest, value string) error { req.CompanyName = value; return nil },
+	"contact_name":  func(req *dto.CustomerCreateRequest, value string) error { req.ContactName = value; return nil },
+	"email":         func(req *dto.CustomerCreateRequest, value string) error { req.Email = value; return nil },
+	"phone":         func(req *dto.CustomerCreateRequest, value string) error { req.Phone = value; return nil },
+	"address":       func(req *dto.CustomerCreateRequest, value string) error { req.Address = value; return nil },
+	"npwp":          func(req *dto.CustomerCreateRequest, value string) error { req.NPWP = value; return nil },
+	"status":        func(req *dto.CustomerCreateRequest, value string) error { req.Status = value; return nil },
+	"balance": func(req *dto.CustomerCreateRequest, value string) (err error) {
+		req.Balance, err = models.ParseMoney(value)
+		return err
+	},
+	"tax_exempt": func(req *dto.CustomerCreateRequest, value string) (err error) {
+		req.TaxExempt, err = strconv.ParseBool(value)
+		return err
+	},
+	"tax_exempt_reason": func(req *dto.CustomerCreateRequest, value string) error { req.TaxExemptReason = value; return nil },
+	"tax_exempt_until":  func(req *dto.CustomerCreateRequest, value string) error { req.TaxExemptUntil = value; return nil },
+	"credit_limit": func(req *dto.CustomerCreateRequest, value string) (err error) {
+		req.CreditLimit, err = models.ParseMoney(value)
+		return err
+	},
+	"overdraft_policy": func(req *dto.CustomerCreateRequest, value string) error { req.OverdraftPolicy = value; return nil },
+	"low_balance_threshold": func(req *dto.CustomerCreateRequest, value string) error {
+		threshold, err := models.ParseMoney(value)
+		if err != nil {
+			return err
+		}
+		req.LowBalanceThreshold = &threshold
+		return nil
+	},
+}
+
+// ImportCustomers - Import customer dari CSV/XLSX. Setiap baris divalidasi dengan aturan yang sama
+// seperti CreateCustomer; dry_run hanya mengembalikan laporan validasi.
+func (cc *CustomerController) ImportCustomers(c *gin.Context) {
+	var req dto.CustomerImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "File is required"})
+		return
+	}
+	if fileHeader.Size > cc.cfg.Import.MaxFileSize {
+		utils.ErrorResponse(c, 413, gin.H{"message": fmt.Sprintf("File exceeds the maximum size of %d bytes", cc.cfg.Import.MaxFileSize)})
+		return
+	}
+	format, err := exports.FormatFromFileName(fileHeader.Filename)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Only .csv and .xlsx files are supported"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Failed to read file", "error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	header, records, err := exports.ReadRows(format, file, cc.cfg.Import.MaxRows)
+	if err != nil {
+		status := 400
+		if errors.Is(err, exports.ErrImportTooManyRows) {
+			status = 413
+		}
+		utils.ErrorResponse(c, status, gin.H{"message": "Failed to read file", "error": err.Error()})
+		return
+	}
+
+	// Kolom yang tidak dikenal (mis. id, created_at dari file export) diabaikan
+	columns := make(map[int]string)
+	found := make(map[string]bool)
+	for i, name := range header {
+		key := exports.NormalizeHeader(name)
+		if _, ok := importFields[key]; ok && !found[key] {
+			columns[i] = key
+			found[key] = true
+		}
+	}
+	if !found["customer_code"] || !found["company_name"] {
+		utils.ErrorResponse(c, 400, gin.H{"message": "File must have customer_code and company_name columns"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("role")
+
+	rows := make([]*importRow, len(records))
+	for i, record := range records {
+		rows[i] = parseImportRow(record, columns, userRole, userID.(uint))
+	}
+	if err := cc.checkImportDuplicates(rows); err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to check customer codes", "error": err.Error()})
+		return
+	}
+
+	var valid []*importRow
+	rowErrors := []importRowError{}
+	for _, row := range rows {
+		if len(row.errors) > 0 {
+			rowErrors = append(rowErrors, importRowError{Row: row.line, CustomerCode: row.req.CustomerCode, Errors: row.errors})
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	response := gin.H{
+		"dry_run":      req.DryRun,
+		"total_rows":   len(rows),
+		"valid_rows":   len(valid),
+		"invalid_rows": len(rowErrors),
+		"created":      0,
+		"errors":       rowErrors,
+	}
+	if req.DryRun {
+		utils.SuccessResponse(c, 200, response)
+		return
+	}
+	if len(rowErrors) > 0 && !req.SkipInvalid {
+		response["message"] = "Import contains invalid rows, nothing was imported"
+		utils.ErrorResponse(c, 422, response)
+		return
+	}
+
+	// Setiap batch satu transaksi; batch yang gagal di-rollback tanpa membatalkan batch sebelumnya
+	created := 0
+	failed := []importRowError{}
+	var approvals []models.BalanceApproval
+	batchSize := cc.cfg.Import.BatchSize
+	for start := 0; start < len(valid); start += batchSize {
+		batch := valid[start:min(start+batchSize, len(valid))]
+		var pending []models.BalanceApproval
+		err := cc.db.Transaction(func(tx *gorm.DB) error {
+			for _, row := range batch {
+				approval, err := createCustomer(tx, cc.cfg, &row.customer, row.req.Balance, userID.(uint))
+				if err != nil {
+					return fmt.Errorf("row %d: %w", row.line, err)
+				}
+				if approval != nil {
+					pending = append(pending, *approval)
+				}
+
+				changes := createChanges(row.customer)
+				changes["source"] = "import"
+				history := models.CustomerHistory{
+					CustomerID: row.customer.ID,
+					Action:     "create",
+					Changes:    utils.ToJSON(changes),
+					ChangedBy:  userID.(uint),
+					CreatedAt:  time.Now(),
+				}
+				if err := tx.Create(&history).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("⚠️ Customer import batch starting at row %d rolled back: %v\n", batch[0].line, err)
+			for _, row := range batch {
+				failed = append(failed, importRowError{Row: row.line, CustomerCode: row.req.CustomerCode, Errors: []string{"batch rolled back: " + err.Error()}})
+			}
+			continue
+		}
+		created += len(batch)
+		approvals = append(approvals, pending...)
+	}
+
+	// Saldo awal di atas threshold menunggu approval user lain
+	for _, approval := range approvals {
+		go billing.NotifyApprovers(cc.cfg, cc.db, approval)
+	}
+
+	response["created"] = created
+	response["failed"] = failed
+	response["pending_approvals"] = approvals
+	if created == 0 && len(failed) > 0 {
+		response["message"] = "Failed to import customers"
+		utils.ErrorResponse(c, 500, response)
+		return
+	}
+	utils.SuccessResponse(c, 201, response)
+}
+
+// parseImportRow - Isi CustomerCreateRequest dari satu baris lalu validasi binding tag dan aturan bisnisnya
+func parseImportRow(record exports.Record, columns map[int]string, userRole interface{}, userID uint) *importRow {
+	row := &importRow{line: record.Line}
+	for i, value := range record.Values {
+		key, ok := columns[i]
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			continue
+		}
+		if err := importFields[key](&row.req, value); err != nil {
+			row.errors = append(row.errors, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+
+	if err := binding.Validator.ValidateStruct(&row.req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			row.errors = append(row.errors, err.Error())
+			return row
+		}
+		for _, fieldErr := range validationErrors {
+			row.errors = append(row.errors, fmt.Sprintf("%s: failed on '%s' validation", importFieldName(fieldErr.StructField()), fieldErr.Tag()))
+		}
+	}
+	if len(row.errors) > 0 {
+		return row
+	}
+
+	customer, inputErr := newCustomer(row.req, userRole, userID)
+	if inputErr != nil {
+		message := inputErr.message
+		if inputErr.detail != "" {
+			message += ": " + inputErr.detail
+		}
+		row.errors = append(row.errors, message)
+		return row
+	}
+	row.customer = customer
+	return row
+}
+
+// importFieldName - Nama kolom (tag json) dari nama field CustomerCreateRequest
+func importFieldName(structField string) string {
+	field, ok := reflect.TypeOf(dto.CustomerCreateRequest{}).FieldByName(structField)
+	if !ok {
+		return structField
+	}
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+// checkImportDuplicates - customer_code tidak boleh muncul dua kali di file atau sudah ada di database
+func (cc *CustomerController) checkImportDuplicates(rows []*importRow) error {
+	seen := make(map[string]int)
+	var codes []string
+	for _, row := range rows {
+		if row.req.CustomerCode == "" {
+			continue
+		}
+		// Collation MySQL tidak membedakan huruf besar/kecil
+		key := strings.ToLower(row.req.CustomerCode)
+		if line, ok := seen[key]; ok {
+			row.errors = append(row.errors, fmt.Sprintf("customer_code: duplicate of row %d", line))
+			continue
+		}
+		seen[key] = row.line
+		codes = append(codes, row.req.CustomerCode)
+	}
+
+	existing := make(map[string]bool)
+	for start := 0; start < len(codes); start += 500 {
+		var found []string
+		chunk := codes[start:min(start+500, len(codes))]
+		if err := cc.db.Unscoped().Model(&models.Customer{}).Where("customer_code IN ?", chunk).Pluck("customer_code", &found).Error; err != nil {
+			return err
+		}
+		for _, code := range found {
+			existing[strings.ToLower(code)] = true
+		}
+	}
+
+	for _, row := range rows {
+		if existing[strings.ToLower(row.req.CustomerCode)] {
+			row.errors = append(row.errors, "customer_code: already exists")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/dto"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DunningController struct {
+	cfg *config.Config
+	db  *gorm.DB
+}
+
+func NewDunningController(cfg *config.Config, db *gorm.DB) *DunningController {
+	return &DunningController{cfg: cfg, db: db}
+}
+
+func dunningErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return 404
+	case errors.Is(err, billing.ErrDunningPauseReasonRequired):
+		return 400
+	case errors.Is(err, billing.ErrDunningNotPaused),
+		errors.Is(err, billing.ErrCustomerTerminated):
+		return 409
+	}
+	return 500
+}
+
+// dunningBoardRow - Posisi satu customer dalam jadwal dunning
+type dunningBoardRow struct {
+	models.DunningState
+	CustomerCode string       `json:"customer_code"`
+	CompanyName  string       `json:"company_name"`
+	Status       string       `json:"status"`
+	Balance      models.Money `json:"balance"`
+	DaysOverdue  int          `gorm:"-" json:"days_overdue"`
+	NextStep     string       `gorm:"-" json:"next_step,omitempty"`
+	NextStepDate string       `gorm:"-" json:"next_step_date,omitempty"`
+}
+
+// GetDunningBoard - Dashboard finance: posisi setiap customer yang menunggak dalam jadwal dunning
+func (dc *DunningController) GetDunningBoard(c *gin.Context) {
+	var req dto.DunningBoardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+
+	query := dc.db.Table("dunning_states").
+		Joins("JOIN customers ON customers.id = dunning_states.customer_id AND customers.deleted_at IS NULL").
+		Where("dunning_states.overdue_since IS NOT NULL OR dunning_states.paused = ?", true)
+
+	// Ringkasan per tahap sebelum filter, untuk kartu di dashboard
+	var stages []struct {
+		StageName string       `json:"stage_name"`
+		Customers int64        `json:"customers"`
+		AmountDue models.Money `json:"amount_due"`
+	}
+	if err := query.Session(&gorm.Session{}).
+		Select("dunning_states.stage_name, COUNT(*) AS customers, COALESCE(SUM(dunning_states.amount_due), 0) AS amount_due").
+		Group("dunning_states.stage_name").Scan(&stages).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to summarize dunning stages", "error": err.Error()})
+		return
+	}
+
+	if req.Stage == "none" {
+		query = query.Where("dunning_states.stage = ?", 0)
+	} else if req.Stage != "" {
+		query = query.Where("dunning_states.stage_name = ?", req.Stage)
+	}
+	if req.Paused != nil {
+		query = query.Where("dunning_states.paused = ?", *req.Paused)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	var rows []dunningBoardRow
+	offset := (req.Page - 1) * req.PageSize
+	err := query.Select("dunning_states.*, customers.customer_code, customers.company_name, customers.status, customers.balance").
+		Order("dunning_states.stage DESC, dunning_states.overdue_since ASC").
+		Offset(offset).Limit(req.PageSize).Scan(&rows).Error
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch dunning board", "error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	for i := range rows {
+		row := &rows[i]
+		if row.OverdueSince == nil {
+			continue
+		}
+		row.DaysOverdue = billing.DaysOverdue(*row.OverdueSince, now)
+		if next := billing.NextDunningStep(dc.cfg, row.Stage); next != nil {
+			row.NextStep = next.Name
+			row.NextStepDate = row.OverdueSince.AddDate(0, 0, next.AfterDays).Format("2006-01-02")
+		}
+	}
+
+	totalPage := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"schedule":   dc.cfg.Dunning.Steps,
+		"stages":     stages,
+		"customers":  rows,
+		"total":      total,
+		"page":       req.Page,
+		"page_size":  req.PageSize,
+		"total_page": totalPage,
+	})
+}
+
+// PauseDunning - Hentikan eskalasi dunning customer, mis. karena tagihan sedang di-dispute
+func (dc *DunningController) PauseDunning(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("customer_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid customer ID"})
+		return
+	}
+
+	var req dto.DunningPauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	var until *time.Time
+	if req.Until != "" {
+		date, _ := time.ParseInLocation("2006-01-02", req.Until, time.Local)
+		if !date.After(time.Now()) {
+			utils.ErrorResponse(c, 400, gin.H{"message": "until must be a future date"})
+			return
+		}
+		until = &date
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var state *models.DunningState
+	err = dc.db.Transaction(func(tx *gorm.DB) error {
+		state, err = billing.PauseDunning(tx, uint(customerID), req.Reason, until, userID.(uint))
+		return err
+	})
+	if err != nil {
+		utils.ErrorResponse(c, dunningErrorStatus(err), gin.H{"message": "Failed to pause dunning", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, state)
+}
+
+// ResumeDunning - Lanjutkan jadwal dunning yang di-pause
+func (dc *DunningController) ResumeDunning(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("customer_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid customer ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var state *models.DunningState
+	err = dc.db.Transaction(func(tx *gorm.DB) error {
+		state, err = billing.ResumeDunning(tx, uint(customerID), userID.(uint))
+		return err
+	})
+	if err != nil {
+		utils.ErrorResponse(c, dunningErrorStatus(err), gin.H{"message": "Failed to resume dunning", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, state)
+}
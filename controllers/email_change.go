@@ -0,0 +1,290 @@
+package controllers
+
+import (
+	"auth-api/database"
+	"auth-api/dto"
+	"auth-api/models"
+	"auth-api/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errEmailTaken = errors.New("email already registered")
+
+type emailChangeCancel struct {
+	UserID   uint   `json:"user_id"`
+	OldEmail string `json:"old_email"`
+	NewEmail string `json:"new_email"`
+}
+
+// RequestEmailChange - Request ganti email, OTP dikirim ke email lama dan email baru
+func (ac *AuthController) RequestEmailChange(c *gin.Context) {
+	var req dto.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := ac.db.First(&user, userID).Error; err != nil {
+		utils.ErrorResponse(c, 404, gin.H{"message": "User not found"})
+		return
+	}
+
+	// Verify password
+	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Password is incorrect"})
+		return
+	}
+
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		utils.ErrorResponse(c, 400, gin.H{"message": "New email must be different from current email"})
+		return
+	}
+
+	// Check if new email already registered
+	var existingUser models.User
+	if err := ac.db.Where("email = ?", newEmail).First(&existingUser).Error; err == nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Email already registered"})
+		return
+	}
+
+	// Generate OTP untuk email lama dan email baru
+	oldOTP, err := utils.GenerateOTP(ac.cfg.Security.OTPLength)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to generate OTP"})
+		return
+	}
+	newOTP, err := utils.GenerateOTP(ac.cfg.Security.OTPLength)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to generate OTP"})
+		return
+	}
+
+	if err := database.StoreEmailChange(user.ID, newEmail, oldOTP, newOTP, ac.cfg.Security.OTPExpiry); err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to store OTP"})
+		return
+	}
+
+	minutes := int(ac.cfg.Security.OTPExpiry.Minutes())
+	if err := utils.SendEmailChangeOTPEmail(ac.cfg, user.Email, user.Name, oldOTP, newEmail, false, minutes); err != nil {
+		database.DeleteEmailChange(user.ID)
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to send OTP email"})
+		return
+	}
+	if err := utils.SendEmailChangeOTPEmail(ac.cfg, newEmail, user.Name, newOTP, newEmail, true, minutes); err != nil {
+		database.DeleteEmailChange(user.ID)
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to send OTP email"})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"message":        "OTP has been sent to your current and new email addresses",
+		"new_email":      newEmail,
+		"otp_expires_in": int(ac.cfg.Security.OTPExpiry.Seconds()),
+	})
+}
+
+// ConfirmEmailChange - Konfirmasi ganti email dengan OTP dari kedua alamat
+func (ac *AuthController) ConfirmEmailChange(c *gin.Context) {
+	var req dto.ConfirmEmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := ac.db.First(&user, userID).Error; err != nil {
+		utils.ErrorResponse(c, 404, gin.H{"message": "User not found"})
+		return
+	}
+
+	pending, err := database.GetEmailChange(user.ID)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+		return
+	}
+	if len(pending) == 0 {
+		utils.ErrorResponse(c, 400, gin.H{"message": "OTP has expired or not found"})
+		return
+	}
+
+	if pending["old_otp"] != req.OldEmailOTP || pending["new_otp"] != req.NewEmailOTP {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid OTP"})
+		return
+	}
+
+	oldEmail := user.Email
+	newEmail := pending["new_email"]
+
+	// Swap email secara atomic, hanya jika email belum berubah sejak request
+	if err := ac.swapUserEmail(user.ID, oldEmail, newEmail); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 409, gin.H{"message": "Email was changed by another request"})
+			return
+		}
+		if err == errEmailTaken {
+			utils.ErrorResponse(c, 400, gin.H{"message": "Email already registered"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to change email"})
+		return
+	}
+
+	database.DeleteEmailChange(user.ID)
+
+	if err := database.MigrateEmailState(oldEmail, newEmail); err != nil {
+		fmt.Printf("⚠️ Failed to migrate Redis state for %s: %v\n", oldEmail, err)
+	}
+
+	// Semua token yang sudah ada masih membawa claim email lama
+	if err := database.RevokeUserSessions(user.ID, ac.cfg.JWT.Expiry); err != nil {
+		fmt.Printf("⚠️ Failed to revoke sessions for user %d: %v\n", user.ID, err)
+	}
+
+	// Kirim notifikasi ke email lama dengan link pembatalan
+	cancelToken, err := utils.GenerateRandomToken(32)
+	if err == nil {
+		data := utils.ToJSON(emailChangeCancel{UserID: user.ID, OldEmail: oldEmail, NewEmail: newEmail})
+		err = database.StoreEmailChangeCancel(cancelToken, data, ac.cfg.Security.EmailChangeCancelExpiry)
+	}
+	if err != nil {
+		fmt.Printf("⚠️ Failed to create email change cancel token: %v\n", err)
+	} else {
+		cancelURL := fmt.Sprintf("%s/billapi/v2/change-email/cancel?token=%s", ac.cfg.Server.BaseURL, cancelToken)
+		if err := utils.SendEmailChangedNotification(ac.cfg, oldEmail, user.Name, newEmail, cancelURL); err != nil {
+			fmt.Printf("⚠️ Failed to send email change notification: %v\n", err)
+		}
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"message": "Email has been changed successfully. Please login again with your new email.",
+		"email":   newEmail,
+	})
+}
+
+// CancelEmailChangePage - Halaman konfirmasi dari link yang dikirim ke email lama, pembatalan dilakukan lewat POST
+func (ac *AuthController) CancelEmailChangePage(c *gin.Context) {
+	token := c.Query("token")
+	pending, ok := loadEmailChangeCancel(c, token)
+	if !ok {
+		return
+	}
+
+	utils.RenderConfirmPage(c, utils.ConfirmPageData{
+		Title:   "Batalkan Perubahan Email",
+		Message: fmt.Sprintf("Email akun Anda akan dikembalikan dari %s ke %s dan semua sesi login akan diakhiri.", pending.NewEmail, pending.OldEmail),
+		Action:  "/billapi/v2/change-email/cancel",
+		Token:   token,
+		Button:  "Batalkan Perubahan Email",
+	})
+}
+
+// CancelEmailChange - Membatalkan ganti email dengan token dari link yang dikirim ke email lama
+func (ac *AuthController) CancelEmailChange(c *gin.Context) {
+	var req dto.LinkTokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	token := req.Token
+
+	pending, ok := loadEmailChangeCancel(c, token)
+	if !ok {
+		return
+	}
+
+	if err := ac.swapUserEmail(pending.UserID, pending.NewEmail, pending.OldEmail); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 409, gin.H{"message": "Email has been changed again and can no longer be reverted"})
+			return
+		}
+		if err == errEmailTaken {
+			utils.ErrorResponse(c, 409, gin.H{"message": "Previous email is already used by another account"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to revert email"})
+		return
+	}
+
+	database.DeleteEmailChangeCancel(token)
+
+	if err := database.MigrateEmailState(pending.NewEmail, pending.OldEmail); err != nil {
+		fmt.Printf("⚠️ Failed to migrate Redis state for %s: %v\n", pending.NewEmail, err)
+	}
+	if err := database.RevokeUserSessions(pending.UserID, ac.cfg.JWT.Expiry); err != nil {
+		fmt.Printf("⚠️ Failed to revoke sessions for user %d: %v\n", pending.UserID, err)
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"message": "Email change has been cancelled. Please login with your previous email and change your password.",
+		"email":   pending.OldEmail,
+	})
+}
+
+// loadEmailChangeCancel - Data pembatalan dari token, response error sudah dikirim jika false
+func loadEmailChangeCancel(c *gin.Context, token string) (emailChangeCancel, bool) {
+	var pending emailChangeCancel
+	if token == "" {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Token is required"})
+		return pending, false
+	}
+
+	data, err := database.GetEmailChangeCancel(token)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+		return pending, false
+	}
+	if data == "" {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Cancel link has expired or is invalid"})
+		return pending, false
+	}
+
+	if err := json.Unmarshal([]byte(data), &pending); err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+		return pending, false
+	}
+	return pending, true
+}
+
+// swapUserEmail - Ganti email user dalam satu transaksi, gagal jika email sekarang bukan fromEmail
+func (ac *AuthController) swapUserEmail(userID uint, fromEmail, toEmail string) error {
+	return ac.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("email = ? AND id <> ?", toEmail, userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errEmailTaken
+		}
+
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND email = ?", userID, fromEmail).
+			Update("email", toEmail)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"auth-api/config"
+	"auth-api/dto"
+	"auth-api/exports"
+	"auth-api/models"
+	"auth-api/utils"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ExportController struct {
+	cfg *config.Config
+	db  *gorm.DB
+}
+
+func NewExportController(cfg *config.Config, db *gorm.DB) *ExportController {
+	return &ExportController{cfg: cfg, db: db}
+}
+
+// exportScope - Requester export dari context auth
+func exportScope(c *gin.Context) exports.Scope {
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("role")
+	role, _ := userRole.(string)
+	id, _ := userID.(uint)
+	return exports.Scope{Role: role, UserID: id}
+}
+
+// streamExport - Kirim export langsung sebagai file download. Isi dikirim bertahap tanpa Content-Length.
+func streamExport(c *gin.Context, format, basename string, bom bool, write func(w exports.Writer) (int64, error)) {
+	if format == "" {
+		format = exports.FormatCSV
+	}
+
+	w, err := exports.NewWriter(format, c.Writer, bom)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	filename := exports.FileName(fmt.Sprintf("%s_%s", basename, time.Now().Format("20060102_150405")), format)
+	c.Header("Content-Type", exports.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Status(200)
+
+	if _, err := write(w); err != nil {
+		// Header sudah terkirim, file di sisi client akan terpotong
+		fmt.Printf("⚠️ Export %s aborted: %v\n", basename, err)
+		c.Abort()
+	}
+}
+
+// CreateExportJob - Export asinkron untuk data besar, file ditulis oleh worker
+func (ec *ExportController) CreateExportJob(c *gin.Context) {
+	var req dto.ExportJobCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	if req.Format == "" {
+		req.Format = exports.FormatCSV
+	}
+
+	// Validasi kolom sekarang agar kesalahan tidak baru terlihat saat worker berjalan
+	if req.Type == exports.JobTypeCustomers {
+		if _, err := exports.ParseCustomerColumns(req.Columns); err != nil {
+			utils.ErrorResponse(c, 400, gin.H{"message": err.Error(), "columns": exports.CustomerColumnKeys()})
+			return
+		}
+	} else {
+		req.Columns = ""
+	}
+
+	scope := exportScope(c)
+	filters := exports.JobFilters{
+		CustomerFilter: exports.CustomerFilter{
+			Search:    req.Filters.Search,
+			Status:    req.Filters.Status,
+			Tag:       req.Filters.Tag,
+			SortBy:    req.Filters.SortBy,
+			SortOrder: req.Filters.SortOrder,
+		},
+		HistoryFilter: exports.HistoryFilter{
+			CustomerID: req.Filters.CustomerID,
+			Action:     req.Filters.Action,
+			From:       req.Filters.From,
+			To:         req.Filters.To,
+		},
+	}
+
+	job := models.ExportJob{
+		Type:          req.Type,
+		Format:        req.Format,
+		Filters:       utils.ToJSON(filters),
+		Columns:       req.Columns,
+		BOM:           req.BOM && req.Format == exports.FormatCSV,
+		Status:        "pending",
+		RequestedBy:   scope.UserID,
+		RequestedRole: scope.Role,
+	}
+	if err := ec.db.Create(&job).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to create export job", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 202, gin.H{
+		"job":        job,
+		"status_url": fmt.Sprintf("%s/billapi/v2/exports/%d", ec.cfg.Server.BaseURL, job.ID),
+	})
+}
+
+// GetExportJobs - Daftar export milik user yang login
+func (ec *ExportController) GetExportJobs(c *gin.Context) {
+	var req dto.ExportJobSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+
+	userID, _ := c.Get("user_id")
+	query := ec.db.Model(&models.ExportJob{}).Where("requested_by = ?", userID)
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var jobs []models.ExportJob
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(req.PageSize).Find(&jobs).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch export jobs", "error": err.Error()})
+		return
+	}
+
+	totalPage := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"jobs":       jobs,
+		"total":      total,
+		"page":       req.Page,
+		"page_size":  req.PageSize,
+		"total_page": totalPage,
+	})
+}
+
+// findExportJob - Job hanya bisa dilihat oleh user yang membuatnya
+func (ec *ExportController) findExportJob(c *gin.Context) (*models.ExportJob, bool) {
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid export job ID"})
+		return nil, false
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var job models.ExportJob
+	if err := ec.db.Where("id = ? AND requested_by = ?", jobID, userID).First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Export job not found"})
+			return nil, false
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch export job", "error": err.Error()})
+		return nil, false
+	}
+	return &job, true
+}
+
+// GetExportJob - Status export dan link download jika sudah selesai
+func (ec *ExportController) GetExportJob(c *gin.Context) {
+	job, ok := ec.findExportJob(c)
+	if !ok {
+		return
+	}
+
+	response := gin.H{"job": job}
+	if job.Status == "completed" {
+		response["download_url"] = fmt.Sprintf("%s/billapi/v2/exports/%d/download", ec.cfg.Server.BaseURL, job.ID)
+	}
+	utils.SuccessResponse(c, 200, response)
+}
+
+// DownloadExportJob - Unduh file hasil export yang sudah selesai dan belum expired
+func (ec *ExportController) DownloadExportJob(c *gin.Context) {
+	job, ok := ec.findExportJob(c)
+	if !ok {
+		return
+	}
+
+	if job.Status != "completed" {
+		utils.ErrorResponse(c, 409, gin.H{"message": fmt.Sprintf("Export job is %s", job.Status)})
+		return
+	}
+	if job.ExpiresAt != nil && !time.Now().Before(*job.ExpiresAt) {
+		utils.ErrorResponse(c, 410, gin.H{"message": "Export file has expired"})
+		return
+	}
+	if _, err := os.Stat(job.FilePath); err != nil {
+		utils.ErrorResponse(c, 410, gin.H{"message": "Export file is no longer available"})
+		return
+	}
+
+	filename := exports.FileName(fmt.Sprintf("%s_export_%d", job.Type, job.ID), job.Format)
+	c.Header("Content-Type", exports.ContentType(job.Format))
+	c.FileAttachment(job.FilePath, filename)
+}
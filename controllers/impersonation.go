@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"auth-api/middleware"
+	"auth-api/models"
+	"auth-api/utils"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AdminImpersonateUser - Admin mendapatkan token berumur pendek atas nama user customer
+func (ac *AuthController) AdminImpersonateUser(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid user ID"})
+		return
+	}
+
+	actorID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	// Impersonation berantai tidak diizinkan
+	if _, impersonated := c.Get("impersonator_id"); impersonated {
+		utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: this action is not allowed while impersonating"})
+		return
+	}
+
+	var actor models.User
+	if err := ac.db.First(&actor, actorID).Error; err != nil {
+		utils.ErrorResponse(c, 404, gin.H{"message": "User not found"})
+		return
+	}
+
+	var target models.User
+	if err := ac.db.First(&target, targetID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "User not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	// Scope dibatasi ke user role customer yang aktif
+	if target.Role != "customer" {
+		utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: only customer users can be impersonated"})
+		return
+	}
+	if target.Status != "active" || target.AnonymizedAt != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Account is not active"})
+		return
+	}
+
+	token, expiresAt, err := middleware.GenerateImpersonationToken(target, actor.ID, actor.Email, ac.cfg)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to generate token"})
+		return
+	}
+
+	entry := models.ImpersonationLog{
+		ActorID:   actor.ID,
+		SubjectID: target.ID,
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Status:    200,
+		IPAddress: c.ClientIP(),
+		CreatedAt: time.Now(),
+	}
+	if err := ac.db.Create(&entry).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to write audit log"})
+		return
+	}
+	log.Printf("👤 Impersonation started: admin %d (%s) as user %d (%s)", actor.ID, actor.Email, target.ID, target.Email)
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"token":      token,
+		"expires_at": expiresAt.Format(time.RFC3339),
+		"user": gin.H{
+			"id":    target.ID,
+			"name":  target.Name,
+			"email": target.Email,
+			"role":  target.Role,
+		},
+		"actor": gin.H{
+			"id":    actor.ID,
+			"email": actor.Email,
+		},
+	})
+}
+
+// AdminGetImpersonationLogs - Audit log impersonation (admin only)
+func (ac *AuthController) AdminGetImpersonationLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := ac.db.Model(&models.ImpersonationLog{})
+	if actorID := c.Query("actor_id"); actorID != "" {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if subjectID := c.Query("subject_id"); subjectID != "" {
+		query = query.Where("subject_id = ?", subjectID)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var logs []models.ImpersonationLog
+	if err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logs).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch impersonation logs"})
+		return
+	}
+
+	totalPage := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"logs":       logs,
+		"total":      total,
+		"page":       page,
+		"page_size":  pageSize,
+		"total_page": totalPage,
+	})
+}
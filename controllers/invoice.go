@@ -0,0 +1,375 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/dto"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InvoiceController struct {
+	cfg *config.Config
+	db  *gorm.DB
+}
+
+func NewInvoiceController(cfg *config.Config, db *gorm.DB) *InvoiceController {
+	return &InvoiceController{cfg: cfg, db: db}
+}
+
+// scopeInvoices - Customer hanya bisa melihat invoice (non-draft) milik customer-nya sendiri
+func scopeInvoices(c *gin.Context, query *gorm.DB) *gorm.DB {
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("role")
+
+	if userRole == "customer" {
+		query = query.Where("customer_id IN (?)", gorm.Expr("SELECT id FROM customers WHERE user_id = ?", userID)).
+			Where("status <> ?", "draft")
+	}
+	return query
+}
+
+// lineInputs - Line tanpa tarif memakai PPN yang berlaku; customer bebas PPN selalu 0%
+func lineInputs(tx *gorm.DB, customer models.Customer, lines []dto.InvoiceLineRequest) ([]billing.LineInput, error) {
+	now := time.Now()
+	exempt := customer.IsTaxExemptAt(now)
+
+	defaultBps := 0
+	if !exempt {
+		var err error
+		if defaultBps, err = billing.PPNRateAt(tx, now); err != nil {
+			return nil, err
+		}
+	}
+
+	inputs := make([]billing.LineInput, 0, len(lines))
+	for _, line := range lines {
+		taxRateBps := line.TaxRateBps(defaultBps)
+		if exempt {
+			taxRateBps = 0
+		}
+		inputs = append(inputs, billing.LineInput{
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			TaxRateBps:  taxRateBps,
+		})
+	}
+	return inputs, nil
+}
+
+// findInvoiceForUpdate - Ambil invoice beserta line dengan row lock di dalam transaksi
+func findInvoiceForUpdate(tx *gorm.DB, invoiceID uint64) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, invoiceID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("invoice_id = ?", invoice.ID).Order("id ASC").Find(&invoice.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func invoiceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return 404
+	case errors.Is(err, billing.ErrInvoiceNotDraft),
+		errors.Is(err, billing.ErrInvoiceNotIssued),
+		errors.Is(err, billing.ErrInvoiceNotVoidable),
+		errors.Is(err, billing.ErrInvoiceEmpty):
+		return 409
+	case errors.Is(err, billing.ErrAmountTooLarge):
+		return 400
+	}
+	return 500
+}
+
+// CreateInvoice - Membuat invoice draft untuk customer
+func (ic *InvoiceController) CreateInvoice(c *gin.Context) {
+	var req dto.InvoiceCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var customer models.Customer
+	if err := ic.db.First(&customer, req.CustomerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Customer not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customer", "error": err.Error()})
+		return
+	}
+	if customer.Status == "terminated" {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Cannot invoice a terminated customer"})
+		return
+	}
+
+	dueDate := time.Now().AddDate(0, 0, ic.cfg.Billing.PaymentTermDays)
+	if req.DueDate != "" {
+		dueDate, _ = time.ParseInLocation("2006-01-02", req.DueDate, time.Local)
+	}
+
+	invoice := models.Invoice{
+		CustomerID: customer.ID,
+		Status:     "draft",
+		DueDate:    dueDate,
+		Notes:      req.Notes,
+		CreatedBy:  userID.(uint),
+	}
+	inputs, err := lineInputs(ic.db, customer, req.Lines)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to resolve tax rate", "error": err.Error()})
+		return
+	}
+	if err := billing.ApplyLines(&invoice, inputs); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	if err := ic.db.Create(&invoice).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to create invoice", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 201, dto.ToInvoiceResponse(invoice))
+}
+
+// GetInvoices - List invoice dengan filter customer/status dan pagination
+func (ic *InvoiceController) GetInvoices(c *gin.Context) {
+	var req dto.InvoiceSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+
+	query := scopeInvoices(c, ic.db.Model(&models.Invoice{}))
+	if req.CustomerID > 0 {
+		query = query.Where("customer_id = ?", req.CustomerID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var invoices []models.Invoice
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(req.PageSize).Find(&invoices).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch invoices", "error": err.Error()})
+		return
+	}
+
+	invoiceResponses := []dto.InvoiceResponse{}
+	for _, invoice := range invoices {
+		invoiceResponses = append(invoiceResponses, dto.ToInvoiceResponse(invoice))
+	}
+
+	totalPage := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, dto.InvoiceListResponse{
+		Invoices:  invoiceResponses,
+		Total:     total,
+		Page:      req.Page,
+		PageSize:  req.PageSize,
+		TotalPage: totalPage,
+	})
+}
+
+// GetInvoiceByID - Detail invoice beserta line item
+func (ic *InvoiceController) GetInvoiceByID(c *gin.Context) {
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid invoice ID"})
+		return
+	}
+
+	var invoice models.Invoice
+	query := scopeInvoices(c, ic.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}))
+	if err := query.First(&invoice, invoiceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Invoice not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch invoice", "error": err.Error()})
+		return
+	}
+
+	response := dto.ToInvoiceResponse(invoice)
+	ic.db.Table("payment_allocations").
+		Select("payments.id AS payment_id, payments.method, payments.reference, payment_allocations.amount, payments.received_at").
+		Joins("JOIN payments ON payments.id = payment_allocations.payment_id").
+		Where("payment_allocations.invoice_id = ?", invoice.ID).
+		Order("payment_allocations.id ASC").
+		Scan(&response.Payments)
+
+	utils.SuccessResponse(c, 200, response)
+}
+
+// UpdateInvoice - Mengubah invoice draft (due date, catatan, dan/atau seluruh line item)
+func (ic *InvoiceController) UpdateInvoice(c *gin.Context) {
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid invoice ID"})
+		return
+	}
+
+	var req dto.InvoiceUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	var invoice *models.Invoice
+	err = ic.db.Transaction(func(tx *gorm.DB) error {
+		invoice, err = findInvoiceForUpdate(tx, invoiceID)
+		if err != nil {
+			return err
+		}
+		if invoice.Status != "draft" {
+			return billing.ErrInvoiceNotDraft
+		}
+
+		if req.DueDate != "" {
+			invoice.DueDate, _ = time.ParseInLocation("2006-01-02", req.DueDate, time.Local)
+		}
+		if req.Notes != nil {
+			invoice.Notes = *req.Notes
+		}
+		if len(req.Lines) > 0 {
+			if err := tx.Where("invoice_id = ?", invoice.ID).Delete(&models.InvoiceLine{}).Error; err != nil {
+				return err
+			}
+			var customer models.Customer
+			if err := tx.First(&customer, invoice.CustomerID).Error; err != nil {
+				return err
+			}
+			inputs, err := lineInputs(tx, customer, req.Lines)
+			if err != nil {
+				return err
+			}
+			if err := billing.ApplyLines(invoice, inputs); err != nil {
+				return err
+			}
+		}
+
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(invoice).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, invoiceErrorStatus(err), gin.H{"message": "Failed to update invoice", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, dto.ToInvoiceResponse(*invoice))
+}
+
+// DeleteInvoice - Hanya draft yang boleh dihapus, invoice issued harus di-void
+func (ic *InvoiceController) DeleteInvoice(c *gin.Context) {
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid invoice ID"})
+		return
+	}
+
+	err = ic.db.Transaction(func(tx *gorm.DB) error {
+		invoice, err := findInvoiceForUpdate(tx, invoiceID)
+		if err != nil {
+			return err
+		}
+		if invoice.Status != "draft" {
+			return billing.ErrInvoiceNotDraft
+		}
+		if err := tx.Where("invoice_id = ?", invoice.ID).Delete(&models.InvoiceLine{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(invoice).Error
+	})
+	if err != nil {
+		utils.ErrorResponse(c, invoiceErrorStatus(err), gin.H{"message": "Failed to delete invoice", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{"message": "Invoice deleted successfully"})
+}
+
+// IssueInvoice - Finalisasi draft: beri nomor dan catat piutang ke ledger
+func (ic *InvoiceController) IssueInvoice(c *gin.Context) {
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid invoice ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var invoice *models.Invoice
+	err = ic.db.Transaction(func(tx *gorm.DB) error {
+		invoice, err = findInvoiceForUpdate(tx, invoiceID)
+		if err != nil {
+			return err
+		}
+		return billing.Issue(tx, invoice, ic.cfg.Billing.InvoicePrefix, userID.(uint))
+	})
+	if err != nil {
+		utils.ErrorResponse(c, invoiceErrorStatus(err), gin.H{"message": "Failed to issue invoice", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, dto.ToInvoiceResponse(*invoice))
+}
+
+// VoidInvoice - Membatalkan invoice draft/issued yang belum dibayar
+func (ic *InvoiceController) VoidInvoice(c *gin.Context) {
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid invoice ID"})
+		return
+	}
+
+	var req dto.InvoiceVoidRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var invoice *models.Invoice
+	err = ic.db.Transaction(func(tx *gorm.DB) error {
+		invoice, err = findInvoiceForUpdate(tx, invoiceID)
+		if err != nil {
+			return err
+		}
+		return billing.Void(tx, invoice, req.Reason, userID.(uint))
+	})
+	if err != nil {
+		utils.ErrorResponse(c, invoiceErrorStatus(err), gin.H{"message": "Failed to void invoice", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, dto.ToInvoiceResponse(*invoice))
+}
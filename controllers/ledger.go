@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"auth-api/ledger"
+	"auth-api/models"
+	"auth-api/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetCustomerLedger - Journal entry customer (urut kronologis) dengan running balance
+func (cc *CustomerController) GetCustomerLedger(c *gin.Context) {
+	id := c.Param("id")
+	customerID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid customer ID"})
+		return
+	}
+
+	var customer models.Customer
+	if err := cc.db.First(&customer, customerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Customer not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customer", "error": err.Error()})
+		return
+	}
+
+	// Get user info for access control
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("role")
+
+	if userRole == "customer" && customer.UserID != userID.(uint) {
+		utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: You can only view ledger of your own customers"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	query := cc.db.Model(&models.JournalEntry{}).Where("customer_id = ?", customer.ID)
+
+	var total int64
+	query.Count(&total)
+
+	var entries []models.JournalEntry
+	if err := query.Preload("Lines").
+		Order("id ASC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&entries).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch ledger", "error": err.Error()})
+		return
+	}
+
+	// Running balance dimulai dari saldo sebelum entry pertama di halaman ini
+	var runningBalance models.Money
+	if len(entries) > 0 {
+		runningBalance, err = ledger.BalanceBefore(cc.db, customer.ID, entries[0].ID)
+		if err != nil {
+			utils.ErrorResponse(c, 500, gin.H{"message": "Failed to calculate running balance", "error": err.Error()})
+			return
+		}
+	}
+
+	var entryResponses []gin.H
+	for _, entry := range entries {
+		net := ledger.EntryNet(entry, customer.ID)
+		runningBalance += net
+
+		var lines []gin.H
+		for _, line := range entry.Lines {
+			lines = append(lines, gin.H{
+				"account": line.Account,
+				"debit":   line.Debit,
+				"credit":  line.Credit,
+			})
+		}
+
+		entryResponses = append(entryResponses, gin.H{
+			"id":              entry.ID,
+			"type":            entry.Type,
+			"description":     entry.Description,
+			"reference":       entry.Reference,
+			"amount":          net,
+			"running_balance": runningBalance,
+			"lines":           lines,
+			"created_by":      entry.CreatedBy,
+			"created_at":      entry.CreatedAt,
+		})
+	}
+
+	ledgerBalance, err := ledger.CustomerBalance(cc.db, customer.ID)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to calculate ledger balance", "error": err.Error()})
+		return
+	}
+
+	totalPage := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"customer_id":    customer.ID,
+		"customer_code":  customer.CustomerCode,
+		"company_name":   customer.CompanyName,
+		"entries":        entryResponses,
+		"ledger_balance": ledgerBalance,
+		"cached_balance": customer.Balance,
+		"in_sync":        ledgerBalance == customer.Balance,
+		"total":          total,
+		"page":           page,
+		"page_size":      pageSize,
+		"total_page":     totalPage,
+	})
+}
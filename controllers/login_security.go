@@ -0,0 +1,395 @@
+package controllers
+
+import (
+	"auth-api/database"
+	"auth-api/dto"
+	"auth-api/models"
+	"auth-api/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// loginAssessment - Hasil analisa device dan lokasi untuk satu request login
+type loginAssessment struct {
+	Fingerprint      string
+	IPAddress        string
+	UserAgent        string
+	Geo              utils.GeoLocation
+	IsNewDevice      bool
+	HasKnownDevices  bool
+	ImpossibleTravel bool
+}
+
+// RequiresStepUp - Device baru atau perjalanan tidak wajar wajib OTP meski user sudah verified
+func (a loginAssessment) RequiresStepUp() bool {
+	return a.IsNewDevice || a.ImpossibleTravel
+}
+
+// ShouldAlert - Alert "apakah ini Anda?" tidak dikirim untuk device pertama user
+func (a loginAssessment) ShouldAlert() bool {
+	return (a.IsNewDevice && a.HasKnownDevices) || a.ImpossibleTravel
+}
+
+func (ac *AuthController) assessLogin(c *gin.Context, user models.User) loginAssessment {
+	userAgent := c.GetHeader("User-Agent")
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	assessment := loginAssessment{
+		Fingerprint: utils.DeviceFingerprint(c),
+		IPAddress:   c.ClientIP(),
+		UserAgent:   userAgent,
+	}
+	assessment.Geo = utils.LookupGeo(assessment.IPAddress)
+
+	var knownDevices int64
+	ac.db.Model(&models.KnownDevice{}).Where("user_id = ?", user.ID).Count(&knownDevices)
+	assessment.HasKnownDevices = knownDevices > 0
+
+	var device models.KnownDevice
+	err := ac.db.Where("user_id = ? AND fingerprint = ?", user.ID, assessment.Fingerprint).First(&device).Error
+	assessment.IsNewDevice = err != nil
+
+	// Impossible travel: kecepatan dari lokasi login sukses terakhir melebihi batas wajar.
+	// Login step-up dicatat success=false (pending_otp) sampai OTP valid, jadi tidak ikut sebagai pembanding.
+	if assessment.Geo.Found {
+		var last models.LoginHistory
+		err := ac.db.Where("user_id = ? AND success = ? AND latitude IS NOT NULL", user.ID, true).
+			Order("created_at DESC").
+			First(&last).Error
+		if err == nil && last.Latitude != nil && last.Longitude != nil {
+			distance := utils.DistanceKm(*last.Latitude, *last.Longitude, assessment.Geo.Latitude, assessment.Geo.Longitude)
+			hours := time.Since(last.CreatedAt).Hours()
+			if hours < 1.0/60 {
+				hours = 1.0 / 60
+			}
+			assessment.ImpossibleTravel = distance/hours > ac.cfg.Security.ImpossibleTravelSpeedKmh
+		}
+	}
+
+	return assessment
+}
+
+// recordLoginAttempt - Simpan percobaan login ke login_histories
+func (ac *AuthController) recordLoginAttempt(c *gin.Context, userID *uint, email, stage string, success bool, failureReason string, assessment *loginAssessment) {
+	entry := models.LoginHistory{
+		UserID:        userID,
+		Email:         email,
+		Stage:         stage,
+		Success:       success,
+		FailureReason: failureReason,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.GetHeader("User-Agent"),
+		CreatedAt:     time.Now(),
+	}
+	if len(entry.UserAgent) > 255 {
+		entry.UserAgent = entry.UserAgent[:255]
+	}
+
+	if assessment != nil {
+		entry.DeviceFingerprint = assessment.Fingerprint
+		entry.IsNewDevice = assessment.IsNewDevice
+		entry.Suspicious = assessment.ImpossibleTravel
+		if assessment.Geo.Found {
+			entry.Country = assessment.Geo.Country
+			entry.City = assessment.Geo.City
+			entry.Latitude = &assessment.Geo.Latitude
+			entry.Longitude = &assessment.Geo.Longitude
+		}
+	} else {
+		entry.DeviceFingerprint = utils.DeviceFingerprint(c)
+	}
+
+	if err := ac.db.Create(&entry).Error; err != nil {
+		fmt.Printf("⚠️ Failed to record login attempt for %s: %v\n", email, err)
+	}
+}
+
+// rememberDevice - Tandai device sebagai dikenal setelah login berhasil
+func (ac *AuthController) rememberDevice(user models.User, assessment loginAssessment) {
+	now := time.Now()
+	device := models.KnownDevice{
+		UserID:      user.ID,
+		Fingerprint: assessment.Fingerprint,
+		UserAgent:   assessment.UserAgent,
+		LastIP:      assessment.IPAddress,
+		Country:     assessment.Geo.Country,
+		City:        assessment.Geo.City,
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}
+
+	err := ac.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "fingerprint"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_agent", "last_ip", "country", "city", "last_seen_at"}),
+	}).Create(&device).Error
+	if err != nil {
+		fmt.Printf("⚠️ Failed to remember device for user %d: %v\n", user.ID, err)
+	}
+}
+
+// sendLoginAlert - Kirim email "apakah ini Anda?" dengan link untuk mengakhiri semua sesi
+func (ac *AuthController) sendLoginAlert(user models.User, assessment loginAssessment) {
+	token, err := utils.GenerateRandomToken(32)
+	if err == nil {
+		err = database.StoreSessionRevokeToken(token, user.ID, assessment.Fingerprint, ac.cfg.JWT.Expiry)
+	}
+	if err != nil {
+		fmt.Printf("⚠️ Failed to create session revoke token: %v\n", err)
+		return
+	}
+
+	location := "Tidak diketahui"
+	if assessment.Geo.Found {
+		location = strings.TrimPrefix(fmt.Sprintf("%s, %s", assessment.Geo.City, assessment.Geo.Country), ", ")
+	}
+
+	data := utils.LoginAlertEmailData{
+		Name:       user.Name,
+		Time:       time.Now().Format("02 Jan 2006 15:04 MST"),
+		IPAddress:  assessment.IPAddress,
+		Location:   location,
+		UserAgent:  assessment.UserAgent,
+		Suspicious: assessment.ImpossibleTravel,
+		RevokeURL:  fmt.Sprintf("%s/billapi/v2/sessions/revoke?token=%s", ac.cfg.Server.BaseURL, token),
+	}
+	if err := utils.SendLoginAlertEmail(ac.cfg, user.Email, data); err != nil {
+		fmt.Printf("⚠️ Failed to send login alert email: %v\n", err)
+	}
+}
+
+// RevokeSessionsPage - Halaman konfirmasi dari link email alert, sesi diakhiri lewat POST
+func (ac *AuthController) RevokeSessionsPage(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Token is required"})
+		return
+	}
+
+	data, err := database.GetSessionRevokeToken(token)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+		return
+	}
+	if data == "" {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Revoke link has expired or is invalid"})
+		return
+	}
+
+	utils.RenderConfirmPage(c, utils.ConfirmPageData{
+		Title:   "Akhiri Semua Sesi",
+		Message: "Semua sesi login akun Anda akan diakhiri dan device dari login ini akan dilupakan.",
+		Action:  "/billapi/v2/sessions/revoke",
+		Token:   token,
+		Button:  "Akhiri Semua Sesi",
+	})
+}
+
+// RevokeSessionsFromAlert - Akhiri semua sesi dan lupakan device dengan token sekali pakai dari email alert
+func (ac *AuthController) RevokeSessionsFromAlert(c *gin.Context) {
+	var req dto.LinkTokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	data, err := database.ConsumeSessionRevokeToken(req.Token)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+		return
+	}
+	if data == "" {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Revoke link has expired or is invalid"})
+		return
+	}
+
+	parts := strings.SplitN(data, ":", 2)
+	userID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil || len(parts) != 2 {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+		return
+	}
+
+	if err := database.RevokeUserSessions(uint(userID), ac.cfg.JWT.Expiry); err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to revoke sessions"})
+		return
+	}
+
+	ac.db.Where("user_id = ? AND fingerprint = ?", userID, parts[1]).Delete(&models.KnownDevice{})
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"message": "All sessions have been revoked. Please reset your password immediately.",
+	})
+}
+
+// GetProfileLogins - Riwayat percobaan login milik user yang sedang login
+func (ac *AuthController) GetProfileLogins(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := ac.db.First(&user, userID).Error; err != nil {
+		utils.ErrorResponse(c, 404, gin.H{"message": "User not found"})
+		return
+	}
+
+	ac.respondLoginHistory(c, user)
+}
+
+// AdminGetUserLogins - Riwayat percobaan login user tertentu (admin only)
+func (ac *AuthController) AdminGetUserLogins(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid user ID"})
+		return
+	}
+
+	var user models.User
+	if err := ac.db.First(&user, targetID).Error; err != nil {
+		utils.ErrorResponse(c, 404, gin.H{"message": "User not found"})
+		return
+	}
+
+	ac.respondLoginHistory(c, user)
+}
+
+func (ac *AuthController) respondLoginHistory(c *gin.Context, user models.User) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	// Percobaan yang diblokir dicatat tanpa user_id, cocokkan juga lewat email
+	query := ac.db.Model(&models.LoginHistory{}).
+		Where("user_id = ? OR (user_id IS NULL AND email = ?)", user.ID, user.Email)
+
+	switch c.Query("success") {
+	case "true":
+		query = query.Where("success = ?", true)
+	case "false":
+		query = query.Where("success = ?", false)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var logins []models.LoginHistory
+	if err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logins).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch login history"})
+		return
+	}
+
+	var loginResponses []gin.H
+	for _, l := range logins {
+		loginResponses = append(loginResponses, gin.H{
+			"id":             l.ID,
+			"stage":          l.Stage,
+			"success":        l.Success,
+			"failure_reason": l.FailureReason,
+			"ip_address":     l.IPAddress,
+			"user_agent":     l.UserAgent,
+			"country":        l.Country,
+			"city":           l.City,
+			"is_new_device":  l.IsNewDevice,
+			"suspicious":     l.Suspicious,
+			"created_at":     l.CreatedAt,
+		})
+	}
+
+	totalPage := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"user_id":    user.ID,
+		"logins":     loginResponses,
+		"total":      total,
+		"page":       page,
+		"page_size":  pageSize,
+		"total_page": totalPage,
+	})
+}
+
+// GetProfileDevices - Daftar device yang dikenal untuk user yang sedang login
+func (ac *AuthController) GetProfileDevices(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var devices []models.KnownDevice
+	if err := ac.db.Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&devices).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch devices"})
+		return
+	}
+
+	currentFingerprint := utils.DeviceFingerprint(c)
+
+	var deviceResponses []gin.H
+	for _, d := range devices {
+		deviceResponses = append(deviceResponses, gin.H{
+			"id":            d.ID,
+			"user_agent":    d.UserAgent,
+			"last_ip":       d.LastIP,
+			"country":       d.Country,
+			"city":          d.City,
+			"first_seen_at": d.FirstSeenAt,
+			"last_seen_at":  d.LastSeenAt,
+			"current":       d.Fingerprint == currentFingerprint,
+		})
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"devices": deviceResponses,
+		"count":   len(deviceResponses),
+	})
+}
+
+// DeleteProfileDevice - Lupakan device, login berikutnya dari device tsb wajib OTP
+func (ac *AuthController) DeleteProfileDevice(c *gin.Context) {
+	deviceID, err := strconv.ParseUint(c.Param("device_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid device ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	result := ac.db.Where("id = ? AND user_id = ?", deviceID, userID).Delete(&models.KnownDevice{})
+	if result.Error != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to delete device"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.ErrorResponse(c, 404, gin.H{"message": "Device not found"})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"message":   "Device has been removed",
+		"device_id": deviceID,
+	})
+}
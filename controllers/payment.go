@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/dto"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PaymentController struct {
+	cfg *config.Config
+	db  *gorm.DB
+}
+
+func NewPaymentController(cfg *config.Config, db *gorm.DB) *PaymentController {
+	return &PaymentController{cfg: cfg, db: db}
+}
+
+func allocationInputs(allocations []dto.PaymentAllocationRequest) []billing.AllocationInput {
+	inputs := make([]billing.AllocationInput, 0, len(allocations))
+	for _, a := range allocations {
+		inputs = append(inputs, billing.AllocationInput{InvoiceID: a.InvoiceID, Amount: a.Amount})
+	}
+	return inputs
+}
+
+// reevaluateStatus - Customer yang disuspend oleh policy langsung aktif lagi begitu tunggakannya lunas
+func (pc *PaymentController) reevaluateStatus(customerID uint) {
+	err := pc.db.Transaction(func(tx *gorm.DB) error {
+		_, err := billing.EvaluateCustomerStatus(tx, pc.cfg, customerID, time.Now())
+		return err
+	})
+	if err != nil {
+		fmt.Printf("⚠️ Failed to re-evaluate status of customer %d: %v\n", customerID, err)
+	}
+}
+
+func paymentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return 404
+	case errors.Is(err, billing.ErrAllocationExceedsPayment),
+		errors.Is(err, billing.ErrAllocationExceedsInvoice),
+		errors.Is(err, billing.ErrInvoiceCustomerMismatch):
+		return 400
+	case errors.Is(err, billing.ErrInvoiceNotIssued),
+		errors.Is(err, billing.ErrNothingToAllocate):
+		return 409
+	}
+	return 500
+}
+
+// invoiceNumbers - Map invoice_id -> nomor invoice untuk response alokasi
+func (pc *PaymentController) invoiceNumbers(payments ...models.Payment) map[uint]string {
+	var ids []uint
+	for _, payment := range payments {
+		for _, allocation := range payment.Allocations {
+			ids = append(ids, allocation.InvoiceID)
+		}
+	}
+
+	numbers := make(map[uint]string)
+	if len(ids) == 0 {
+		return numbers
+	}
+
+	var invoices []models.Invoice
+	pc.db.Select("id", "invoice_number").Where("id IN ?", ids).Find(&invoices)
+	for _, invoice := range invoices {
+		if invoice.InvoiceNumber != nil {
+			numbers[invoice.ID] = *invoice.InvoiceNumber
+		}
+	}
+	return numbers
+}
+
+// balanceHistory - Catat perubahan saldo kredit customer akibat payment
+func balanceHistory(tx *gorm.DB, customerID uint, changeType string, oldBalance, newBalance models.Money, payment *models.Payment, entryID, userID uint) error {
+	history := models.CustomerHistory{
+		CustomerID: customerID,
+		Action:     "balance_update",
+		Changes: utils.ToJSON(gin.H{
+			"type":             changeType,
+			"amount":           newBalance - oldBalance,
+			"old_balance":      oldBalance,
+			"new_balance":      newBalance,
+			"payment_id":       payment.ID,
+			"reference":        payment.Reference,
+			"journal_entry_id": entryID,
+		}),
+		ChangedBy: userID,
+		CreatedAt: time.Now(),
+	}
+	return tx.Create(&history).Error
+}
+
+// CreatePayment - Mencatat uang masuk dan (opsional) langsung mengalokasikannya ke invoice
+func (pc *PaymentController) CreatePayment(c *gin.Context) {
+	var req dto.PaymentCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	if req.AutoAllocate && len(req.Allocations) > 0 {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Use either allocations or auto_allocate, not both"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	payment := models.Payment{
+		CustomerID: req.CustomerID,
+		Method:     req.Method,
+		Reference:  req.Reference,
+		Amount:     req.Amount,
+		ReceivedAt: time.Now(),
+		Notes:      req.Notes,
+	}
+	if req.ReceivedAt != nil {
+		payment.ReceivedAt = *req.ReceivedAt
+	}
+
+	err := pc.db.Transaction(func(tx *gorm.DB) error {
+		var customer models.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, req.CustomerID).Error; err != nil {
+			return err
+		}
+
+		entry, err := billing.RecordPayment(tx, &payment, allocationInputs(req.Allocations), req.AutoAllocate, userID.(uint))
+		if err != nil {
+			return err
+		}
+
+		if payment.UnappliedAmount > 0 {
+			return balanceHistory(tx, customer.ID, "payment", customer.Balance, customer.Balance+payment.UnappliedAmount, &payment, entry.ID, userID.(uint))
+		}
+		return nil
+	})
+	if err != nil {
+		utils.ErrorResponse(c, paymentErrorStatus(err), gin.H{"message": "Failed to record payment", "error": err.Error()})
+		return
+	}
+
+	pc.reevaluateStatus(payment.CustomerID)
+
+	utils.SuccessResponse(c, 201, dto.ToPaymentResponse(payment, pc.invoiceNumbers(payment)))
+}
+
+// GetPayments - List payment dengan filter customer/method/reference
+func (pc *PaymentController) GetPayments(c *gin.Context) {
+	var req dto.PaymentSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("role")
+
+	query := pc.db.Model(&models.Payment{})
+	if userRole == "customer" {
+		query = query.Where("customer_id IN (?)", gorm.Expr("SELECT id FROM customers WHERE user_id = ?", userID))
+	}
+	if req.CustomerID > 0 {
+		query = query.Where("customer_id = ?", req.CustomerID)
+	}
+	if req.Method != "" {
+		query = query.Where("method = ?", req.Method)
+	}
+	if req.Reference != "" {
+		query = query.Where("reference = ?", req.Reference)
+	}
+	if req.Unapplied {
+		query = query.Where("unapplied_amount > 0")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var payments []models.Payment
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Preload("Allocations").Order("received_at DESC, id DESC").Offset(offset).Limit(req.PageSize).Find(&payments).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch payments", "error": err.Error()})
+		return
+	}
+
+	numbers := pc.invoiceNumbers(payments...)
+	paymentResponses := []dto.PaymentResponse{}
+	for _, payment := range payments {
+		paymentResponses = append(paymentResponses, dto.ToPaymentResponse(payment, numbers))
+	}
+
+	totalPage := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, dto.PaymentListResponse{
+		Payments:  paymentResponses,
+		Total:     total,
+		Page:      req.Page,
+		PageSize:  req.PageSize,
+		TotalPage: totalPage,
+	})
+}
+
+// GetPaymentByID - Detail payment beserta invoice yang dilunasinya
+func (pc *PaymentController) GetPaymentByID(c *gin.Context) {
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid payment ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("role")
+
+	query := pc.db.Preload("Allocations")
+	if userRole == "customer" {
+		query = query.Where("customer_id IN (?)", gorm.Expr("SELECT id FROM customers WHERE user_id = ?", userID))
+	}
+
+	var payment models.Payment
+	if err := query.First(&payment, paymentID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Payment not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch payment", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, dto.ToPaymentResponse(payment, pc.invoiceNumbers(payment)))
+}
+
+// AllocatePayment - Alokasikan sisa kredit payment ke invoice yang masih terbuka
+func (pc *PaymentController) AllocatePayment(c *gin.Context) {
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid payment ID"})
+		return
+	}
+
+	var req dto.PaymentAllocateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	if req.AutoAllocate == (len(req.Allocations) > 0) {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Provide either allocations or auto_allocate"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var payment models.Payment
+	err = pc.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+			return err
+		}
+		var customer models.Customer
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, payment.CustomerID).Error; err != nil {
+			return err
+		}
+		oldBalance := customer.Balance
+
+		entry, err := billing.AllocatePayment(tx, &payment, &customer, allocationInputs(req.Allocations), req.AutoAllocate, userID.(uint))
+		if err != nil {
+			return err
+		}
+
+		return balanceHistory(tx, customer.ID, "payment_allocation", oldBalance, customer.Balance, &payment, entry.ID, userID.(uint))
+	})
+	if err != nil {
+		utils.ErrorResponse(c, paymentErrorStatus(err), gin.H{"message": "Failed to allocate payment", "error": err.Error()})
+		return
+	}
+
+	pc.reevaluateStatus(payment.CustomerID)
+
+	// Tampilkan seluruh alokasi payment, bukan hanya yang baru dibuat
+	pc.db.Where("payment_id = ?", payment.ID).Order("id ASC").Find(&payment.Allocations)
+
+	utils.SuccessResponse(c, 200, dto.ToPaymentResponse(payment, pc.invoiceNumbers(payment)))
+}
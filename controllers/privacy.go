@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"archive/zip"
+	"auth-api/dto"
+	"auth-api/models"
+	"auth-api/utils"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportProfile - Export seluruh data pribadi user (JSON atau ZIP)
+func (ac *AuthController) ExportProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := ac.db.First(&user, userID).Error; err != nil {
+		utils.ErrorResponse(c, 404, gin.H{"message": "User not found"})
+		return
+	}
+
+	export, err := ac.buildPersonalDataExport(user)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to build data export", "error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("personal_data_%d_%s", user.ID, time.Now().Format("20060102"))
+
+	if c.DefaultQuery("format", "json") != "zip" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.json", filename))
+		c.JSON(200, export)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.zip", filename))
+	c.Status(200)
+
+	zw := zip.NewWriter(c.Writer)
+	for name, section := range export {
+		w, err := zw.Create(name + ".json")
+		if err != nil {
+			fmt.Printf("⚠️ Failed to write data export for user %d: %v\n", user.ID, err)
+			return
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(section); err != nil {
+			fmt.Printf("⚠️ Failed to write data export for user %d: %v\n", user.ID, err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		fmt.Printf("⚠️ Failed to write data export for user %d: %v\n", user.ID, err)
+	}
+}
+
+// buildPersonalDataExport - Kumpulkan profile, login history, customer milik user dan history-nya
+func (ac *AuthController) buildPersonalDataExport(user models.User) (map[string]interface{}, error) {
+	var customers []models.Customer
+	if err := ac.db.Where("user_id = ?", user.ID).Find(&customers).Error; err != nil {
+		return nil, err
+	}
+
+	customerIDs := make([]uint, 0, len(customers))
+	customerResponses := make([]dto.CustomerResponse, 0, len(customers))
+	for _, customer := range customers {
+		customerIDs = append(customerIDs, customer.ID)
+		customerResponses = append(customerResponses, dto.ToCustomerResponse(customer))
+	}
+
+	var history []models.CustomerHistory
+	query := ac.db.Where("changed_by = ?", user.ID)
+	if len(customerIDs) > 0 {
+		query = ac.db.Where("customer_id IN ? OR changed_by = ?", customerIDs, user.ID)
+	}
+	if err := query.Order("created_at ASC").Find(&history).Error; err != nil {
+		return nil, err
+	}
+
+	var loginHistory []models.LoginHistory
+	if err := ac.db.Where("user_id = ?", user.ID).Order("created_at ASC").Find(&loginHistory).Error; err != nil {
+		return nil, err
+	}
+
+	var devices []models.KnownDevice
+	if err := ac.db.Where("user_id = ?", user.ID).Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"profile": gin.H{
+			"id":                    user.ID,
+			"name":                  user.Name,
+			"email":                 user.Email,
+			"role":                  user.Role,
+			"customer_id":           user.CustomerID,
+			"status":                user.Status,
+			"is_verified":           user.IsVerified,
+			"last_login":            user.LastLogin,
+			"created_at":            user.CreatedAt,
+			"updated_at":            user.UpdatedAt,
+			"deletion_scheduled_at": user.DeletionScheduledAt,
+			"exported_at":           time.Now(),
+		},
+		"login_history":    loginHistory,
+		"known_devices":    devices,
+		"customers":        customerResponses,
+		"customer_history": history,
+	}, nil
+}
+
+// RequestAccountDeletion - Jadwalkan penghapusan akun setelah grace period
+func (ac *AuthController) RequestAccountDeletion(c *gin.Context) {
+	var req dto.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := ac.db.First(&user, userID).Error; err != nil {
+		utils.ErrorResponse(c, 404, gin.H{"message": "User not found"})
+		return
+	}
+
+	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Password is incorrect"})
+		return
+	}
+
+	if user.DeletionScheduledAt != nil {
+		utils.ErrorResponse(c, 409, gin.H{
+			"message":               "Account deletion has already been requested",
+			"deletion_scheduled_at": user.DeletionScheduledAt,
+		})
+		return
+	}
+
+	// Admin terakhir tidak boleh menghapus akunnya sendiri
+	if user.Role == "admin" {
+		var admins int64
+		ac.db.Model(&models.User{}).
+			Where("role = ? AND status = ? AND deletion_scheduled_at IS NULL", "admin", "active").
+			Count(&admins)
+		if admins <= 1 {
+			utils.ErrorResponse(c, 409, gin.H{"message": "The last active admin account cannot be deleted"})
+			return
+		}
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(ac.cfg.Privacy.DeletionGracePeriod)
+	user.DeletionRequestedAt = &now
+	user.DeletionScheduledAt = &scheduledAt
+	if err := ac.db.Save(&user).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to schedule account deletion"})
+		return
+	}
+
+	var ownedCustomers int64
+	ac.db.Model(&models.Customer{}).Where("user_id = ?", user.ID).Count(&ownedCustomers)
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"message":               "Account deletion has been scheduled. You can cancel it before the scheduled time.",
+		"deletion_scheduled_at": scheduledAt,
+		"owned_customers":       ownedCustomers,
+	})
+}
+
+// CancelAccountDeletion - Membatalkan penghapusan akun selama grace period
+func (ac *AuthController) CancelAccountDeletion(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, 401, gin.H{"message": "User not authenticated"})
+		return
+	}
+
+	var user models.User
+	if err := ac.db.First(&user, userID).Error; err != nil {
+		utils.ErrorResponse(c, 404, gin.H{"message": "User not found"})
+		return
+	}
+
+	if user.DeletionScheduledAt == nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "No pending account deletion"})
+		return
+	}
+
+	user.DeletionRequestedAt = nil
+	user.DeletionScheduledAt = nil
+	if err := ac.db.Save(&user).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to cancel account deletion"})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"message": "Account deletion has been cancelled",
+	})
+}
@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/dto"
+	"auth-api/exports"
+	"auth-api/utils"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReportController struct {
+	cfg *config.Config
+	db  *gorm.DB
+}
+
+func NewReportController(cfg *config.Config, db *gorm.DB) *ReportController {
+	return &ReportController{cfg: cfg, db: db}
+}
+
+// reportRange - Periode laporan (tanggal, inklusif), default awal bulan berjalan sampai hari ini
+func reportRange(c *gin.Context, req dto.ReportRequest) (time.Time, time.Time, bool) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	to := now
+	if req.From != "" {
+		from, _ = time.ParseInLocation("2006-01-02", req.From, time.Local)
+	}
+	if req.To != "" {
+		to, _ = time.ParseInLocation("2006-01-02", req.To, time.Local)
+	}
+	if to.Before(from) {
+		utils.ErrorResponse(c, 400, gin.H{"message": "to must not be before from"})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// writeCSV - Kirim laporan sebagai file CSV
+func writeCSV(c *gin.Context, filename string, header []string, rows [][]string) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Status(200)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(header)
+	for _, row := range rows {
+		for i := range row {
+			row[i] = exports.EscapeCSVCell(row[i])
+		}
+	}
+	w.WriteAll(rows)
+}
+
+func reportFilename(name string, from, to time.Time) string {
+	return fmt.Sprintf("%s_%s_%s.csv", name, from.Format("20060102"), to.Format("20060102"))
+}
+
+// GetDashboard - Ringkasan finance: aging, pergerakan saldo periode berjalan, top customer dan total per user
+func (rc *ReportController) GetDashboard(c *gin.Context) {
+	var req dto.ReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	from, to, ok := reportRange(c, req)
+	if !ok {
+		return
+	}
+
+	aging, err := billing.BuildAgingReport(rc.db, nil, to)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to build aging report", "error": err.Error()})
+		return
+	}
+	movements, err := billing.BuildBalanceMovements(rc.db, from, to, "day")
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to build balance movements", "error": err.Error()})
+		return
+	}
+	top, err := billing.BuildTopCustomers(rc.db, from, to, 5, false)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch top customers", "error": err.Error()})
+		return
+	}
+	users, err := billing.BuildFinanceUserTotals(rc.db, from, to)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to build finance user totals", "error": err.Error()})
+		return
+	}
+
+	var totals billing.BalanceMovementRow
+	for _, row := range movements {
+		totals.Deposits += row.Deposits
+		totals.Deducts += row.Deducts
+		totals.Allocations += row.Allocations
+		totals.Adjustments += row.Adjustments
+		totals.NetChange += row.NetChange
+		totals.Changes += row.Changes
+	}
+	totals.Period = fmt.Sprintf("%s..%s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"from":            from.Format("2006-01-02"),
+		"to":              to.Format("2006-01-02"),
+		"receivables":     aging.Total,
+		"aging":           aging.Buckets,
+		"movements":       totals,
+		"top_customers":   top,
+		"finance_users":   users,
+		"daily_movements": movements,
+	})
+}
+
+// GetAgingReport - AR aging per customer dengan bucket 0-30/31-60/61-90/90+ hari dari due date
+func (rc *ReportController) GetAgingReport(c *gin.Context) {
+	var req dto.ReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	from, to, ok := reportRange(c, req)
+	if !ok {
+		return
+	}
+
+	// Tanpa from, semua invoice yang masih terbuka ikut dihitung
+	var issuedFrom *time.Time
+	if req.From != "" {
+		issuedFrom = &from
+	}
+
+	report, err := billing.BuildAgingReport(rc.db, issuedFrom, to)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to build aging report", "error": err.Error()})
+		return
+	}
+
+	if req.Format != "csv" {
+		utils.SuccessResponse(c, 200, report)
+		return
+	}
+
+	header := []string{"Customer ID", "Customer Code", "Company Name"}
+	for _, bucket := range billing.AgingBuckets {
+		header = append(header, bucket.Label)
+	}
+	header = append(header, "Total")
+
+	rows := make([][]string, 0, len(report.Customers)+1)
+	for _, customer := range report.Customers {
+		row := []string{strconv.FormatUint(uint64(customer.CustomerID), 10), customer.CustomerCode, customer.CompanyName}
+		for _, amount := range customer.Buckets {
+			row = append(row, amount.String())
+		}
+		rows = append(rows, append(row, customer.Total.String()))
+	}
+	totalRow := []string{"", "", "TOTAL"}
+	for _, bucket := range report.Buckets {
+		totalRow = append(totalRow, bucket.Amount.String())
+	}
+	rows = append(rows, append(totalRow, report.Total.String()))
+
+	writeCSV(c, fmt.Sprintf("ar_aging_%s.csv", report.AsOf.Format("20060102")), header, rows)
+}
+
+// GetBalanceMovements - Pergerakan saldo customer per hari atau bulan dari journal saldo customer dan payment
+func (rc *ReportController) GetBalanceMovements(c *gin.Context) {
+	var req dto.BalanceMovementReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	from, to, ok := reportRange(c, req.ReportRequest)
+	if !ok {
+		return
+	}
+	if req.Interval == "" {
+		req.Interval = "day"
+	}
+
+	movements, err := billing.BuildBalanceMovements(rc.db, from, to, req.Interval)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to build balance movements", "error": err.Error()})
+		return
+	}
+
+	if req.Format != "csv" {
+		utils.SuccessResponse(c, 200, gin.H{
+			"from":      from.Format("2006-01-02"),
+			"to":        to.Format("2006-01-02"),
+			"interval":  req.Interval,
+			"movements": movements,
+		})
+		return
+	}
+
+	rows := make([][]string, 0, len(movements))
+	for _, m := range movements {
+		rows = append(rows, []string{
+			m.Period,
+			m.Deposits.String(),
+			m.Deducts.String(),
+			m.Allocations.String(),
+			m.Adjustments.String(),
+			m.NetChange.String(),
+			strconv.FormatInt(m.Changes, 10),
+		})
+	}
+	writeCSV(c, reportFilename("balance_movements", from, to),
+		[]string{"Period", "Deposits", "Deducts", "Allocations", "Adjustments", "Net Change", "Changes"}, rows)
+}
+
+// GetTopCustomers - Customer dengan saldo terbesar (atau paling negatif dengan order=asc)
+func (rc *ReportController) GetTopCustomers(c *gin.Context) {
+	var req dto.TopCustomersReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	from, to, ok := reportRange(c, req.ReportRequest)
+	if !ok {
+		return
+	}
+	if req.Limit < 1 || req.Limit > 100 {
+		req.Limit = 10
+	}
+
+	customers, err := billing.BuildTopCustomers(rc.db, from, to, req.Limit, req.Order == "asc")
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch top customers", "error": err.Error()})
+		return
+	}
+
+	if req.Format != "csv" {
+		utils.SuccessResponse(c, 200, gin.H{
+			"from":      from.Format("2006-01-02"),
+			"to":        to.Format("2006-01-02"),
+			"customers": customers,
+		})
+		return
+	}
+
+	rows := make([][]string, 0, len(customers))
+	for _, customer := range customers {
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(customer.CustomerID), 10),
+			customer.CustomerCode,
+			customer.CompanyName,
+			customer.Status,
+			customer.Balance.String(),
+			customer.CreditLimit.String(),
+			customer.PeriodChange.String(),
+		})
+	}
+	writeCSV(c, reportFilename("top_customers", from, to),
+		[]string{"Customer ID", "Customer Code", "Company Name", "Status", "Balance", "Credit Limit", "Period Change"}, rows)
+}
+
+// GetFinanceUserTotals - Total deposit dan deduct yang dicatat oleh tiap user finance/admin
+func (rc *ReportController) GetFinanceUserTotals(c *gin.Context) {
+	var req dto.ReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	from, to, ok := reportRange(c, req)
+	if !ok {
+		return
+	}
+
+	users, err := billing.BuildFinanceUserTotals(rc.db, from, to)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to build finance user totals", "error": err.Error()})
+		return
+	}
+
+	if req.Format != "csv" {
+		utils.SuccessResponse(c, 200, gin.H{
+			"from":  from.Format("2006-01-02"),
+			"to":    to.Format("2006-01-02"),
+			"users": users,
+		})
+		return
+	}
+
+	rows := make([][]string, 0, len(users))
+	for _, user := range users {
+		rows = append(rows, []string{
+			strconv.FormatUint(uint64(user.UserID), 10),
+			user.Name,
+			user.Email,
+			user.Role,
+			strconv.FormatInt(user.DepositCount, 10),
+			user.Deposits.String(),
+			strconv.FormatInt(user.DeductCount, 10),
+			user.Deducts.String(),
+		})
+	}
+	writeCSV(c, reportFilename("finance_user_totals", from, to),
+		[]string{"User ID", "Name", "Email", "Role", "Deposit Count", "Deposits", "Deduct Count", "Deducts"}, rows)
+}
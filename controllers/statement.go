@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/models"
+	"auth-api/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxStatementDays - Batas panjang periode statement agar PDF dan query transaksi tetap kecil
+const maxStatementDays = 366
+
+// GetCustomerStatementPDF - Statement (rekening koran) customer dalam PDF.
+// Default periode adalah bulan berjalan sampai hari ini.
+func (cc *CustomerController) GetCustomerStatementPDF(c *gin.Context) {
+	id := c.Param("id")
+	customerID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid customer ID"})
+		return
+	}
+
+	var customer models.Customer
+	if err := cc.db.First(&customer, customerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Customer not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customer", "error": err.Error()})
+		return
+	}
+
+	// Get user info for access control
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("role")
+
+	if userRole == "customer" && customer.UserID != userID.(uint) {
+		utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: You can only view statements of your own customers"})
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	to := now
+	if v := c.Query("from"); v != "" {
+		if from, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			utils.ErrorResponse(c, 400, gin.H{"message": "Invalid from date, use YYYY-MM-DD"})
+			return
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			utils.ErrorResponse(c, 400, gin.H{"message": "Invalid to date, use YYYY-MM-DD"})
+			return
+		}
+	}
+	if to.Before(from) {
+		utils.ErrorResponse(c, 400, gin.H{"message": "to must not be before from"})
+		return
+	}
+	if to.After(from.AddDate(0, 0, maxStatementDays)) {
+		utils.ErrorResponse(c, 400, gin.H{"message": fmt.Sprintf("Statement period must not exceed %d days", maxStatementDays)})
+		return
+	}
+
+	statement, err := billing.BuildStatement(cc.db, customer, from, to)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to build statement", "error": err.Error()})
+		return
+	}
+
+	pdf, err := billing.RenderStatementPDF(cc.cfg, statement)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to render statement", "error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("statement_%s_%s_%s.pdf", customer.CustomerCode, statement.From.Format("20060102"), statement.To.Format("20060102"))
+	c.Header("Content-Disposition", "inline; filename="+filename)
+	c.Data(200, "application/pdf", pdf)
+}
+
+// GetInvoicePDF - Cetak invoice dalam PDF
+func (ic *InvoiceController) GetInvoicePDF(c *gin.Context) {
+	invoiceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid invoice ID"})
+		return
+	}
+
+	var invoice models.Invoice
+	query := scopeInvoices(c, ic.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}))
+	if err := query.First(&invoice, invoiceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Invoice not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch invoice", "error": err.Error()})
+		return
+	}
+
+	var customer models.Customer
+	if err := ic.db.First(&customer, invoice.CustomerID).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customer", "error": err.Error()})
+		return
+	}
+
+	pdf, err := billing.RenderInvoicePDF(ic.cfg, invoice, customer)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to render invoice", "error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("invoice_draft_%d.pdf", invoice.ID)
+	if invoice.InvoiceNumber != nil {
+		filename = strings.ReplaceAll(*invoice.InvoiceNumber, "/", "-") + ".pdf"
+	}
+	c.Header("Content-Disposition", "inline; filename="+filename)
+	c.Data(200, "application/pdf", pdf)
+}
@@ -0,0 +1,383 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/dto"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SubscriptionController struct {
+	cfg *config.Config
+	db  *gorm.DB
+}
+
+func NewSubscriptionController(cfg *config.Config, db *gorm.DB) *SubscriptionController {
+	return &SubscriptionController{cfg: cfg, db: db}
+}
+
+func subscriptionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return 404
+	case errors.Is(err, billing.ErrPlanInactive),
+		errors.Is(err, billing.ErrSamePlan),
+		errors.Is(err, billing.ErrSubscriptionIntervalMix):
+		return 400
+	case errors.Is(err, billing.ErrSubscriptionNotActive),
+		errors.Is(err, billing.ErrSubscriptionNotPaused),
+		errors.Is(err, billing.ErrSubscriptionCancelled),
+		errors.Is(err, billing.ErrSubscriptionLocked):
+		return 409
+	}
+	return 500
+}
+
+// GetPlans - List plan. Customer hanya melihat plan yang aktif.
+func (sc *SubscriptionController) GetPlans(c *gin.Context) {
+	userRole, _ := c.Get("role")
+
+	query := sc.db.Order("price ASC")
+	if userRole == "customer" || c.Query("active") == "true" {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var plans []models.Plan
+	if err := query.Find(&plans).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch plans", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, plans)
+}
+
+// CreatePlan - Membuat plan baru
+func (sc *SubscriptionController) CreatePlan(c *gin.Context) {
+	var req dto.PlanCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	var existing models.Plan
+	if err := sc.db.Where("code = ?", req.Code).First(&existing).Error; err == nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Plan code already exists"})
+		return
+	}
+
+	// Tarif kosong = ikut tarif PPN yang berlaku pada setiap periode tagihan
+	var taxRateBps *int
+	if req.TaxRate != nil {
+		bps := dto.TaxRateToBps(*req.TaxRate)
+		taxRateBps = &bps
+	}
+
+	plan := models.Plan{
+		Code:            req.Code,
+		Name:            req.Name,
+		Description:     req.Description,
+		Price:           req.Price,
+		BillingInterval: req.BillingInterval,
+		TaxRateBps:      taxRateBps,
+		IsActive:        true,
+	}
+	if plan.BillingInterval == "" {
+		plan.BillingInterval = "monthly"
+	}
+
+	if err := sc.db.Create(&plan).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to create plan", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 201, plan)
+}
+
+// UpdatePlan - Mengubah plan. Harga baru berlaku mulai periode berikutnya.
+func (sc *SubscriptionController) UpdatePlan(c *gin.Context) {
+	planID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid plan ID"})
+		return
+	}
+
+	var req dto.PlanUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	var plan models.Plan
+	if err := sc.db.First(&plan, planID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Plan not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch plan", "error": err.Error()})
+		return
+	}
+
+	if req.Name != "" {
+		plan.Name = req.Name
+	}
+	if req.Description != nil {
+		plan.Description = *req.Description
+	}
+	if req.Price != nil {
+		plan.Price = *req.Price
+	}
+	if req.ClearTaxRate {
+		plan.TaxRateBps = nil
+	} else if req.TaxRate != nil {
+		bps := dto.TaxRateToBps(*req.TaxRate)
+		plan.TaxRateBps = &bps
+	}
+	if req.IsActive != nil {
+		plan.IsActive = *req.IsActive
+	}
+
+	if err := sc.db.Save(&plan).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to update plan", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, plan)
+}
+
+// GetSubscriptions - List subscription, customer hanya melihat miliknya sendiri
+func (sc *SubscriptionController) GetSubscriptions(c *gin.Context) {
+	var req dto.SubscriptionSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
+
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("role")
+
+	query := sc.db.Model(&models.Subscription{})
+	if userRole == "customer" {
+		query = query.Where("customer_id IN (?)", gorm.Expr("SELECT id FROM customers WHERE user_id = ?", userID))
+	}
+	if req.CustomerID > 0 {
+		query = query.Where("customer_id = ?", req.CustomerID)
+	}
+	if req.Status != "" {
+		query = query.Where("status = ?", req.Status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var subscriptions []models.Subscription
+	offset := (req.Page - 1) * req.PageSize
+	if err := query.Preload("Plan").Order("created_at DESC").Offset(offset).Limit(req.PageSize).Find(&subscriptions).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch subscriptions", "error": err.Error()})
+		return
+	}
+
+	totalPage := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPage++
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"subscriptions": subscriptions,
+		"total":         total,
+		"page":          req.Page,
+		"page_size":     req.PageSize,
+		"total_page":    totalPage,
+	})
+}
+
+// GetSubscriptionByID - Detail subscription beserta riwayat tagihannya
+func (sc *SubscriptionController) GetSubscriptionByID(c *gin.Context) {
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid subscription ID"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("role")
+
+	query := sc.db.Preload("Plan")
+	if userRole == "customer" {
+		query = query.Where("customer_id IN (?)", gorm.Expr("SELECT id FROM customers WHERE user_id = ?", userID))
+	}
+
+	var subscription models.Subscription
+	if err := query.First(&subscription, subscriptionID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Subscription not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch subscription", "error": err.Error()})
+		return
+	}
+
+	var charges []models.SubscriptionCharge
+	sc.db.Where("subscription_id = ?", subscription.ID).Order("id DESC").Limit(50).Find(&charges)
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"subscription": subscription,
+		"charges":      charges,
+	})
+}
+
+// CreateSubscription - Daftarkan customer ke plan, periode pertama langsung ditagih
+func (sc *SubscriptionController) CreateSubscription(c *gin.Context) {
+	var req dto.SubscriptionCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	var customer models.Customer
+	if err := sc.db.First(&customer, req.CustomerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Customer not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customer", "error": err.Error()})
+		return
+	}
+	if customer.Status != "active" {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Customer is not active"})
+		return
+	}
+
+	var plan models.Plan
+	if err := sc.db.First(&plan, req.PlanID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Plan not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch plan", "error": err.Error()})
+		return
+	}
+
+	subscription := models.Subscription{
+		CustomerID:    customer.ID,
+		BillingMethod: req.BillingMethod,
+	}
+	if subscription.BillingMethod == "" {
+		subscription.BillingMethod = "invoice"
+	}
+
+	err := sc.db.Transaction(func(tx *gorm.DB) error {
+		return billing.StartSubscription(tx, sc.cfg, &subscription, plan, userID.(uint))
+	})
+	if err != nil {
+		utils.ErrorResponse(c, subscriptionErrorStatus(err), gin.H{"message": "Failed to create subscription", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 201, subscription)
+}
+
+// mutateSubscription - Jalankan perubahan subscription di bawah Redis lock dan row lock
+func (sc *SubscriptionController) mutateSubscription(c *gin.Context, action string, fn func(tx *gorm.DB, subscription *models.Subscription) (interface{}, error)) {
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": "Invalid subscription ID"})
+		return
+	}
+
+	release, err := billing.LockSubscription(uint(subscriptionID))
+	if err != nil {
+		utils.ErrorResponse(c, subscriptionErrorStatus(err), gin.H{"message": "Failed to " + action + " subscription", "error": err.Error()})
+		return
+	}
+	defer release()
+
+	var subscription *models.Subscription
+	var extra interface{}
+	err = sc.db.Transaction(func(tx *gorm.DB) error {
+		subscription, err = billing.FindSubscriptionForUpdate(tx, uint(subscriptionID))
+		if err != nil {
+			return err
+		}
+		extra, err = fn(tx, subscription)
+		return err
+	})
+	if err != nil {
+		utils.ErrorResponse(c, subscriptionErrorStatus(err), gin.H{"message": "Failed to " + action + " subscription", "error": err.Error()})
+		return
+	}
+
+	response := gin.H{"subscription": subscription}
+	if extra != nil {
+		response["charge"] = extra
+	}
+	utils.SuccessResponse(c, 200, response)
+}
+
+// ChangeSubscriptionPlan - Upgrade/downgrade dengan prorata sisa periode
+func (sc *SubscriptionController) ChangeSubscriptionPlan(c *gin.Context) {
+	var req dto.SubscriptionChangePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	sc.mutateSubscription(c, "change plan of", func(tx *gorm.DB, subscription *models.Subscription) (interface{}, error) {
+		var plan models.Plan
+		if err := tx.First(&plan, req.PlanID).Error; err != nil {
+			return nil, err
+		}
+		record, err := billing.ChangePlan(tx, sc.cfg, subscription, plan, userID.(uint))
+		if record == nil {
+			return nil, err
+		}
+		return record, err
+	})
+}
+
+// PauseSubscription - Menghentikan penagihan sementara
+func (sc *SubscriptionController) PauseSubscription(c *gin.Context) {
+	sc.mutateSubscription(c, "pause", func(tx *gorm.DB, subscription *models.Subscription) (interface{}, error) {
+		return nil, billing.PauseSubscription(tx, subscription)
+	})
+}
+
+// ResumeSubscription - Mengaktifkan kembali subscription yang di-pause
+func (sc *SubscriptionController) ResumeSubscription(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	sc.mutateSubscription(c, "resume", func(tx *gorm.DB, subscription *models.Subscription) (interface{}, error) {
+		return nil, billing.ResumeSubscription(tx, sc.cfg, subscription, userID.(uint))
+	})
+}
+
+// CancelSubscription - Membatalkan subscription langsung atau di akhir periode
+func (sc *SubscriptionController) CancelSubscription(c *gin.Context) {
+	var req dto.SubscriptionCancelRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+			return
+		}
+	}
+
+	sc.mutateSubscription(c, "cancel", func(tx *gorm.DB, subscription *models.Subscription) (interface{}, error) {
+		return nil, billing.CancelSubscription(tx, subscription, req.Immediate)
+	})
+}
@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/dto"
+	"auth-api/models"
+	"auth-api/utils"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type TaxController struct {
+	cfg *config.Config
+	db  *gorm.DB
+}
+
+func NewTaxController(cfg *config.Config, db *gorm.DB) *TaxController {
+	return &TaxController{cfg: cfg, db: db}
+}
+
+// GetTaxRates - Daftar tarif pajak beserta tarif PPN yang berlaku hari ini
+func (tc *TaxController) GetTaxRates(c *gin.Context) {
+	var rates []models.TaxRate
+	if err := tc.db.Order("code ASC, effective_from DESC").Find(&rates).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch tax rates", "error": err.Error()})
+		return
+	}
+
+	current, err := billing.PPNRateAt(tc.db, time.Now())
+	if err != nil && !errors.Is(err, billing.ErrNoTaxRate) {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to resolve current tax rate", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"rates":           rates,
+		"current_ppn_bps": current,
+		"current_ppn":     float64(current) / 100,
+	})
+}
+
+// CreateTaxRate - Tambah tarif baru dengan tanggal berlaku. Tarif lama tetap dipakai untuk tanggal sebelumnya.
+func (tc *TaxController) CreateTaxRate(c *gin.Context) {
+	var req dto.TaxRateCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if req.Code == "" {
+		req.Code = billing.TaxCodePPN
+	}
+	effectiveFrom, _ := time.ParseInLocation("2006-01-02", req.EffectiveFrom, time.Local)
+
+	var existing models.TaxRate
+	if err := tc.db.Where("code = ? AND effective_from = ?", req.Code, effectiveFrom).First(&existing).Error; err == nil {
+		utils.ErrorResponse(c, 409, gin.H{"message": "A rate with this code and effective date already exists"})
+		return
+	}
+
+	rate := models.TaxRate{
+		Code:          req.Code,
+		RateBps:       dto.TaxRateToBps(req.Rate),
+		EffectiveFrom: effectiveFrom,
+		Description:   req.Description,
+		CreatedBy:     userID.(uint),
+		CreatedAt:     time.Now(),
+	}
+	if err := tc.db.Create(&rate).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to create tax rate", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 201, rate)
+}
+
+// ValidateNPWP - Cek format dan check digit NPWP tanpa menyimpan apa pun
+func (tc *TaxController) ValidateNPWP(c *gin.Context) {
+	npwp := c.Query("npwp")
+	if npwp == "" {
+		utils.ErrorResponse(c, 400, gin.H{"message": "npwp is required"})
+		return
+	}
+
+	normalized, err := utils.ValidateNPWP(npwp)
+	if err != nil {
+		utils.SuccessResponse(c, 200, gin.H{
+			"valid": false,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"valid":      true,
+		"normalized": normalized,
+		"formatted":  utils.FormatNPWP(normalized),
+	})
+}
+
+// GetTaxSummary - Rekap DPP dan PPN keluaran per periode (default bulan berjalan)
+func (tc *TaxController) GetTaxSummary(c *gin.Context) {
+	var req dto.TaxSummaryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	to := now
+	if req.From != "" {
+		from, _ = time.ParseInLocation("2006-01-02", req.From, time.Local)
+	}
+	if req.To != "" {
+		to, _ = time.ParseInLocation("2006-01-02", req.To, time.Local)
+	}
+	if to.Before(from) {
+		utils.ErrorResponse(c, 400, gin.H{"message": "to must not be before from"})
+		return
+	}
+
+	summary, err := billing.BuildTaxSummary(tc.db, from, to)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to build tax summary", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, summary)
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/database"
+	"auth-api/dto"
+	"auth-api/models"
+	"auth-api/utils"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var metricPattern = regexp.MustCompile(`^[a-z0-9_.\-]{1,50}$`)
+
+type UsageController struct {
+	cfg *config.Config
+	db  *gorm.DB
+}
+
+func NewUsageController(cfg *config.Config, db *gorm.DB) *UsageController {
+	return &UsageController{cfg: cfg, db: db}
+}
+
+// IngestUsage - Terima batch event usage, validasi, dedupe lewat event_id lalu buffer ke Redis.
+// Aggregate di MySQL diperbarui oleh usage rollup worker.
+func (uc *UsageController) IngestUsage(c *gin.Context) {
+	var req dto.UsageBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+	if len(req.Events) > uc.cfg.Usage.MaxBatchSize {
+		utils.ErrorResponse(c, 413, gin.H{"message": fmt.Sprintf("Batch too large, maximum %d events", uc.cfg.Usage.MaxBatchSize)})
+		return
+	}
+
+	// Resolve customer_code sekali per batch
+	codes := make([]string, 0, len(req.Events))
+	for _, event := range req.Events {
+		codes = append(codes, event.CustomerCode)
+	}
+	var customers []models.Customer
+	if err := uc.db.Select("id", "customer_code", "status").Where("customer_code IN ?", codes).Find(&customers).Error; err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to resolve customers", "error": err.Error()})
+		return
+	}
+	customersByCode := make(map[string]models.Customer, len(customers))
+	for _, customer := range customers {
+		customersByCode[customer.CustomerCode] = customer
+	}
+
+	response := dto.UsageBatchResponse{Rejected: []dto.UsageRejectedEvent{}}
+	now := time.Now()
+	seen := make(map[string]bool)
+
+	var events []billing.UsageEvent
+	var dedupeKeys []string
+	for i, e := range req.Events {
+		reject := func(reason string) {
+			response.Rejected = append(response.Rejected, dto.UsageRejectedEvent{Index: i, EventID: e.EventID, Reason: reason})
+		}
+
+		customer, ok := customersByCode[e.CustomerCode]
+		timestamp := now
+		if e.Timestamp != nil {
+			timestamp = *e.Timestamp
+		}
+
+		switch {
+		case e.EventID == "" || len(e.EventID) > 100:
+			reject("event_id is required (max 100 characters)")
+			continue
+		case !ok:
+			reject("unknown customer_code")
+			continue
+		case customer.Status == "terminated":
+			reject("customer is terminated")
+			continue
+		case !metricPattern.MatchString(e.Metric):
+			reject("metric must match [a-z0-9_.-], max 50 characters")
+			continue
+		case e.Quantity <= 0 || math.IsInf(e.Quantity, 0) || math.IsNaN(e.Quantity):
+			reject("quantity must be a positive number")
+			continue
+		case timestamp.Before(now.Add(-uc.cfg.Usage.MaxEventAge)):
+			reject("timestamp is too old")
+			continue
+		case timestamp.After(now.Add(5 * time.Minute)):
+			reject("timestamp is in the future")
+			continue
+		}
+
+		dedupeKey := fmt.Sprintf("%d:%s", customer.ID, e.EventID)
+		if seen[dedupeKey] {
+			response.Duplicates++
+			continue
+		}
+		seen[dedupeKey] = true
+
+		events = append(events, billing.UsageEvent{
+			EventID:    e.EventID,
+			CustomerID: customer.ID,
+			Metric:     e.Metric,
+			Quantity:   e.Quantity,
+			Timestamp:  timestamp,
+		})
+		dedupeKeys = append(dedupeKeys, dedupeKey)
+	}
+
+	if len(events) > 0 {
+		fresh, err := database.MarkUsageEvents(dedupeKeys, uc.cfg.Usage.DedupeTTL)
+		if err != nil {
+			utils.ErrorResponse(c, 503, gin.H{"message": "Usage buffer unavailable, please retry", "error": err.Error()})
+			return
+		}
+
+		var payloads, marked []string
+		for i, event := range events {
+			if !fresh[i] {
+				response.Duplicates++
+				continue
+			}
+			payload, _ := json.Marshal(event)
+			payloads = append(payloads, string(payload))
+			marked = append(marked, dedupeKeys[i])
+		}
+
+		if len(payloads) > 0 {
+			if err := database.PushUsageEvents(payloads); err != nil {
+				database.UnmarkUsageEvents(marked)
+				utils.ErrorResponse(c, 503, gin.H{"message": "Usage buffer unavailable, please retry", "error": err.Error()})
+				return
+			}
+		}
+		response.Accepted = len(payloads)
+	}
+
+	utils.SuccessResponse(c, 202, response)
+}
+
+// GetUsage - Total dan deret waktu pemakaian customer per periode (default bulan berjalan)
+func (uc *UsageController) GetUsage(c *gin.Context) {
+	var req dto.UsageQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ErrorResponse(c, 400, gin.H{"message": err.Error()})
+		return
+	}
+
+	var customer models.Customer
+	if err := uc.db.First(&customer, req.CustomerID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.ErrorResponse(c, 404, gin.H{"message": "Customer not found"})
+			return
+		}
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch customer", "error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	userRole, _ := c.Get("role")
+	if userRole == "customer" && customer.UserID != userID.(uint) {
+		utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: You can only view usage of your own customers"})
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, 0)
+	if req.From != "" {
+		from, _ = time.ParseInLocation("2006-01-02", req.From, time.Local)
+	}
+	if req.To != "" {
+		// to inklusif, query memakai batas eksklusif hari berikutnya
+		to, _ = time.ParseInLocation("2006-01-02", req.To, time.Local)
+		to = to.AddDate(0, 0, 1)
+	}
+	if !to.After(from) {
+		utils.ErrorResponse(c, 400, gin.H{"message": "to must not be before from"})
+		return
+	}
+
+	totals, err := billing.UsageTotals(uc.db, customer.ID, from, to)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch usage totals", "error": err.Error()})
+		return
+	}
+	series, err := billing.UsageSeries(uc.db, customer.ID, from, to, req.GroupBy, req.Metric)
+	if err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Failed to fetch usage series", "error": err.Error()})
+		return
+	}
+
+	utils.SuccessResponse(c, 200, gin.H{
+		"customer_id":   customer.ID,
+		"customer_code": customer.CustomerCode,
+		"from":          from,
+		"to":            to,
+		"group_by":      req.GroupBy,
+		"totals":        totals,
+		"series":        series,
+	})
+}
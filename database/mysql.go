@@ -29,7 +29,33 @@ func InitMySQL(cfg *config.Config) error {
 	DB = db
 
 	// Auto migrate
-	err = db.AutoMigrate(&models.User{})
+	err = db.AutoMigrate(
+		&models.User{},
+		&models.Customer{},
+		&models.CustomerHistory{},
+		&models.ImpersonationLog{},
+		&models.LoginHistory{},
+		&models.KnownDevice{},
+		&models.JournalEntry{},
+		&models.JournalLine{},
+		&models.Invoice{},
+		&models.InvoiceLine{},
+		&models.InvoiceSequence{},
+		&models.Payment{},
+		&models.PaymentAllocation{},
+		&models.Plan{},
+		&models.Subscription{},
+		&models.SubscriptionCharge{},
+		&models.UsageAggregate{},
+		&models.UsageRollupBatch{},
+		&models.TaxRate{},
+		&models.BalanceApproval{},
+		&models.DunningState{},
+		&models.ExportJob{},
+		&models.CustomerTag{},
+		&models.CustomerBulkJob{},
+		&models.CustomerBulkItem{},
+	)
 	if err != nil {
 		return err
 	}
@@ -128,3 +128,246 @@ func DeletePasswordResetOTP(email string) error {
 	key := fmt.Sprintf("pwd_reset:%s", email)
 	return RedisClient.Del(ctx, key).Err()
 }
+
+// Session revocation functions (waktu revoke dalam milidetik agar token yang dibuat
+// di detik yang sama setelah revoke, mis. login ulang, tetap berlaku)
+func RevokeUserSessions(userID uint, ttl time.Duration) error {
+	key := fmt.Sprintf("sessions_revoked:%d", userID)
+	return RedisClient.Set(ctx, key, time.Now().UnixMilli(), ttl).Err()
+}
+
+func GetSessionsRevokedAt(userID uint) (int64, error) {
+	key := fmt.Sprintf("sessions_revoked:%d", userID)
+	revokedAt, err := RedisClient.Get(ctx, key).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	// Nilai lama disimpan dalam detik: anggap revoke di akhir detik tersebut
+	if revokedAt > 0 && revokedAt < 1e12 {
+		revokedAt = revokedAt*1000 + 999
+	}
+	return revokedAt, err
+}
+
+// Email change functions
+func StoreEmailChange(userID uint, newEmail, oldOTP, newOTP string, expiry time.Duration) error {
+	key := fmt.Sprintf("email_change:%d", userID)
+	pipe := RedisClient.TxPipeline()
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, "new_email", newEmail, "old_otp", oldOTP, "new_otp", newOTP)
+	pipe.Expire(ctx, key, expiry)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func GetEmailChange(userID uint) (map[string]string, error) {
+	key := fmt.Sprintf("email_change:%d", userID)
+	return RedisClient.HGetAll(ctx, key).Result()
+}
+
+func DeleteEmailChange(userID uint) error {
+	key := fmt.Sprintf("email_change:%d", userID)
+	return RedisClient.Del(ctx, key).Err()
+}
+
+func StoreEmailChangeCancel(token, data string, expiry time.Duration) error {
+	key := fmt.Sprintf("email_change_cancel:%s", token)
+	return RedisClient.Set(ctx, key, data, expiry).Err()
+}
+
+func GetEmailChangeCancel(token string) (string, error) {
+	key := fmt.Sprintf("email_change_cancel:%s", token)
+	data, err := RedisClient.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return data, err
+}
+
+func DeleteEmailChangeCancel(token string) error {
+	key := fmt.Sprintf("email_change_cancel:%s", token)
+	return RedisClient.Del(ctx, key).Err()
+}
+
+// renameIfExistsScript - RENAMENX hanya jika key lama ada, agar tidak perlu membaca pesan error "no such key"
+var renameIfExistsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+return redis.call("RENAMENX", KEYS[1], KEYS[2])
+`)
+
+// MigrateEmailState - Memindahkan state Redis yang terikat ke email lama.
+// Counter login gagal dan blokir ikut pindah (TTL tetap) tanpa menimpa state yang sudah ada
+// untuk email baru, sedangkan OTP login/reset password yang masih berlaku dihapus karena dikirim ke alamat lama.
+func MigrateEmailState(oldEmail, newEmail string) error {
+	for _, prefix := range []string{"login_attempts", "blocked"} {
+		oldKey := fmt.Sprintf("%s:%s", prefix, oldEmail)
+		newKey := fmt.Sprintf("%s:%s", prefix, newEmail)
+		if err := renameIfExistsScript.Run(ctx, RedisClient, []string{oldKey, newKey}).Err(); err != nil {
+			return err
+		}
+	}
+
+	return RedisClient.Del(ctx,
+		fmt.Sprintf("otp:%s", oldEmail),
+		fmt.Sprintf("pwd_reset:%s", oldEmail),
+	).Err()
+}
+
+// Distributed lock functions (untuk background job di banyak instance)
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// AcquireLock - Mengembalikan token lock, atau string kosong jika lock dipegang instance lain
+func AcquireLock(name string, ttl time.Duration) (string, error) {
+	key := fmt.Sprintf("lock:%s", name)
+	token := fmt.Sprintf("%d", time.Now().UnixNano())
+
+	ok, err := RedisClient.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return "", err
+	}
+	return token, nil
+}
+
+func ReleaseLock(name, token string) error {
+	key := fmt.Sprintf("lock:%s", name)
+	return releaseLockScript.Run(ctx, RedisClient, []string{key}, token).Err()
+}
+
+// ClearEmailState - Menghapus semua state Redis yang terikat ke email
+func ClearEmailState(email string) error {
+	return RedisClient.Del(ctx,
+		fmt.Sprintf("otp:%s", email),
+		fmt.Sprintf("pwd_reset:%s", email),
+		fmt.Sprintf("login_attempts:%s", email),
+		fmt.Sprintf("blocked:%s", email),
+	).Err()
+}
+
+// Session revoke link functions ("was this you?" email)
+func StoreSessionRevokeToken(token string, userID uint, fingerprint string, expiry time.Duration) error {
+	key := fmt.Sprintf("session_revoke:%s", token)
+	return RedisClient.Set(ctx, key, fmt.Sprintf("%d:%s", userID, fingerprint), expiry).Err()
+}
+
+func GetSessionRevokeToken(token string) (string, error) {
+	key := fmt.Sprintf("session_revoke:%s", token)
+	data, err := RedisClient.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return data, err
+}
+
+// ConsumeSessionRevokeToken - Ambil dan hapus token secara atomik agar link hanya bisa dipakai sekali
+func ConsumeSessionRevokeToken(token string) (string, error) {
+	key := fmt.Sprintf("session_revoke:%s", token)
+	data, err := RedisClient.GetDel(ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return data, err
+}
+
+// Idempotency functions
+func ReserveIdempotencyKey(key, data string, ttl time.Duration) (bool, error) {
+	return RedisClient.SetNX(ctx, fmt.Sprintf("idempotency:%s", key), data, ttl).Result()
+}
+
+func GetIdempotencyRecord(key string) (string, error) {
+	data, err := RedisClient.Get(ctx, fmt.Sprintf("idempotency:%s", key)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return data, err
+}
+
+func SaveIdempotencyRecord(key, data string, ttl time.Duration) error {
+	return RedisClient.Set(ctx, fmt.Sprintf("idempotency:%s", key), data, ttl).Err()
+}
+
+func DeleteIdempotencyRecord(key string) error {
+	return RedisClient.Del(ctx, fmt.Sprintf("idempotency:%s", key)).Err()
+}
+
+// Usage metering buffer functions.
+// Event baru di-RPUSH ke usage_buffer. Saat rollup, buffer di-rename menjadi batch
+// (usage_batch:<id>) yang baru dihapus setelah aggregate-nya tersimpan di MySQL.
+const usageBufferKey = "usage_buffer"
+const usageBatchesKey = "usage_batches"
+
+// MarkUsageEvents - Tandai event ID sebagai sudah diterima, hasil true berarti event baru
+func MarkUsageEvents(eventIDs []string, ttl time.Duration) ([]bool, error) {
+	pipe := RedisClient.Pipeline()
+	cmds := make([]*redis.BoolCmd, len(eventIDs))
+	for i, id := range eventIDs {
+		cmds[i] = pipe.SetNX(ctx, fmt.Sprintf("usage_event:%s", id), 1, ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	fresh := make([]bool, len(eventIDs))
+	for i, cmd := range cmds {
+		fresh[i] = cmd.Val()
+	}
+	return fresh, nil
+}
+
+// UnmarkUsageEvents - Dipanggil jika event gagal di-buffer agar client bisa retry
+func UnmarkUsageEvents(eventIDs []string) error {
+	keys := make([]string, len(eventIDs))
+	for i, id := range eventIDs {
+		keys[i] = fmt.Sprintf("usage_event:%s", id)
+	}
+	return RedisClient.Del(ctx, keys...).Err()
+}
+
+func PushUsageEvents(payloads []string) error {
+	values := make([]interface{}, len(payloads))
+	for i, p := range payloads {
+		values[i] = p
+	}
+	return RedisClient.RPush(ctx, usageBufferKey, values...).Err()
+}
+
+// SealUsageBuffer - Pindahkan buffer aktif menjadi batch baru secara atomik, kosong jika tidak ada event
+func SealUsageBuffer() (string, error) {
+	exists, err := RedisClient.Exists(ctx, usageBufferKey).Result()
+	if err != nil || exists == 0 {
+		return "", err
+	}
+
+	batchID := fmt.Sprintf("%d", time.Now().UnixNano())
+	_, err = RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Rename(ctx, usageBufferKey, fmt.Sprintf("usage_batch:%s", batchID))
+		pipe.SAdd(ctx, usageBatchesKey, batchID)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return batchID, nil
+}
+
+func PendingUsageBatches() ([]string, error) {
+	return RedisClient.SMembers(ctx, usageBatchesKey).Result()
+}
+
+func ReadUsageBatch(batchID string) ([]string, error) {
+	return RedisClient.LRange(ctx, fmt.Sprintf("usage_batch:%s", batchID), 0, -1).Result()
+}
+
+func DeleteUsageBatch(batchID string) error {
+	_, err := RedisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, fmt.Sprintf("usage_batch:%s", batchID))
+		pipe.SRem(ctx, usageBatchesKey, batchID)
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,16 @@
+package dto
+
+type ApprovalReviewRequest struct {
+	Note string `json:"note" binding:"omitempty,max=255"`
+}
+
+type ApprovalRejectRequest struct {
+	Note string `json:"note" binding:"required,max=255"` // alasan penolakan wajib diisi
+}
+
+type ApprovalSearchRequest struct {
+	CustomerID uint   `form:"customer_id"`
+	Status     string `form:"status" binding:"omitempty,oneof=pending approved rejected cancelled expired"`
+	Page       int    `form:"page,default=1"`
+	PageSize   int    `form:"page_size,default=10"`
+}
@@ -60,3 +60,22 @@ type LoginResponseData struct {
 		CreatedAt  time.Time `json:"created_at"`
 	} `json:"user,omitempty"`
 }
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	OldEmailOTP string `json:"old_email_otp" binding:"required,min=6,max=6"`
+	NewEmailOTP string `json:"new_email_otp" binding:"required,min=6,max=6"`
+}
+
+// LinkTokenRequest - Token dari link email, dikirim lewat form halaman konfirmasi atau JSON
+type LinkTokenRequest struct {
+	Token string `form:"token" json:"token" binding:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
@@ -6,43 +6,81 @@ import (
 )
 
 type CustomerCreateRequest struct {
-	CustomerCode string  `json:"customer_code" binding:"required,min=3,max=50"`
-	CompanyName  string  `json:"company_name" binding:"required,min=2,max=200"`
-	ContactName  string  `json:"contact_name" binding:"max=100"`
-	Email        string  `json:"email" binding:"omitempty,email,max=100"`
-	Phone        string  `json:"phone" binding:"omitempty,max=20"`
-	Address      string  `json:"address" binding:"omitempty,max=500"`
-	NPWP         string  `json:"npwp" binding:"omitempty,max=25"`
-	Balance      float64 `json:"balance" binding:"omitempty,min=0"`
-	Status       string  `json:"status" binding:"omitempty,oneof=active suspended terminated"`
+	CustomerCode string       `json:"customer_code" binding:"required,min=3,max=50"`
+	CompanyName  string       `json:"company_name" binding:"required,min=2,max=200"`
+	ContactName  string       `json:"contact_name" binding:"max=100"`
+	Email        string       `json:"email" binding:"omitempty,email,max=100"`
+	Phone        string       `json:"phone" binding:"omitempty,max=20"`
+	Address      string       `json:"address" binding:"omitempty,max=500"`
+	NPWP         string       `json:"npwp" binding:"omitempty,max=25"`
+	Balance      models.Money `json:"balance" binding:"omitempty,min=0"`
+	Status       string       `json:"status" binding:"omitempty,oneof=active suspended terminated"`
+
+	// Pembebasan PPN, hanya finance dan admin
+	TaxExempt       bool   `json:"tax_exempt"`
+	TaxExemptReason string `json:"tax_exempt_reason" binding:"omitempty,max=255"`
+	TaxExemptUntil  string `json:"tax_exempt_until" binding:"omitempty,datetime=2006-01-02"`
+
+	// Credit line dan notifikasi saldo, hanya finance dan admin
+	CreditLimit         models.Money  `json:"credit_limit" binding:"omitempty,min=0"`
+	OverdraftPolicy     string        `json:"overdraft_policy" binding:"omitempty,oneof=hard_stop require_approval allow_with_alert"`
+	LowBalanceThreshold *models.Money `json:"low_balance_threshold"`
 }
 
 type CustomerUpdateRequest struct {
-	CompanyName string  `json:"company_name" binding:"omitempty,min=2,max=200"`
-	ContactName string  `json:"contact_name" binding:"omitempty,max=100"`
-	Email       string  `json:"email" binding:"omitempty,email,max=100"`
-	Phone       string  `json:"phone" binding:"omitempty,max=20"`
-	Address     string  `json:"address" binding:"omitempty,max=500"`
-	NPWP        string  `json:"npwp" binding:"omitempty,max=25"`
-	Balance     float64 `json:"balance" binding:"omitempty,min=0"`
-	Status      string  `json:"status" binding:"omitempty,oneof=active suspended terminated"`
+	CompanyName  string       `json:"company_name" binding:"omitempty,min=2,max=200"`
+	ContactName  string       `json:"contact_name" binding:"omitempty,max=100"`
+	Email        string       `json:"email" binding:"omitempty,email,max=100"`
+	Phone        string       `json:"phone" binding:"omitempty,max=20"`
+	Address      string       `json:"address" binding:"omitempty,max=500"`
+	NPWP         string       `json:"npwp" binding:"omitempty,max=25"`
+	Balance      models.Money `json:"balance" binding:"omitempty,min=0"`
+	Status       string       `json:"status" binding:"omitempty,oneof=active suspended terminated"`
+	StatusReason string       `json:"status_reason" binding:"omitempty,max=255"` // wajib jika status berubah
+
+	// Pembebasan PPN, hanya finance dan admin. TaxExemptUntil "" menghapus batas waktu.
+	TaxExempt       *bool   `json:"tax_exempt"`
+	TaxExemptReason *string `json:"tax_exempt_reason" binding:"omitempty,max=255"`
+	TaxExemptUntil  *string `json:"tax_exempt_until" binding:"omitempty"`
+
+	// Credit line dan notifikasi saldo, hanya finance dan admin
+	CreditLimit              *models.Money `json:"credit_limit" binding:"omitempty,min=0"`
+	OverdraftPolicy          string        `json:"overdraft_policy" binding:"omitempty,oneof=hard_stop require_approval allow_with_alert"`
+	LowBalanceThreshold      *models.Money `json:"low_balance_threshold"`
+	ClearLowBalanceThreshold bool          `json:"clear_low_balance_threshold"` // matikan notifikasi saldo rendah
 }
 
 type CustomerResponse struct {
-	ID           uint      `json:"id"`
-	CustomerCode string    `json:"customer_code"`
-	CompanyName  string    `json:"company_name"`
-	ContactName  string    `json:"contact_name"`
-	Email        string    `json:"email"`
-	Phone        string    `json:"phone"`
-	Address      string    `json:"address"`
-	NPWP         string    `json:"npwp"`
-	Balance      float64   `json:"balance"`
-	Status       string    `json:"status"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	UserID       uint      `json:"user_id"`
-	CreatedBy    string    `json:"created_by,omitempty"`
+	ID                  uint          `json:"id"`
+	CustomerCode        string        `json:"customer_code"`
+	CompanyName         string        `json:"company_name"`
+	ContactName         string        `json:"contact_name"`
+	Email               string        `json:"email"`
+	Phone               string        `json:"phone"`
+	Address             string        `json:"address"`
+	NPWP                string        `json:"npwp"`
+	TaxExempt           bool          `json:"tax_exempt"`
+	TaxExemptReason     string        `json:"tax_exempt_reason,omitempty"`
+	TaxExemptUntil      *time.Time    `json:"tax_exempt_until,omitempty"`
+	Balance             models.Money  `json:"balance"`
+	CreditLimit         models.Money  `json:"credit_limit"`
+	AvailableCredit     models.Money  `json:"available_credit"`
+	OverdraftPolicy     string        `json:"overdraft_policy"`
+	LowBalanceThreshold *models.Money `json:"low_balance_threshold,omitempty"`
+	Status              string        `json:"status"`
+	StatusReason        string        `json:"status_reason,omitempty"`
+	StatusChangedAt     *time.Time    `json:"status_changed_at,omitempty"`
+	AutoSuspended       bool          `json:"auto_suspended"`
+	Version             uint          `json:"version"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+	DeletedAt           *time.Time    `json:"deleted_at,omitempty"`
+	PurgedAt            *time.Time    `json:"purged_at,omitempty"`
+	UserID              uint          `json:"user_id"`
+	CreatedBy           string        `json:"created_by,omitempty"`
+
+	// Koreksi saldo yang menunggu approval (maker-checker)
+	PendingApproval *models.BalanceApproval `json:"pending_approval,omitempty"`
 }
 
 type CustomerListResponse struct {
@@ -53,15 +91,17 @@ type CustomerListResponse struct {
 	TotalPage int                `json:"total_page"`
 }
 
+// CustomerBalanceUpdateRequest - Hanya pemotongan saldo; uang masuk dicatat lewat POST /payments
 type CustomerBalanceUpdateRequest struct {
-	Amount float64 `json:"amount" binding:"required"`
-	Type   string  `json:"type" binding:"required,oneof=deposit deduct"`
-	Notes  string  `json:"notes" binding:"omitempty,max=255"`
+	Amount models.Money `json:"amount" binding:"required,gt=0"`
+	Type   string       `json:"type" binding:"required,oneof=deduct"`
+	Notes  string       `json:"notes" binding:"omitempty,max=255"`
 }
 
 type CustomerSearchRequest struct {
 	Search    string `form:"search"`
 	Status    string `form:"status"`
+	Tag       string `form:"tag"`
 	Page      int    `form:"page,default=1"`
 	PageSize  int    `form:"page_size,default=10"`
 	SortBy    string `form:"sort_by,default=created_at"`
@@ -70,19 +110,81 @@ type CustomerSearchRequest struct {
 
 // Helper function untuk convert model ke response
 func ToCustomerResponse(customer models.Customer) CustomerResponse {
-	return CustomerResponse{
-		ID:           customer.ID,
-		CustomerCode: customer.CustomerCode,
-		CompanyName:  customer.CompanyName,
-		ContactName:  customer.ContactName,
-		Email:        customer.Email,
-		Phone:        customer.Phone,
-		Address:      customer.Address,
-		NPWP:         customer.NPWP,
-		Balance:      customer.Balance,
-		Status:       customer.Status,
-		CreatedAt:    customer.CreatedAt,
-		UpdatedAt:    customer.UpdatedAt,
-		UserID:       customer.UserID,
+	response := CustomerResponse{
+		ID:                  customer.ID,
+		CustomerCode:        customer.CustomerCode,
+		CompanyName:         customer.CompanyName,
+		ContactName:         customer.ContactName,
+		Email:               customer.Email,
+		Phone:               customer.Phone,
+		Address:             customer.Address,
+		NPWP:                customer.NPWP,
+		TaxExempt:           customer.TaxExempt,
+		TaxExemptReason:     customer.TaxExemptReason,
+		TaxExemptUntil:      customer.TaxExemptUntil,
+		Balance:             customer.Balance,
+		CreditLimit:         customer.CreditLimit,
+		AvailableCredit:     customer.AvailableCredit(),
+		OverdraftPolicy:     customer.OverdraftPolicy,
+		LowBalanceThreshold: customer.LowBalanceThreshold,
+		Status:              customer.Status,
+		StatusReason:        customer.StatusReason,
+		StatusChangedAt:     customer.StatusChangedAt,
+		AutoSuspended:       customer.AutoSuspended,
+		Version:             customer.Version,
+		CreatedAt:           customer.CreatedAt,
+		UpdatedAt:           customer.UpdatedAt,
+		PurgedAt:            customer.PurgedAt,
+		UserID:              customer.UserID,
 	}
+	if customer.DeletedAt.Valid {
+		response.DeletedAt = &customer.DeletedAt.Time
+	}
+	return response
+}
+
+type CustomerExportRequest struct {
+	CustomerSearchRequest
+	Columns string `form:"columns"` // dipisah koma, mis. customer_code,company_name,balance
+	BOM     bool   `form:"bom"`     // tambahkan UTF-8 BOM untuk Excel (hanya csv)
+	Format  string `form:"format" binding:"omitempty,oneof=csv xlsx ndjson"`
+}
+
+type CustomerHistoryExportRequest struct {
+	Action string `form:"action" binding:"omitempty,oneof=create update delete status_change balance_update dunning restore purge"`
+	From   string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To     string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx ndjson"`
+	BOM    bool   `form:"bom"`
+}
+
+// CustomerImportRequest - Form multipart untuk POST /customers/import (field file berisi CSV/XLSX)
+type CustomerImportRequest struct {
+	DryRun      bool `form:"dry_run"`      // hanya validasi, tidak ada data yang disimpan
+	SkipInvalid bool `form:"skip_invalid"` // tetap import baris yang valid walau ada baris yang gagal validasi
+}
+
+// CustomerBulkRequest - Operasi massal: target berupa daftar ID atau filter (salah satu)
+type CustomerBulkRequest struct {
+	Action string              `json:"action" binding:"required,oneof=set_status reassign add_tag delete"`
+	IDs    []uint              `json:"ids"`
+	Filter *CustomerBulkFilter `json:"filter"`
+
+	Status string `json:"status" binding:"omitempty,oneof=active suspended terminated"` // set_status
+	Reason string `json:"reason" binding:"omitempty,max=255"`                           // set_status
+	UserID uint   `json:"user_id"`                                                      // reassign
+	Tag    string `json:"tag" binding:"omitempty,max=50"`                               // add_tag
+}
+
+// CustomerBulkFilter - Filter yang sama dengan list customer
+type CustomerBulkFilter struct {
+	Search string `json:"search"`
+	Status string `json:"status"`
+	Tag    string `json:"tag"`
+}
+
+type CustomerBulkItemSearchRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending succeeded skipped failed"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=10"`
 }
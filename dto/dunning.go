@@ -0,0 +1,13 @@
+package dto
+
+type DunningPauseRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`             // mis. nomor tiket dispute
+	Until  string `json:"until" binding:"omitempty,datetime=2006-01-02"` // kosong = sampai di-resume manual
+}
+
+type DunningBoardRequest struct {
+	Stage    string `form:"stage"` // nama tahap, atau "none" untuk yang belum masuk tahap apa pun
+	Paused   *bool  `form:"paused"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=10"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+type ExportJobFilters struct {
+	// Filter customer (type customers), sama dengan list customer
+	Search    string `json:"search"`
+	Status    string `json:"status"`
+	Tag       string `json:"tag"`
+	SortBy    string `json:"sort_by"`
+	SortOrder string `json:"sort_order" binding:"omitempty,oneof=asc desc"`
+
+	// Filter history (type customer_history)
+	CustomerID uint   `json:"customer_id"`
+	Action     string `json:"action" binding:"omitempty,oneof=create update delete status_change balance_update dunning restore purge"`
+	From       string `json:"from" binding:"omitempty,datetime=2006-01-02"`
+	To         string `json:"to" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type ExportJobCreateRequest struct {
+	Type    string           `json:"type" binding:"required,oneof=customers customer_history"`
+	Format  string           `json:"format" binding:"omitempty,oneof=csv xlsx ndjson"`
+	Columns string           `json:"columns"` // hanya untuk type customers
+	BOM     bool             `json:"bom"`
+	Filters ExportJobFilters `json:"filters"`
+}
+
+type ExportJobSearchRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending running completed failed expired"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=10"`
+}
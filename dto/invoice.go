@@ -0,0 +1,134 @@
+package dto
+
+import (
+	"auth-api/models"
+	"time"
+)
+
+type InvoiceLineRequest struct {
+	Description string       `json:"description" binding:"required,max=255"`
+	Quantity    float64      `json:"quantity" binding:"required,gt=0,max=99999999999.9999"` // decimal(15,4)
+	UnitPrice   models.Money `json:"unit_price" binding:"min=0"`
+	TaxRate     *float64     `json:"tax_rate" binding:"omitempty,min=0,max=100"` // persen, kosong = tarif PPN yang berlaku
+}
+
+type InvoiceCreateRequest struct {
+	CustomerID uint                 `json:"customer_id" binding:"required"`
+	DueDate    string               `json:"due_date" binding:"omitempty,datetime=2006-01-02"`
+	Notes      string               `json:"notes" binding:"omitempty,max=1000"`
+	Lines      []InvoiceLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+type InvoiceUpdateRequest struct {
+	DueDate string               `json:"due_date" binding:"omitempty,datetime=2006-01-02"`
+	Notes   *string              `json:"notes" binding:"omitempty,max=1000"`
+	Lines   []InvoiceLineRequest `json:"lines" binding:"omitempty,min=1,dive"`
+}
+
+type InvoiceVoidRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+type InvoiceSearchRequest struct {
+	CustomerID uint   `form:"customer_id"`
+	Status     string `form:"status" binding:"omitempty,oneof=draft issued paid void"`
+	Page       int    `form:"page,default=1"`
+	PageSize   int    `form:"page_size,default=10"`
+}
+
+type InvoiceLineResponse struct {
+	ID          uint         `json:"id"`
+	Description string       `json:"description"`
+	Quantity    float64      `json:"quantity"`
+	UnitPrice   models.Money `json:"unit_price"`
+	Amount      models.Money `json:"amount"`
+	TaxRate     float64      `json:"tax_rate"`
+	TaxAmount   models.Money `json:"tax_amount"`
+}
+
+type InvoiceResponse struct {
+	ID            uint                  `json:"id"`
+	InvoiceNumber *string               `json:"invoice_number"`
+	CustomerID    uint                  `json:"customer_id"`
+	Status        string                `json:"status"`
+	IssueDate     *time.Time            `json:"issue_date"`
+	DueDate       time.Time             `json:"due_date"`
+	Subtotal      models.Money          `json:"subtotal"`
+	TaxTotal      models.Money          `json:"tax_total"`
+	Total         models.Money          `json:"total"`
+	AmountPaid    models.Money          `json:"amount_paid"`
+	AmountDue     models.Money          `json:"amount_due"`
+	Notes         string                `json:"notes"`
+	VoidReason    string                `json:"void_reason,omitempty"`
+	IssuedAt      *time.Time            `json:"issued_at,omitempty"`
+	PaidAt        *time.Time            `json:"paid_at,omitempty"`
+	VoidedAt      *time.Time            `json:"voided_at,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+	Lines         []InvoiceLineResponse `json:"lines,omitempty"`
+	Payments      []InvoicePaymentInfo  `json:"payments,omitempty"`
+}
+
+// InvoicePaymentInfo - Payment yang (sebagian) melunasi invoice
+type InvoicePaymentInfo struct {
+	PaymentID  uint         `json:"payment_id"`
+	Method     string       `json:"method"`
+	Reference  string       `json:"reference"`
+	Amount     models.Money `json:"amount"`
+	ReceivedAt time.Time    `json:"received_at"`
+}
+
+type InvoiceListResponse struct {
+	Invoices  []InvoiceResponse `json:"invoices"`
+	Total     int64             `json:"total"`
+	Page      int               `json:"page"`
+	PageSize  int               `json:"page_size"`
+	TotalPage int               `json:"total_page"`
+}
+
+// TaxRateBps - Konversi persen ke basis poin (11.5 -> 1150), defaultBps jika tarif tidak diisi
+func (r InvoiceLineRequest) TaxRateBps(defaultBps int) int {
+	if r.TaxRate == nil {
+		return defaultBps
+	}
+	return TaxRateToBps(*r.TaxRate)
+}
+
+// Helper function untuk convert model ke response
+func ToInvoiceResponse(invoice models.Invoice) InvoiceResponse {
+	response := InvoiceResponse{
+		ID:            invoice.ID,
+		InvoiceNumber: invoice.InvoiceNumber,
+		CustomerID:    invoice.CustomerID,
+		Status:        invoice.Status,
+		IssueDate:     invoice.IssueDate,
+		DueDate:       invoice.DueDate,
+		Subtotal:      invoice.Subtotal,
+		TaxTotal:      invoice.TaxTotal,
+		Total:         invoice.Total,
+		AmountPaid:    invoice.AmountPaid,
+		Notes:         invoice.Notes,
+		VoidReason:    invoice.VoidReason,
+		IssuedAt:      invoice.IssuedAt,
+		PaidAt:        invoice.PaidAt,
+		VoidedAt:      invoice.VoidedAt,
+		CreatedAt:     invoice.CreatedAt,
+		UpdatedAt:     invoice.UpdatedAt,
+	}
+	if invoice.Status == "issued" {
+		response.AmountDue = invoice.OutstandingAmount()
+	}
+
+	for _, line := range invoice.Lines {
+		response.Lines = append(response.Lines, InvoiceLineResponse{
+			ID:          line.ID,
+			Description: line.Description,
+			Quantity:    line.Quantity,
+			UnitPrice:   line.UnitPrice,
+			Amount:      line.Amount,
+			TaxRate:     float64(line.TaxRateBps) / 100,
+			TaxAmount:   line.TaxAmount,
+		})
+	}
+	return response
+}
@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"auth-api/models"
+	"time"
+)
+
+type PaymentAllocationRequest struct {
+	InvoiceID uint         `json:"invoice_id" binding:"required"`
+	Amount    models.Money `json:"amount" binding:"required,gt=0"`
+}
+
+type PaymentCreateRequest struct {
+	CustomerID   uint                       `json:"customer_id" binding:"required"`
+	Method       string                     `json:"method" binding:"required,oneof=bank_transfer virtual_account cash e_wallet other"`
+	Reference    string                     `json:"reference" binding:"omitempty,max=100"`
+	Amount       models.Money               `json:"amount" binding:"required,gt=0"`
+	ReceivedAt   *time.Time                 `json:"received_at"`
+	Notes        string                     `json:"notes" binding:"omitempty,max=255"`
+	Allocations  []PaymentAllocationRequest `json:"allocations" binding:"omitempty,dive"`
+	AutoAllocate bool                       `json:"auto_allocate"` // alokasi otomatis ke invoice dengan due date paling lama
+}
+
+type PaymentAllocateRequest struct {
+	Allocations  []PaymentAllocationRequest `json:"allocations" binding:"omitempty,dive"`
+	AutoAllocate bool                       `json:"auto_allocate"`
+}
+
+type PaymentSearchRequest struct {
+	CustomerID uint   `form:"customer_id"`
+	Method     string `form:"method"`
+	Reference  string `form:"reference"`
+	Unapplied  bool   `form:"unapplied"` // hanya payment yang masih punya sisa kredit
+	Page       int    `form:"page,default=1"`
+	PageSize   int    `form:"page_size,default=10"`
+}
+
+type PaymentAllocationResponse struct {
+	ID            uint         `json:"id"`
+	InvoiceID     uint         `json:"invoice_id"`
+	InvoiceNumber string       `json:"invoice_number,omitempty"`
+	Amount        models.Money `json:"amount"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+type PaymentResponse struct {
+	ID              uint                        `json:"id"`
+	CustomerID      uint                        `json:"customer_id"`
+	Method          string                      `json:"method"`
+	Reference       string                      `json:"reference"`
+	Amount          models.Money                `json:"amount"`
+	AllocatedAmount models.Money                `json:"allocated_amount"`
+	UnappliedAmount models.Money                `json:"unapplied_amount"`
+	ReceivedAt      time.Time                   `json:"received_at"`
+	Notes           string                      `json:"notes"`
+	JournalEntryID  uint                        `json:"journal_entry_id"`
+	CreatedAt       time.Time                   `json:"created_at"`
+	Allocations     []PaymentAllocationResponse `json:"allocations,omitempty"`
+}
+
+type PaymentListResponse struct {
+	Payments  []PaymentResponse `json:"payments"`
+	Total     int64             `json:"total"`
+	Page      int               `json:"page"`
+	PageSize  int               `json:"page_size"`
+	TotalPage int               `json:"total_page"`
+}
+
+// Helper function untuk convert model ke response, invoiceNumbers opsional (invoice_id -> nomor)
+func ToPaymentResponse(payment models.Payment, invoiceNumbers map[uint]string) PaymentResponse {
+	response := PaymentResponse{
+		ID:              payment.ID,
+		CustomerID:      payment.CustomerID,
+		Method:          payment.Method,
+		Reference:       payment.Reference,
+		Amount:          payment.Amount,
+		AllocatedAmount: payment.Amount - payment.UnappliedAmount,
+		UnappliedAmount: payment.UnappliedAmount,
+		ReceivedAt:      payment.ReceivedAt,
+		Notes:           payment.Notes,
+		JournalEntryID:  payment.JournalEntryID,
+		CreatedAt:       payment.CreatedAt,
+	}
+
+	for _, allocation := range payment.Allocations {
+		response.Allocations = append(response.Allocations, PaymentAllocationResponse{
+			ID:            allocation.ID,
+			InvoiceID:     allocation.InvoiceID,
+			InvoiceNumber: invoiceNumbers[allocation.InvoiceID],
+			Amount:        allocation.Amount,
+			CreatedAt:     allocation.CreatedAt,
+		})
+	}
+	return response
+}
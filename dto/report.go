@@ -0,0 +1,18 @@
+package dto
+
+type ReportRequest struct {
+	From   string `form:"from" binding:"omitempty,datetime=2006-01-02"` // default awal bulan berjalan
+	To     string `form:"to" binding:"omitempty,datetime=2006-01-02"`   // default hari ini
+	Format string `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+type BalanceMovementReportRequest struct {
+	ReportRequest
+	Interval string `form:"interval" binding:"omitempty,oneof=day month"`
+}
+
+type TopCustomersReportRequest struct {
+	ReportRequest
+	Limit int    `form:"limit,default=10"`
+	Order string `form:"order" binding:"omitempty,oneof=desc asc"` // asc = saldo paling negatif dulu
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "auth-api/models"
+
+type PlanCreateRequest struct {
+	Code            string       `json:"code" binding:"required,min=2,max=50"`
+	Name            string       `json:"name" binding:"required,max=100"`
+	Description     string       `json:"description" binding:"omitempty,max=255"`
+	Price           models.Money `json:"price" binding:"min=0"`
+	BillingInterval string       `json:"billing_interval" binding:"omitempty,oneof=monthly yearly"`
+	TaxRate         *float64     `json:"tax_rate" binding:"omitempty,min=0,max=100"` // persen, kosong = tarif PPN yang berlaku
+}
+
+type PlanUpdateRequest struct {
+	Name        string        `json:"name" binding:"omitempty,max=100"`
+	Description *string       `json:"description" binding:"omitempty,max=255"`
+	Price       *models.Money `json:"price" binding:"omitempty,min=0"`
+	TaxRate     *float64      `json:"tax_rate" binding:"omitempty,min=0,max=100"`
+	IsActive    *bool         `json:"is_active"`
+
+	// Hapus tarif khusus, plan kembali mengikuti tarif PPN yang berlaku
+	ClearTaxRate bool `json:"clear_tax_rate"`
+}
+
+type SubscriptionCreateRequest struct {
+	CustomerID    uint   `json:"customer_id" binding:"required"`
+	PlanID        uint   `json:"plan_id" binding:"required"`
+	BillingMethod string `json:"billing_method" binding:"omitempty,oneof=invoice balance"`
+}
+
+type SubscriptionChangePlanRequest struct {
+	PlanID uint `json:"plan_id" binding:"required"`
+}
+
+type SubscriptionCancelRequest struct {
+	Immediate bool `json:"immediate"` // false = berhenti di akhir periode berjalan
+}
+
+type SubscriptionSearchRequest struct {
+	CustomerID uint   `form:"customer_id"`
+	Status     string `form:"status" binding:"omitempty,oneof=active paused cancelled"`
+	Page       int    `form:"page,default=1"`
+	PageSize   int    `form:"page_size,default=10"`
+}
+
+// TaxRateToBps - Konversi persen ke basis poin (11 -> 1100)
+func TaxRateToBps(rate float64) int {
+	return int(rate*100 + 0.5)
+}
@@ -0,0 +1,13 @@
+package dto
+
+type TaxRateCreateRequest struct {
+	Code          string  `json:"code" binding:"omitempty,max=20"` // default PPN
+	Rate          float64 `json:"rate" binding:"min=0,max=100"`    // persen
+	EffectiveFrom string  `json:"effective_from" binding:"required,datetime=2006-01-02"`
+	Description   string  `json:"description" binding:"omitempty,max=255"`
+}
+
+type TaxSummaryRequest struct {
+	From string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To   string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+}
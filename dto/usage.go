@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+// UsageEventRequest divalidasi per event di controller agar satu event invalid tidak menggagalkan seluruh batch
+type UsageEventRequest struct {
+	EventID      string     `json:"event_id"`
+	CustomerCode string     `json:"customer_code"`
+	Metric       string     `json:"metric"`
+	Quantity     float64    `json:"quantity"`
+	Timestamp    *time.Time `json:"timestamp"`
+}
+
+type UsageBatchRequest struct {
+	Events []UsageEventRequest `json:"events" binding:"required,min=1"`
+}
+
+type UsageRejectedEvent struct {
+	Index   int    `json:"index"`
+	EventID string `json:"event_id"`
+	Reason  string `json:"reason"`
+}
+
+type UsageBatchResponse struct {
+	Accepted   int                  `json:"accepted"`
+	Duplicates int                  `json:"duplicates"`
+	Rejected   []UsageRejectedEvent `json:"rejected"`
+}
+
+type UsageQueryRequest struct {
+	CustomerID uint   `form:"customer_id" binding:"required"`
+	From       string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To         string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	Metric     string `form:"metric"`
+	GroupBy    string `form:"group_by,default=day" binding:"omitempty,oneof=hour day month"`
+}
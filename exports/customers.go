@@ -0,0 +1,182 @@
+package exports
+
+import (
+	"auth-api/models"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// batchSize - Jumlah row yang dibaca per query saat export
+const batchSize = 500
+
+// Scope - Requester export. Role menentukan data yang boleh ikut diexport.
+type Scope struct {
+	Role   string `json:"role"`
+	UserID uint   `json:"user_id"`
+}
+
+// CustomerFilter - Filter yang sama dengan list customer (CustomerSearchRequest)
+type CustomerFilter struct {
+	Search    string `json:"search,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+	SortBy    string `json:"sort_by,omitempty"`
+	SortOrder string `json:"sort_order,omitempty"`
+}
+
+// FilterCustomers - Role-based access control serta filter search dan status
+func FilterCustomers(query *gorm.DB, scope Scope, filter CustomerFilter) *gorm.DB {
+	if scope.Role == "customer" {
+		// Customer hanya bisa melihat data miliknya sendiri
+		query = query.Where("user_id = ?", scope.UserID)
+	} else if scope.Role == "finance" {
+		// Finance bisa melihat semua kecuali yang di-terminated
+		query = query.Where("status != ?", "terminated")
+	}
+	// Admin bisa melihat semua
+
+	// Apply search filter
+	if filter.Search != "" {
+		search := "%" + strings.ToLower(filter.Search) + "%"
+		query = query.Where("LOWER(customer_code) LIKE ? OR LOWER(company_name) LIKE ? OR LOWER(contact_name) LIKE ? OR LOWER(email) LIKE ?",
+			search, search, search, search)
+	}
+
+	// Apply status filter
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	// Apply tag filter
+	if filter.Tag != "" {
+		query = query.Where("id IN (?)", query.Session(&gorm.Session{NewDB: true}).
+			Model(&models.CustomerTag{}).Select("customer_id").Where("tag = ?", strings.ToLower(strings.TrimSpace(filter.Tag))))
+	}
+	return query
+}
+
+// CustomerSortOrder - ORDER BY dari sort_by/sort_order, dengan id sebagai tie-breaker agar urutan stabil antar halaman
+func CustomerSortOrder(filter CustomerFilter) string {
+	sortOrder := "DESC"
+	if filter.SortOrder == "asc" {
+		sortOrder = "ASC"
+	}
+
+	validSortFields := map[string]bool{
+		"customer_code": true,
+		"company_name":  true,
+		"balance":       true,
+		"created_at":    true,
+		"updated_at":    true,
+	}
+
+	sortField := "created_at"
+	if validSortFields[filter.SortBy] {
+		sortField = filter.SortBy
+	}
+
+	return fmt.Sprintf("%s %s, id %s", sortField, sortOrder, sortOrder)
+}
+
+// CustomerColumn - Kolom export customer beserta cara mengambil nilainya
+type CustomerColumn struct {
+	Column
+	value func(customer models.Customer) interface{}
+}
+
+// customerColumns - Semua kolom yang bisa dipilih lewat ?columns=
+var customerColumns = []CustomerColumn{
+	{Column{"id", "ID"}, func(cu models.Customer) interface{} { return cu.ID }},
+	{Column{"customer_code", "Customer Code"}, func(cu models.Customer) interface{} { return cu.CustomerCode }},
+	{Column{"company_name", "Company Name"}, func(cu models.Customer) interface{} { return cu.CompanyName }},
+	{Column{"contact_name", "Contact Name"}, func(cu models.Customer) interface{} { return cu.ContactName }},
+	{Column{"email", "Email"}, func(cu models.Customer) interface{} { return cu.Email }},
+	{Column{"phone", "Phone"}, func(cu models.Customer) interface{} { return cu.Phone }},
+	{Column{"address", "Address"}, func(cu models.Customer) interface{} { return cu.Address }},
+	{Column{"npwp", "NPWP"}, func(cu models.Customer) interface{} { return cu.NPWP }},
+	{Column{"balance", "Balance"}, func(cu models.Customer) interface{} { return cu.Balance }},
+	{Column{"credit_limit", "Credit Limit"}, func(cu models.Customer) interface{} { return cu.CreditLimit }},
+	{Column{"status", "Status"}, func(cu models.Customer) interface{} { return cu.Status }},
+	{Column{"created_by", "Created By"}, func(cu models.Customer) interface{} { return cu.User.Name }},
+	{Column{"created_at", "Created At"}, func(cu models.Customer) interface{} { return cu.CreatedAt }},
+	{Column{"updated_at", "Updated At"}, func(cu models.Customer) interface{} { return cu.UpdatedAt }},
+}
+
+// defaultCustomerColumns - Kolom yang dipakai jika ?columns= kosong (sama dengan format export lama)
+const defaultCustomerColumns = "id,customer_code,company_name,contact_name,email,phone,address,npwp,balance,status,created_by,created_at"
+
+// CustomerColumnKeys - Daftar kolom yang valid, untuk pesan error
+func CustomerColumnKeys() []string {
+	keys := make([]string, 0, len(customerColumns))
+	for _, column := range customerColumns {
+		keys = append(keys, column.Key)
+	}
+	return keys
+}
+
+// ParseCustomerColumns - Parse ?columns= (dipisah koma) sesuai urutan yang diminta
+func ParseCustomerColumns(param string) ([]CustomerColumn, error) {
+	if strings.TrimSpace(param) == "" {
+		param = defaultCustomerColumns
+	}
+
+	var columns []CustomerColumn
+	for _, key := range strings.Split(param, ",") {
+		key = strings.TrimSpace(key)
+		found := false
+		for _, column := range customerColumns {
+			if column.Key == key {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w %q", ErrUnknownColumn, key)
+		}
+	}
+	return columns, nil
+}
+
+// WriteCustomers - Tulis customer sesuai scope dan filter, dibaca per batch dengan urutan yang sama seperti list customer
+func WriteCustomers(db *gorm.DB, w Writer, scope Scope, filter CustomerFilter, columns []CustomerColumn) (int64, error) {
+	header := make([]Column, len(columns))
+	for i, column := range columns {
+		header[i] = column.Column
+	}
+	if err := w.WriteHeader(header); err != nil {
+		return 0, err
+	}
+
+	query := FilterCustomers(db.Model(&models.Customer{}), scope, filter).Order(CustomerSortOrder(filter))
+
+	var rows int64
+	values := make([]interface{}, len(columns))
+	for offset := 0; ; offset += batchSize {
+		var customers []models.Customer
+		err := query.Session(&gorm.Session{}).Preload("User").
+			Offset(offset).Limit(batchSize).Find(&customers).Error
+		if err != nil {
+			return rows, err
+		}
+
+		for _, customer := range customers {
+			for i, column := range columns {
+				values[i] = column.value(customer)
+			}
+			if err := w.WriteRow(values); err != nil {
+				return rows, err
+			}
+			rows++
+		}
+		if err := w.Flush(); err != nil {
+			return rows, err
+		}
+
+		if len(customers) < batchSize {
+			return rows, w.Close()
+		}
+	}
+}
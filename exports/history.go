@@ -0,0 +1,122 @@
+package exports
+
+import (
+	"auth-api/models"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// HistoryFilter - Filter export history customer. From/To berformat 2006-01-02 (inklusif).
+type HistoryFilter struct {
+	CustomerID uint   `json:"customer_id,omitempty"`
+	Action     string `json:"action,omitempty"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+}
+
+var historyColumns = []Column{
+	{"id", "ID"},
+	{"customer_id", "Customer ID"},
+	{"customer_code", "Customer Code"},
+	{"action", "Action"},
+	{"changes", "Changes"},
+	{"changed_by", "Changed By"},
+	{"changed_by_id", "Changed By ID"},
+	{"created_at", "Created At"},
+}
+
+// FilterHistory - History hanya untuk customer yang boleh dilihat requester
+func FilterHistory(db *gorm.DB, scope Scope, filter HistoryFilter) *gorm.DB {
+	query := db.Model(&models.CustomerHistory{})
+	if scope.Role != "admin" {
+		visible := FilterCustomers(db.Model(&models.Customer{}).Select("id"), scope, CustomerFilter{})
+		query = query.Where("customer_id IN (?)", visible)
+	}
+
+	if filter.CustomerID > 0 {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if from, err := time.ParseInLocation("2006-01-02", filter.From, time.Local); err == nil {
+		query = query.Where("created_at >= ?", from)
+	}
+	if to, err := time.ParseInLocation("2006-01-02", filter.To, time.Local); err == nil {
+		query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+	}
+	return query
+}
+
+// WriteHistory - Tulis history customer urut kronologis, dibaca per batch
+func WriteHistory(db *gorm.DB, w Writer, scope Scope, filter HistoryFilter) (int64, error) {
+	if err := w.WriteHeader(historyColumns); err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	var history []models.CustomerHistory
+	result := FilterHistory(db, scope, filter).FindInBatches(&history, batchSize, func(tx *gorm.DB, batch int) error {
+		codes, names := historyLookups(db, history)
+
+		values := make([]interface{}, len(historyColumns))
+		for _, h := range history {
+			// ChangedBy 0 = perubahan otomatis oleh sistem (mis. auto-suspend)
+			changedBy := "system"
+			if h.ChangedBy != 0 {
+				changedBy = names[h.ChangedBy]
+			}
+
+			values[0] = h.ID
+			values[1] = h.CustomerID
+			values[2] = codes[h.CustomerID]
+			values[3] = h.Action
+			values[4] = json.RawMessage(h.Changes)
+			values[5] = changedBy
+			values[6] = h.ChangedBy
+			values[7] = h.CreatedAt
+			if h.Changes == "" {
+				values[4] = nil
+			}
+			if err := w.WriteRow(values); err != nil {
+				return err
+			}
+			rows++
+		}
+		return w.Flush()
+	})
+	if result.Error != nil {
+		return rows, result.Error
+	}
+	return rows, w.Close()
+}
+
+// historyLookups - Kode customer dan nama user untuk satu batch history
+func historyLookups(db *gorm.DB, history []models.CustomerHistory) (map[uint]string, map[uint]string) {
+	var customerIDs, userIDs []uint
+	for _, h := range history {
+		customerIDs = append(customerIDs, h.CustomerID)
+		if h.ChangedBy != 0 {
+			userIDs = append(userIDs, h.ChangedBy)
+		}
+	}
+
+	codes := make(map[uint]string)
+	var customers []models.Customer
+	db.Select("id", "customer_code").Where("id IN ?", customerIDs).Find(&customers)
+	for _, customer := range customers {
+		codes[customer.ID] = customer.CustomerCode
+	}
+
+	names := make(map[uint]string)
+	if len(userIDs) > 0 {
+		var users []models.User
+		db.Select("id", "name").Where("id IN ?", userIDs).Find(&users)
+		for _, user := range users {
+			names[user.ID] = user.Name
+		}
+	}
+	return codes, names
+}
@@ -0,0 +1,175 @@
+package exports
+
+import (
+	"auth-api/config"
+	"auth-api/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Jenis export asinkron
+const (
+	JobTypeCustomers = "customers"
+	JobTypeHistory   = "customer_history"
+)
+
+// JobFilters - Isi kolom Filters pada ExportJob. Field yang dipakai tergantung Type.
+type JobFilters struct {
+	CustomerFilter
+	HistoryFilter
+}
+
+// RunPendingJobs - Jalankan job pending secara berurutan (FIFO)
+func RunPendingJobs(cfg *config.Config, db *gorm.DB) {
+	var jobs []models.ExportJob
+	if err := db.Where("status = ?", "pending").Order("id ASC").Limit(10).Find(&jobs).Error; err != nil {
+		log.Printf("⚠️ Export: failed to fetch pending jobs: %v", err)
+		return
+	}
+
+	for i := range jobs {
+		if err := RunJob(cfg, db, &jobs[i]); err != nil {
+			log.Printf("⚠️ Export job %d failed: %v", jobs[i].ID, err)
+		}
+	}
+}
+
+// RunJob - Klaim job lalu tulis filenya ke direktori export. Kegagalan dicatat di job.
+func RunJob(cfg *config.Config, db *gorm.DB, job *models.ExportJob) error {
+	// Klaim dulu agar job tidak dijalankan dua kali oleh instance lain
+	now := time.Now()
+	result := db.Model(&models.ExportJob{}).
+		Where("id = ? AND status = ?", job.ID, "pending").
+		Updates(map[string]interface{}{"status": "running", "started_at": now, "updated_at": now})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+	job.Status = "running"
+	job.StartedAt = &now
+
+	rows, path, err := writeJobFile(cfg, db, job)
+	if err != nil {
+		message := err.Error()
+		if len(message) > 500 {
+			message = message[:500]
+		}
+		db.Model(&models.ExportJob{}).Where("id = ? AND status = ?", job.ID, "running").
+			Updates(map[string]interface{}{"status": "failed", "error": message})
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// Job yang sudah ditandai gagal oleh CleanupJobs (dianggap macet) tidak dihidupkan lagi
+	completedAt := time.Now()
+	expiresAt := completedAt.Add(cfg.Export.TTL)
+	result = db.Model(&models.ExportJob{}).
+		Where("id = ? AND status = ?", job.ID, "running").
+		Updates(map[string]interface{}{
+			"status":       "completed",
+			"file_path":    path,
+			"file_size":    info.Size(),
+			"row_count":    rows,
+			"completed_at": completedAt,
+			"expires_at":   expiresAt,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		// File tidak tercatat di job manapun, hapus agar tidak tertinggal di direktori export
+		os.Remove(path)
+		if result.Error != nil {
+			return result.Error
+		}
+		return fmt.Errorf("export job %d is no longer running", job.ID)
+	}
+	return nil
+}
+
+// writeJobFile - File ditulis dengan nama sementara lalu di-rename agar tidak pernah terbaca setengah jadi
+func writeJobFile(cfg *config.Config, db *gorm.DB, job *models.ExportJob) (int64, string, error) {
+	var filters JobFilters
+	if job.Filters != "" {
+		if err := json.Unmarshal([]byte(job.Filters), &filters); err != nil {
+			return 0, "", err
+		}
+	}
+	scope := Scope{Role: job.RequestedRole, UserID: job.RequestedBy}
+
+	if err := os.MkdirAll(cfg.Export.Dir, 0750); err != nil {
+		return 0, "", err
+	}
+	path := filepath.Join(cfg.Export.Dir, fmt.Sprintf("export_%d.%s", job.ID, job.Format))
+	tmpPath := path + ".tmp"
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return 0, "", err
+	}
+	defer os.Remove(tmpPath)
+
+	rows, err := writeJob(db, file, job, scope, filters)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return rows, "", err
+	}
+	return rows, path, os.Rename(tmpPath, path)
+}
+
+func writeJob(db *gorm.DB, file *os.File, job *models.ExportJob, scope Scope, filters JobFilters) (int64, error) {
+	w, err := NewWriter(job.Format, file, job.BOM)
+	if err != nil {
+		return 0, err
+	}
+
+	switch job.Type {
+	case JobTypeCustomers:
+		columns, err := ParseCustomerColumns(job.Columns)
+		if err != nil {
+			return 0, err
+		}
+		return WriteCustomers(db, w, scope, filters.CustomerFilter, columns)
+	case JobTypeHistory:
+		return WriteHistory(db, w, scope, filters.HistoryFilter)
+	}
+	return 0, fmt.Errorf("unknown export type %q", job.Type)
+}
+
+// CleanupJobs - Hapus file export yang sudah lewat masa berlaku, dan tandai gagal job yang macet
+// karena worker berhenti di tengah jalan
+func CleanupJobs(cfg *config.Config, db *gorm.DB, now time.Time) (int64, error) {
+	err := db.Model(&models.ExportJob{}).
+		Where("status = ? AND started_at < ?", "running", now.Add(-cfg.Export.StaleAfter)).
+		Updates(map[string]interface{}{"status": "failed", "error": "export worker stopped before the job finished", "updated_at": now}).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var jobs []models.ExportJob
+	if err := db.Where("status = ? AND expires_at <= ?", "completed", now).Find(&jobs).Error; err != nil {
+		return 0, err
+	}
+
+	var expired int64
+	for _, job := range jobs {
+		if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+			log.Printf("⚠️ Export job %d: failed to remove file: %v", job.ID, err)
+			continue
+		}
+		err := db.Model(&models.ExportJob{}).Where("id = ? AND status = ?", job.ID, "completed").
+			Updates(map[string]interface{}{"status": "expired", "file_path": ""}).Error
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
@@ -0,0 +1,149 @@
+package exports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	ErrImportTooManyRows = errors.New("import file exceeds the maximum number of rows")
+	ErrImportEmpty       = errors.New("import file has no header row")
+)
+
+// FormatFromFileName - Format import dari ekstensi file (.csv / .xlsx)
+func FormatFromFileName(name string) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, filepath.Ext(name))
+}
+
+// Record - Satu baris data import. Line adalah nomor baris di file (header = baris 1).
+type Record struct {
+	Line   int
+	Values []string
+}
+
+// ReadRows - Baca file import (sheet pertama untuk XLSX). Baris pertama adalah header,
+// baris kosong dilewati tapi tetap dihitung agar nomor baris sama dengan di file.
+func ReadRows(format string, r io.Reader, maxRows int) ([]string, []Record, error) {
+	var records []Record
+	add := func(line int, values []string) error {
+		if blankRecord(values) {
+			return nil
+		}
+		if len(records) >= maxRows {
+			return ErrImportTooManyRows
+		}
+		records = append(records, Record{Line: line, Values: values})
+		return nil
+	}
+
+	var header []string
+	var err error
+	switch format {
+	case FormatCSV:
+		header, err = readCSV(r, add)
+	case FormatXLSX:
+		header, err = readXLSX(r, add)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return header, records, nil
+}
+
+// NormalizeHeader - "Customer Code", "customer-code" dan "customer_code" dianggap sama
+func NormalizeHeader(header string) string {
+	header = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, "\uFEFF")))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(header)
+}
+
+func readCSV(r io.Reader, add func(line int, values []string) error) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrImportEmpty
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return header, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// csv.Reader melewati baris kosong, jadi nomor baris diambil dari posisi field
+		line, _ := reader.FieldPos(0)
+		if err := add(line, record); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readXLSX(r io.Reader, add func(line int, values []string) error) ([]string, error) {
+	file, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, ErrImportEmpty
+	}
+	iter, err := file.Rows(sheets[0])
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+
+	var header []string
+	for line := 1; iter.Next(); line++ {
+		record, err := iter.Columns()
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 {
+			header = record
+			continue
+		}
+		if err := add(line, record); err != nil {
+			return nil, err
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	if len(header) == 0 {
+		return nil, ErrImportEmpty
+	}
+	return header, nil
+}
+
+func blankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,266 @@
+package exports
+
+import (
+	"auth-api/models"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format file export yang didukung
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatNDJSON = "ndjson"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown export format")
+	ErrUnknownColumn = errors.New("unknown export column")
+	ErrTooManyRows   = errors.New("export exceeds the maximum number of rows for xlsx, use csv or ndjson")
+)
+
+// Column - Satu kolom export: Key dipakai di ?columns= dan NDJSON, Header di CSV/XLSX
+type Column struct {
+	Key    string
+	Header string
+}
+
+// Writer - Penulis file export. Row ditulis bertahap agar export besar tidak ditampung di memori.
+type Writer interface {
+	WriteHeader(columns []Column) error
+	WriteRow(values []interface{}) error
+	Flush() error // kirim row yang sudah ditulis ke output (no-op untuk XLSX)
+	Close() error // selesaikan file
+}
+
+// NewWriter - Writer sesuai format. BOM hanya berlaku untuk CSV (agar Excel membaca UTF-8).
+func NewWriter(format string, out io.Writer, bom bool) (Writer, error) {
+	switch format {
+	case FormatCSV, "":
+		if bom {
+			if _, err := io.WriteString(out, "\uFEFF"); err != nil {
+				return nil, err
+			}
+		}
+		return &csvWriter{w: csv.NewWriter(out)}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{w: bufio.NewWriter(out)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(out)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+// ContentType - MIME type untuk response download
+func ContentType(format string) string {
+	switch format {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FileName - Nama file dengan ekstensi sesuai format
+func FileName(base, format string) string {
+	if format == "" {
+		format = FormatCSV
+	}
+	return base + "." + format
+}
+
+// textValue - Representasi teks untuk CSV
+func textValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case models.Money:
+		return value.String()
+	case time.Time:
+		return value.Format("2006-01-02 15:04:05")
+	case *time.Time:
+		if value == nil {
+			return ""
+		}
+		return value.Format("2006-01-02 15:04:05")
+	case json.RawMessage:
+		return string(value)
+	case uint:
+		return strconv.FormatUint(uint64(value), 10)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	return fmt.Sprint(v)
+}
+
+// EscapeCSVCell - Awali teks yang bisa dibaca sebagai formula oleh spreadsheet (=, +, -, @, tab, CR)
+// dengan ' agar tidak dieksekusi saat file dibuka. Angka seperti "-10.00" dibiarkan.
+func EscapeCSVCell(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (cw *csvWriter) WriteHeader(columns []Column) error {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Header
+	}
+	return cw.w.Write(header)
+}
+
+func (cw *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = textValue(value)
+		switch value.(type) {
+		case string, json.RawMessage:
+			record[i] = EscapeCSVCell(record[i])
+		}
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	return cw.Flush()
+}
+
+// ndjsonWriter - Satu object JSON per baris dengan key sesuai urutan kolom
+type ndjsonWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+func (nw *ndjsonWriter) WriteHeader(columns []Column) error {
+	nw.keys = make([][]byte, len(columns))
+	for i, column := range columns {
+		key, err := json.Marshal(column.Key)
+		if err != nil {
+			return err
+		}
+		nw.keys[i] = key
+	}
+	return nil
+}
+
+func (nw *ndjsonWriter) WriteRow(values []interface{}) error {
+	nw.w.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			nw.w.WriteByte(',')
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		nw.w.Write(nw.keys[i])
+		nw.w.WriteByte(':')
+		nw.w.Write(encoded)
+	}
+	_, err := nw.w.WriteString("}\n")
+	return err
+}
+
+func (nw *ndjsonWriter) Flush() error {
+	return nw.w.Flush()
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nw.w.Flush()
+}
+
+// xlsxWriter - StreamWriter excelize menyimpan row di file sementara, workbook ditulis saat Close
+type xlsxWriter struct {
+	out  io.Writer
+	file *excelize.File
+	sw   *excelize.StreamWriter
+	row  int
+}
+
+func newXLSXWriter(out io.Writer) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	sw, err := file.NewStreamWriter("Sheet1")
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxWriter{out: out, file: file, sw: sw}, nil
+}
+
+func (xw *xlsxWriter) WriteHeader(columns []Column) error {
+	style, err := xw.file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = excelize.Cell{StyleID: style, Value: column.Header}
+	}
+	return xw.writeCells(header)
+}
+
+func (xw *xlsxWriter) WriteRow(values []interface{}) error {
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case models.Money:
+			cells[i] = v.Float64()
+		case time.Time, *time.Time, json.RawMessage:
+			cells[i] = textValue(v)
+		default:
+			cells[i] = v
+		}
+	}
+	return xw.writeCells(cells)
+}
+
+func (xw *xlsxWriter) writeCells(cells []interface{}) error {
+	xw.row++
+	if xw.row > excelize.TotalRows {
+		return ErrTooManyRows
+	}
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	return xw.sw.SetRow(cell, cells)
+}
+
+func (xw *xlsxWriter) Flush() error {
+	return nil
+}
+
+func (xw *xlsxWriter) Close() error {
+	defer xw.file.Close()
+	if err := xw.sw.Flush(); err != nil {
+		return err
+	}
+	return xw.file.Write(xw.out)
+}
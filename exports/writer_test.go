@@ -0,0 +1,53 @@
+package exports
+
+import (
+	"auth-api/models"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestEscapeCSVCell(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"PT Maju Jaya", "PT Maju Jaya"},
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1+cmd|' /C calc'!A0", "'+1+cmd|' /C calc'!A0"},
+		{"-cmd|' /C calc'!A0", "'-cmd|' /C calc'!A0"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1+1", "'\t=1+1"},
+		{"\r=1+1", "'\r=1+1"},
+		{"-10.00", "-10.00"},
+		{"+62812345678", "+62812345678"},
+		{"a=b", "a=b"},
+	}
+	for _, tt := range tests {
+		if got := EscapeCSVCell(tt.in); got != tt.want {
+			t.Errorf("EscapeCSVCell(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCSVWriterEscapesTextOnly(t *testing.T) {
+	var out bytes.Buffer
+	w, err := NewWriter(FormatCSV, &out, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteHeader([]Column{{"code", "Code"}, {"balance", "Balance"}, {"changes", "Changes"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteRow([]interface{}{"=1+1", models.Money(-1050), json.RawMessage(`@x`)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "Code,Balance,Changes\n'=1+1,-10.50,'@x\n"; out.String() != want {
+		t.Errorf("csv output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,134 @@
+package jobs
+
+import (
+	"auth-api/config"
+	"auth-api/database"
+	"auth-api/models"
+	"auth-api/utils"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartAccountPurgeWorker - Menjalankan anonimisasi akun yang grace period-nya sudah lewat
+func StartAccountPurgeWorker(cfg *config.Config, db *gorm.DB) {
+	go runEvery(cfg.Privacy.PurgeInterval, "account_purge", func() {
+		purgeScheduledAccounts(cfg, db)
+	})
+}
+
+func purgeScheduledAccounts(cfg *config.Config, db *gorm.DB) {
+	var users []models.User
+	err := db.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ? AND anonymized_at IS NULL", time.Now()).
+		Find(&users).Error
+	if err != nil {
+		log.Printf("⚠️ Account purge: failed to fetch users: %v", err)
+		return
+	}
+
+	for _, user := range users {
+		if err := anonymizeUser(cfg, db, user); err != nil {
+			log.Printf("⚠️ Account purge: failed to anonymize user %d: %v", user.ID, err)
+			continue
+		}
+		log.Printf("🗑️ Account purge: user %d anonymized", user.ID)
+	}
+}
+
+// anonymizeUser - Hapus PII user tanpa menghapus row-nya, sehingga CustomerHistory
+// yang mereferensikan changed_by tetap utuh.
+func anonymizeUser(cfg *config.Config, db *gorm.DB, user models.User) error {
+	oldEmail := user.Email
+
+	// Password acak agar akun tidak bisa dipakai login lagi
+	randomPassword, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var customers []models.Customer
+		if err := tx.Where("user_id = ?", user.ID).Find(&customers).Error; err != nil {
+			return err
+		}
+
+		for _, customer := range customers {
+			oldValues := map[string]interface{}{}
+			newValues := map[string]interface{}{}
+
+			if cfg.Privacy.SuccessorUserID > 0 && cfg.Privacy.SuccessorUserID != user.ID {
+				oldValues["user_id"] = customer.UserID
+				newValues["user_id"] = cfg.Privacy.SuccessorUserID
+				customer.UserID = cfg.Privacy.SuccessorUserID
+			}
+
+			// Customer milik user role customer berisi data kontak pribadi
+			if user.Role == "customer" {
+				oldValues["status"] = customer.Status
+				newValues["status"] = "terminated"
+				customer.Status = "terminated"
+				customer.ContactName = ""
+				customer.Email = ""
+				customer.Phone = ""
+				newValues["contact"] = "anonymized"
+			}
+
+			if len(newValues) == 0 {
+				continue
+			}
+
+			if err := tx.Save(&customer).Error; err != nil {
+				return err
+			}
+
+			history := models.CustomerHistory{
+				CustomerID: customer.ID,
+				Action:     "update",
+				Changes:    utils.ToJSON(map[string]interface{}{"old": oldValues, "new": newValues, "reason": "account_deletion"}),
+				ChangedBy:  user.ID,
+				CreatedAt:  time.Now(),
+			}
+			if err := tx.Create(&history).Error; err != nil {
+				return err
+			}
+		}
+
+		// Login history dan device menyimpan IP, lokasi dan user agent
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.KnownDevice{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? OR email = ?", user.ID, oldEmail).Delete(&models.LoginHistory{}).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"name":          "Deleted User",
+			"email":         fmt.Sprintf("deleted-%d@deleted.invalid", user.ID),
+			"password":      hashedPassword,
+			"status":        "inactive",
+			"is_verified":   false,
+			"last_login":    nil,
+			"customer_id":   nil,
+			"anonymized_at": now,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := database.ClearEmailState(oldEmail); err != nil {
+		log.Printf("⚠️ Account purge: failed to clear Redis state for user %d: %v", user.ID, err)
+	}
+	if err := database.RevokeUserSessions(user.ID, cfg.JWT.Expiry); err != nil {
+		log.Printf("⚠️ Account purge: failed to revoke sessions for user %d: %v", user.ID, err)
+	}
+
+	return nil
+}
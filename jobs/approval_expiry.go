@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartApprovalExpiryWorker - Request approval yang tidak di-review sampai batas waktu ditandai expired
+func StartApprovalExpiryWorker(cfg *config.Config, db *gorm.DB) {
+	go runEvery(cfg.Approval.ExpireInterval, "approval_expiry", func() {
+		expired, err := billing.ExpireApprovals(db, time.Now())
+		if err != nil {
+			log.Printf("⚠️ Approval expiry: %v", err)
+			return
+		}
+		if expired > 0 {
+			log.Printf("⌛ Approval expiry: %d request(s) expired", expired)
+		}
+	})
+}
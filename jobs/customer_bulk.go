@@ -0,0 +1,15 @@
+package jobs
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+
+	"gorm.io/gorm"
+)
+
+// StartCustomerBulkWorker - Proses operasi massal customer
+func StartCustomerBulkWorker(cfg *config.Config, db *gorm.DB) {
+	go runEvery(cfg.Bulk.PollInterval, "customer_bulk", func() {
+		billing.RunPendingBulkJobs(cfg, db)
+	})
+}
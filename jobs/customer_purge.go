@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartCustomerPurgeWorker - Anonimkan customer yang sudah dihapus lebih lama dari masa retensi
+func StartCustomerPurgeWorker(cfg *config.Config, db *gorm.DB) {
+	go runEvery(cfg.Privacy.PurgeInterval, "customer_purge", func() {
+		purgeDeletedCustomers(cfg, db)
+	})
+}
+
+func purgeDeletedCustomers(cfg *config.Config, db *gorm.DB) {
+	now := time.Now()
+
+	var customers []models.Customer
+	err := db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at <= ? AND purged_at IS NULL", now.Add(-cfg.Privacy.CustomerRetention)).
+		Find(&customers).Error
+	if err != nil {
+		log.Printf("⚠️ Customer purge: failed to fetch customers: %v", err)
+		return
+	}
+
+	for i := range customers {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return billing.PurgeCustomer(tx, &customers[i], now)
+		})
+		if err != nil {
+			log.Printf("⚠️ Customer purge: failed to purge customer %d: %v", customers[i].ID, err)
+			continue
+		}
+		log.Printf("🗑️ Customer purge: customer %d anonymized", customers[i].ID)
+	}
+}
@@ -0,0 +1,56 @@
+package jobs
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartDunningWorker - Jalankan jadwal dunning untuk customer yang menunggak
+func StartDunningWorker(cfg *config.Config, db *gorm.DB) {
+	if len(cfg.Dunning.Steps) == 0 {
+		return
+	}
+	go runEvery(cfg.Dunning.Interval, "dunning", func() {
+		runDunning(cfg, db)
+	})
+}
+
+func runDunning(cfg *config.Config, db *gorm.DB) {
+	now := time.Now()
+
+	// Kandidat: customer dengan invoice lewat jatuh tempo atau saldo di bawah credit limit,
+	// ditambah customer yang masih punya state dunning agar jadwalnya bisa di-reset setelah lunas
+	overdue := db.Model(&models.Invoice{}).Select("customer_id").
+		Where("status = ? AND due_date < ?", "issued", now.Format("2006-01-02"))
+	tracked := db.Model(&models.DunningState{}).Select("customer_id")
+	var ids []uint
+	err := db.Model(&models.Customer{}).
+		Where("(status <> ? AND (balance < -credit_limit OR id IN (?))) OR id IN (?)", "terminated", overdue, tracked).
+		Pluck("id", &ids).Error
+	if err != nil {
+		log.Printf("⚠️ Dunning: failed to fetch customers: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		var notice *billing.DunningNotice
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			notice, err = billing.RunDunning(tx, cfg, id, now)
+			return err
+		})
+		if err != nil {
+			log.Printf("⚠️ Dunning: customer %d: %v", id, err)
+			continue
+		}
+		if notice != nil {
+			log.Printf("📨 Dunning: customer %d reached %s (%d days overdue)", id, notice.Step.Name, notice.DaysOverdue)
+			billing.SendDunningNotice(cfg, notice)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"auth-api/config"
+	"auth-api/exports"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartExportWorker - Proses export asinkron dan hapus file yang sudah expired
+func StartExportWorker(cfg *config.Config, db *gorm.DB) {
+	go runEvery(cfg.Export.PollInterval, "export_jobs", func() {
+		exports.RunPendingJobs(cfg, db)
+	})
+	go runEvery(cfg.Export.CleanupInterval, "export_cleanup", func() {
+		expired, err := exports.CleanupJobs(cfg, db, time.Now())
+		if err != nil {
+			log.Printf("⚠️ Export cleanup: %v", err)
+			return
+		}
+		if expired > 0 {
+			log.Printf("🧹 Export cleanup: %d file(s) expired", expired)
+		}
+	})
+}
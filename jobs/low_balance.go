@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/models"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// StartLowBalanceWorker - Notifikasi saldo rendah untuk perubahan saldo dari jalur mana pun
+// (subscription, payment, adjustment), bukan hanya dari PATCH balance
+func StartLowBalanceWorker(cfg *config.Config, db *gorm.DB) {
+	go runEvery(cfg.Credit.CheckInterval, "low_balance_alerts", func() {
+		checkLowBalances(cfg, db)
+	})
+}
+
+func checkLowBalances(cfg *config.Config, db *gorm.DB) {
+	var ids []uint
+	err := db.Model(&models.Customer{}).
+		Where("(low_balance_threshold IS NOT NULL AND balance < low_balance_threshold AND low_balance_alerted_at IS NULL) OR "+
+			"(low_balance_alerted_at IS NOT NULL AND (low_balance_threshold IS NULL OR balance >= low_balance_threshold))").
+		Pluck("id", &ids).Error
+	if err != nil {
+		log.Printf("⚠️ Low balance alerts: failed to fetch customers: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		if err := billing.CheckLowBalance(cfg, db, id); err != nil {
+			log.Printf("⚠️ Low balance alerts: customer %d: %v", id, err)
+		}
+	}
+}
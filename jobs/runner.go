@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"auth-api/database"
+	"log"
+	"time"
+)
+
+// runEvery - Menjalankan fn secara periodik. Redis lock memastikan hanya satu
+// instance yang mengeksekusi fn pada satu waktu.
+func runEvery(interval time.Duration, name string, fn func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runLocked(interval, name, fn)
+		<-ticker.C
+	}
+}
+
+func runLocked(ttl time.Duration, name string, fn func()) {
+	token, err := database.AcquireLock(name, ttl)
+	if err != nil {
+		log.Printf("⚠️ Job %s: failed to acquire lock: %v", name, err)
+		return
+	}
+	if token == "" {
+		return
+	}
+	defer database.ReleaseLock(name, token)
+
+	fn()
+}
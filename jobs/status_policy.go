@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/models"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartStatusPolicyWorker - Auto-suspend customer yang menunggak dan aktifkan kembali setelah dilunasi
+func StartStatusPolicyWorker(cfg *config.Config, db *gorm.DB) {
+	if !cfg.Lifecycle.AutoSuspend {
+		return
+	}
+	go runEvery(cfg.Lifecycle.PolicyInterval, "status_policy", func() {
+		applyStatusPolicy(cfg, db)
+	})
+}
+
+func applyStatusPolicy(cfg *config.Config, db *gorm.DB) {
+	now := time.Now()
+
+	// Kandidat: customer aktif yang kemungkinan melanggar, dan customer yang disuspend oleh policy
+	overdue := db.Model(&models.Invoice{}).Select("customer_id").
+		Where("status = ? AND due_date < ?", "issued", now.AddDate(0, 0, -cfg.Lifecycle.OverdueDays))
+	var ids []uint
+	err := db.Model(&models.Customer{}).
+		Where("(status = ? AND (balance < -(credit_limit + ?) OR id IN (?))) OR (status = ? AND auto_suspended = ?)",
+			"active", models.Money(cfg.Lifecycle.NegativeBalanceGrace), overdue, "suspended", true).
+		Pluck("id", &ids).Error
+	if err != nil {
+		log.Printf("⚠️ Status policy: failed to fetch customers: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		var status string
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			status, err = billing.EvaluateCustomerStatus(tx, cfg, id, now)
+			return err
+		})
+		if err != nil {
+			log.Printf("⚠️ Status policy: customer %d: %v", id, err)
+			continue
+		}
+		if status != "" {
+			log.Printf("🔁 Status policy: customer %d is now %s", id, status)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/models"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartSubscriptionBillingWorker - Menagih subscription yang periodenya sudah berakhir
+func StartSubscriptionBillingWorker(cfg *config.Config, db *gorm.DB) {
+	go runEvery(cfg.Billing.SubscriptionInterval, "subscription_billing", func() {
+		billDueSubscriptions(cfg, db)
+	})
+}
+
+func billDueSubscriptions(cfg *config.Config, db *gorm.DB) {
+	now := time.Now()
+
+	var ids []uint
+	err := db.Model(&models.Subscription{}).
+		Joins("JOIN customers ON customers.id = subscriptions.customer_id AND customers.deleted_at IS NULL").
+		Where("subscriptions.status = ? AND subscriptions.current_period_end <= ? AND customers.status = ?", "active", now, "active").
+		Pluck("subscriptions.id", &ids).Error
+	if err != nil {
+		log.Printf("⚠️ Subscription billing: failed to fetch due subscriptions: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		renewed, err := renewSubscription(cfg, db, id, now)
+		if errors.Is(err, billing.ErrSubscriptionLocked) {
+			continue
+		}
+		if err != nil {
+			log.Printf("⚠️ Subscription billing: failed to renew subscription %d: %v", id, err)
+			continue
+		}
+		if renewed > 0 {
+			log.Printf("🧾 Subscription billing: subscription %d charged for %d period(s)", id, renewed)
+		}
+	}
+}
+
+// renewSubscription - Lock Redis per subscription + row lock, lalu tagih periode yang jatuh tempo.
+// Charge key yang unik membuat retry setelah crash tidak menagih dua kali.
+func renewSubscription(cfg *config.Config, db *gorm.DB, subscriptionID uint, now time.Time) (int, error) {
+	release, err := billing.LockSubscription(subscriptionID)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	renewed := 0
+	err = db.Transaction(func(tx *gorm.DB) error {
+		subscription, err := billing.FindSubscriptionForUpdate(tx, subscriptionID)
+		if err != nil {
+			return err
+		}
+		renewed, err = billing.RenewDue(tx, cfg, subscription, now)
+		return err
+	})
+	return renewed, err
+}
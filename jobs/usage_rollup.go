@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"auth-api/billing"
+	"auth-api/config"
+	"auth-api/database"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// StartUsageRollupWorker - Memindahkan event usage dari buffer Redis ke aggregate MySQL
+func StartUsageRollupWorker(cfg *config.Config, db *gorm.DB) {
+	go runEvery(cfg.Usage.RollupInterval, "usage_rollup", func() {
+		rollupUsage(db)
+	})
+}
+
+func rollupUsage(db *gorm.DB) {
+	if _, err := database.SealUsageBuffer(); err != nil {
+		log.Printf("⚠️ Usage rollup: failed to seal buffer: %v", err)
+		return
+	}
+
+	// Termasuk batch lama yang belum selesai karena crash/restart sebelumnya
+	batches, err := database.PendingUsageBatches()
+	if err != nil {
+		log.Printf("⚠️ Usage rollup: failed to list batches: %v", err)
+		return
+	}
+
+	for _, batchID := range batches {
+		payloads, err := database.ReadUsageBatch(batchID)
+		if err != nil {
+			log.Printf("⚠️ Usage rollup: failed to read batch %s: %v", batchID, err)
+			continue
+		}
+
+		count, err := billing.RollupUsageBatch(db, batchID, payloads)
+		if err != nil {
+			log.Printf("⚠️ Usage rollup: failed to apply batch %s: %v", batchID, err)
+			continue
+		}
+
+		if err := database.DeleteUsageBatch(batchID); err != nil {
+			log.Printf("⚠️ Usage rollup: failed to delete batch %s: %v", batchID, err)
+			continue
+		}
+		if count > 0 {
+			log.Printf("📊 Usage rollup: %d events from batch %s aggregated", count, batchID)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package ledger
+
+import (
+	"auth-api/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kode akun. Saldo customer adalah kewajiban (liability): credit menambah, debit mengurangi.
+const (
+	AccountCash            = "cash"
+	AccountCustomerBalance = "customer_balance"
+	AccountRevenue         = "revenue"
+	AccountAdjustment      = "adjustment"
+	AccountReceivable      = "accounts_receivable"
+	AccountTaxPayable      = "tax_payable"
+)
+
+var (
+	ErrUnbalancedEntry     = errors.New("journal entry is not balanced")
+	ErrInvalidLine         = errors.New("journal line must have either a debit or a credit amount")
+	ErrInsufficientBalance = errors.New("insufficient balance")
+)
+
+// Post - Simpan journal entry beserta line-nya lalu update cache Customer.Balance.
+// Harus dipanggil di dalam transaksi agar ledger dan cache selalu konsisten.
+func Post(tx *gorm.DB, entry *models.JournalEntry) error {
+	if len(entry.Lines) < 2 {
+		return ErrUnbalancedEntry
+	}
+
+	var debit, credit models.Money
+	deltas := make(map[uint]models.Money)
+	for _, line := range entry.Lines {
+		d, c := line.Debit, line.Credit
+		if d < 0 || c < 0 || (d == 0) == (c == 0) {
+			return ErrInvalidLine
+		}
+		debit += d
+		credit += c
+
+		if line.Account == AccountCustomerBalance && line.CustomerID != nil {
+			deltas[*line.CustomerID] += c - d
+		}
+	}
+	if debit != credit {
+		return ErrUnbalancedEntry
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+
+	for customerID, delta := range deltas {
+		err := tx.Model(&models.Customer{}).
+			Where("id = ?", customerID).
+			Updates(map[string]interface{}{
+				"balance": gorm.Expr("balance + CAST(? AS DECIMAL(15,2))", delta),
+				"version": gorm.Expr("version + 1"),
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// customerLines - Pasangan line saldo customer dan akun lawan
+func customerLines(customerID uint, amount models.Money, creditCustomer bool, counterAccount string) []models.JournalLine {
+	customerLine := models.JournalLine{Account: AccountCustomerBalance, CustomerID: &customerID}
+	counterLine := models.JournalLine{Account: counterAccount}
+
+	if creditCustomer {
+		customerLine.Credit = amount
+		counterLine.Debit = amount
+	} else {
+		customerLine.Debit = amount
+		counterLine.Credit = amount
+	}
+
+	return []models.JournalLine{counterLine, customerLine}
+}
+
+// Deduct - Pemakaian saldo: debit saldo customer, credit pendapatan (amount - tax) dan PPN keluaran (tax)
+func Deduct(tx *gorm.DB, customerID uint, amount, tax models.Money, description string, userID uint) (*models.JournalEntry, error) {
+	lines := customerLines(customerID, amount, false, AccountRevenue)
+	if tax > 0 {
+		// customerLines mengembalikan [lawan, customer]; kurangi line pendapatan sebesar PPN
+		lines[0].Credit -= tax
+		lines = append(lines, models.JournalLine{Account: AccountTaxPayable, Credit: tax})
+	}
+
+	entry := &models.JournalEntry{
+		CustomerID:  customerID,
+		Type:        "deduct",
+		Description: description,
+		CreatedBy:   userID,
+		Lines:       lines,
+	}
+	return entry, Post(tx, entry)
+}
+
+// Adjust - Koreksi manual atau saldo awal, delta positif menambah saldo customer
+func Adjust(tx *gorm.DB, customerID uint, delta models.Money, entryType, description string, userID uint) (*models.JournalEntry, error) {
+	entry := &models.JournalEntry{
+		CustomerID:  customerID,
+		Type:        entryType,
+		Description: description,
+		CreatedBy:   userID,
+		Lines:       customerLines(customerID, delta.Abs(), delta > 0, AccountAdjustment),
+	}
+	return entry, Post(tx, entry)
+}
+
+// CustomerBalance - Saldo customer yang dihitung ulang dari ledger
+func CustomerBalance(db *gorm.DB, customerID uint) (models.Money, error) {
+	var balance models.Money
+	err := db.Model(&models.JournalLine{}).
+		Where("account = ? AND customer_id = ?", AccountCustomerBalance, customerID).
+		Select("COALESCE(SUM(credit - debit), 0)").
+		Row().Scan(&balance)
+	return balance, err
+}
+
+// BalanceBefore - Saldo customer sebelum journal entry tertentu (untuk running balance)
+func BalanceBefore(db *gorm.DB, customerID, entryID uint) (models.Money, error) {
+	var balance models.Money
+	err := db.Model(&models.JournalLine{}).
+		Where("account = ? AND customer_id = ? AND journal_entry_id < ?", AccountCustomerBalance, customerID, entryID).
+		Select("COALESCE(SUM(credit - debit), 0)").
+		Row().Scan(&balance)
+	return balance, err
+}
+
+// EntryNet - Perubahan saldo customer akibat satu journal entry
+func EntryNet(entry models.JournalEntry, customerID uint) models.Money {
+	var net models.Money
+	for _, line := range entry.Lines {
+		if line.Account == AccountCustomerBalance && line.CustomerID != nil && *line.CustomerID == customerID {
+			net += line.Credit - line.Debit
+		}
+	}
+	return net
+}
+
+// positionAccounts - Posisi customer di statement adalah saldo deposit dikurangi piutang invoice
+var positionAccounts = []string{AccountCustomerBalance, AccountReceivable}
+
+// PositionBefore - Posisi customer (credit - debit pada saldo dan piutang) sebelum waktu tertentu
+func PositionBefore(db *gorm.DB, customerID uint, before time.Time) (models.Money, error) {
+	var position models.Money
+	err := db.Model(&models.JournalLine{}).
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Where("journal_lines.account IN ? AND journal_lines.customer_id = ? AND journal_entries.created_at < ?", positionAccounts, customerID, before).
+		Select("COALESCE(SUM(journal_lines.credit - journal_lines.debit), 0)").
+		Row().Scan(&position)
+	return position, err
+}
+
+// EntryPositionNet - Perubahan posisi customer akibat satu journal entry.
+// Alokasi kredit ke invoice (saldo -> piutang) menghasilkan 0.
+func EntryPositionNet(entry models.JournalEntry, customerID uint) models.Money {
+	var net models.Money
+	for _, line := range entry.Lines {
+		if line.CustomerID == nil || *line.CustomerID != customerID {
+			continue
+		}
+		if line.Account == AccountCustomerBalance || line.Account == AccountReceivable {
+			net += line.Credit - line.Debit
+		}
+	}
+	return net
+}
+
+// BackfillOpeningBalances - Buat entry saldo awal untuk customer lama yang belum punya ledger.
+// Pemanggil sebaiknya memegang lock antar instance; setiap customer tetap dikunci dan dicek ulang
+// di dalam transaksi agar entry tidak dobel dan perubahan saldo yang berjalan tidak hilang.
+func BackfillOpeningBalances(db *gorm.DB) error {
+	var ids []uint
+	err := db.Model(&models.Customer{}).Where("balance <> 0 AND id NOT IN (?)",
+		db.Model(&models.JournalLine{}).
+			Select("customer_id").
+			Where("account = ? AND customer_id IS NOT NULL", AccountCustomerBalance),
+	).Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var customer models.Customer
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, id).Error; err != nil {
+				return err
+			}
+			if customer.Balance == 0 {
+				return nil
+			}
+			var posted int64
+			err := tx.Model(&models.JournalLine{}).
+				Where("account = ? AND customer_id = ?", AccountCustomerBalance, id).
+				Count(&posted).Error
+			if err != nil || posted > 0 {
+				return err
+			}
+
+			// Post menambahkan delta ke cache, jadi nolkan dulu agar hasil akhirnya sama
+			if err := tx.Model(&models.Customer{}).Where("id = ?", id).Update("balance", 0).Error; err != nil {
+				return err
+			}
+			_, err = Adjust(tx, customer.ID, customer.Balance, "opening_balance", "Opening balance migrated from customers.balance", customer.UserID)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"auth-api/models"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockDB - gorm di atas sqlmock, query yang diharapkan didaftarkan oleh masing-masing test
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{SkipDefaultTransaction: true, Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+func customerID(id uint) *uint {
+	return &id
+}
+
+func TestPostRejectsInvalidEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []models.JournalLine
+		err   error
+	}{
+		{"no lines", nil, ErrUnbalancedEntry},
+		{"single line", []models.JournalLine{{Account: AccountCash, Debit: 100}}, ErrUnbalancedEntry},
+		{"debit and credit differ", []models.JournalLine{
+			{Account: AccountCash, Debit: 100},
+			{Account: AccountCustomerBalance, CustomerID: customerID(1), Credit: 99},
+		}, ErrUnbalancedEntry},
+		{"line with both sides", []models.JournalLine{
+			{Account: AccountCash, Debit: 100, Credit: 100},
+			{Account: AccountCustomerBalance, CustomerID: customerID(1), Credit: 0},
+		}, ErrInvalidLine},
+		{"empty line", []models.JournalLine{
+			{Account: AccountCash},
+			{Account: AccountCustomerBalance, CustomerID: customerID(1)},
+		}, ErrInvalidLine},
+		{"negative amount", []models.JournalLine{
+			{Account: AccountCash, Debit: -100},
+			{Account: AccountCustomerBalance, CustomerID: customerID(1), Credit: -100},
+		}, ErrInvalidLine},
+	}
+	for _, tt := range tests {
+		// Entry yang tidak valid ditolak sebelum ada query apa pun
+		db, mock := newMockDB(t)
+		err := Post(db, &models.JournalEntry{Type: "test", Lines: tt.lines})
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestDeductPostsBalancedEntryAndUpdatesCache(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectExec("INSERT INTO `journal_entries`").WillReturnResult(sqlmock.NewResult(10, 1))
+	mock.ExpectExec("INSERT INTO `journal_lines`").WillReturnResult(sqlmock.NewResult(20, 3))
+	mock.ExpectExec("UPDATE `customers` SET `balance`=balance \\+ CAST\\(\\? AS DECIMAL\\(15,2\\)\\),`version`=version \\+ 1,`updated_at`=\\? WHERE id = \\?").
+		WithArgs("-111.00", sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	entry, err := Deduct(db, 7, 11100, 1100, "usage", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	want := map[string][2]models.Money{
+		AccountCustomerBalance: {11100, 0},
+		AccountRevenue:         {0, 10000},
+		AccountTaxPayable:      {0, 1100},
+	}
+	if len(entry.Lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(entry.Lines), len(want))
+	}
+	for _, line := range entry.Lines {
+		if got := [2]models.Money{line.Debit, line.Credit}; got != want[line.Account] {
+			t.Errorf("%s debit/credit = %v, want %v", line.Account, got, want[line.Account])
+		}
+	}
+	if net := EntryPositionNet(*entry, 7); net != -11100 {
+		t.Errorf("EntryPositionNet = %d, want -11100", net)
+	}
+}
+
+func TestEntryPositionNet(t *testing.T) {
+	// Alokasi kredit ke invoice: saldo customer turun, piutang ikut turun, posisi tidak berubah
+	allocation := models.JournalEntry{Lines: []models.JournalLine{
+		{Account: AccountCustomerBalance, CustomerID: customerID(7), Debit: 5000},
+		{Account: AccountReceivable, CustomerID: customerID(7), Credit: 5000},
+	}}
+	// Invoice terbit: piutang naik (debit), posisi customer turun
+	invoice := models.JournalEntry{Lines: []models.JournalLine{
+		{Account: AccountReceivable, CustomerID: customerID(7), Debit: 11100},
+		{Account: AccountRevenue, Credit: 10000},
+		{Account: AccountTaxPayable, Credit: 1100},
+	}}
+
+	tests := []struct {
+		name     string
+		entry    models.JournalEntry
+		customer uint
+		want     models.Money
+	}{
+		{"allocation", allocation, 7, 0},
+		{"invoice", invoice, 7, -11100},
+		{"other customer", invoice, 8, 0},
+	}
+	for _, tt := range tests {
+		if got := EntryPositionNet(tt.entry, tt.customer); got != tt.want {
+			t.Errorf("%s: EntryPositionNet = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
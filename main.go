@@ -1,9 +1,12 @@
 package main
 
 import (
+	"auth-api/billing"
 	"auth-api/config"
 	"auth-api/controllers"
 	"auth-api/database"
+	"auth-api/jobs"
+	"auth-api/ledger"
 	"auth-api/middleware"
 	"auth-api/utils"
 	_ "fmt"
@@ -26,11 +29,45 @@ func main() {
 		log.Fatalf("❌ Failed to connect to MySQL: %v", err)
 	}
 
+	// Tarif PPN default (10% / 11%) jika tabel tax_rates masih kosong
+	if err := billing.SeedTaxRates(database.DB); err != nil {
+		log.Fatalf("❌ Failed to seed tax rates: %v", err)
+	}
+
 	// Initialize Redis
 	if err := database.InitRedis(cfg); err != nil {
 		log.Fatalf("❌ Failed to connect to Redis: %v", err)
 	}
 
+	// Customer lama yang belum punya ledger mendapat entry saldo awal. Hanya satu instance yang
+	// menjalankannya, instance lain yang start bersamaan melewatinya.
+	if token, err := database.AcquireLock("ledger_backfill", 10*time.Minute); err != nil {
+		log.Fatalf("❌ Failed to acquire ledger backfill lock: %v", err)
+	} else if token != "" {
+		err := ledger.BackfillOpeningBalances(database.DB)
+		database.ReleaseLock("ledger_backfill", token)
+		if err != nil {
+			log.Fatalf("❌ Failed to backfill ledger opening balances: %v", err)
+		}
+	}
+
+	// Initialize GeoIP (opsional, dipakai untuk deteksi login mencurigakan)
+	if err := utils.InitGeoIP(cfg.Security.GeoIPDBPath); err != nil {
+		log.Printf("⚠️ GeoIP database not loaded, location checks disabled: %v", err)
+	}
+
+	// Start background workers
+	jobs.StartAccountPurgeWorker(cfg, database.DB)
+	jobs.StartSubscriptionBillingWorker(cfg, database.DB)
+	jobs.StartUsageRollupWorker(cfg, database.DB)
+	jobs.StartLowBalanceWorker(cfg, database.DB)
+	jobs.StartApprovalExpiryWorker(cfg, database.DB)
+	jobs.StartStatusPolicyWorker(cfg, database.DB)
+	jobs.StartDunningWorker(cfg, database.DB)
+	jobs.StartExportWorker(cfg, database.DB)
+	jobs.StartCustomerBulkWorker(cfg, database.DB)
+	jobs.StartCustomerPurgeWorker(cfg, database.DB)
+
 	// Initialize Gin
 	gin.SetMode(gin.ReleaseMode) // Use gin.DebugMode for development
 	r := gin.Default()
@@ -39,7 +76,8 @@ func main() {
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, Idempotency-Key, If-Match")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
@@ -53,6 +91,15 @@ func main() {
 	// Initialize controller
 	authController := controllers.NewAuthController(cfg, database.DB)
 	customerController := controllers.NewCustomerController(cfg, database.DB)
+	invoiceController := controllers.NewInvoiceController(cfg, database.DB)
+	paymentController := controllers.NewPaymentController(cfg, database.DB)
+	subscriptionController := controllers.NewSubscriptionController(cfg, database.DB)
+	usageController := controllers.NewUsageController(cfg, database.DB)
+	taxController := controllers.NewTaxController(cfg, database.DB)
+	dunningController := controllers.NewDunningController(cfg, database.DB)
+	reportController := controllers.NewReportController(cfg, database.DB)
+	exportController := controllers.NewExportController(cfg, database.DB)
+	approvalController := controllers.NewApprovalController(cfg, database.DB)
 
 	// Health check endpoint
 	r.GET("/health", func(c *gin.Context) {
@@ -73,21 +120,37 @@ func main() {
 		api.POST("/resend-otp", authController.ResendOTP)
 		api.POST("/forgot-password", authController.ForgotPassword)
 		api.POST("/reset-password", authController.ResetPassword)
+		api.GET("/change-email/cancel", authController.CancelEmailChangePage)
+		api.POST("/change-email/cancel", authController.CancelEmailChange)
+		api.GET("/sessions/revoke", authController.RevokeSessionsPage)
+		api.POST("/sessions/revoke", authController.RevokeSessionsFromAlert)
 
 		// Protected routes
 		protected := api.Group("/")
-		protected.Use(middleware.JWTAuth(cfg))
+		protected.Use(middleware.JWTAuth(cfg), middleware.ImpersonationAudit(database.DB))
 		{
 			protected.GET("/profile", authController.GetProfile)
-			protected.POST("/change-password", authController.ChangePassword)
+			protected.GET("/profile/export", authController.ExportProfile)
+			protected.GET("/profile/logins", authController.GetProfileLogins)
+			protected.GET("/profile/devices", authController.GetProfileDevices)
+			protected.DELETE("/profile/devices/:device_id", middleware.DenyImpersonation(), authController.DeleteProfileDevice)
+			protected.DELETE("/profile", middleware.DenyImpersonation(), authController.RequestAccountDeletion)
+			protected.POST("/profile/deletion/cancel", middleware.DenyImpersonation(), authController.CancelAccountDeletion)
+			protected.POST("/change-password", middleware.DenyImpersonation(), authController.ChangePassword)
+			protected.POST("/change-email", middleware.DenyImpersonation(), authController.RequestEmailChange)
+			protected.POST("/change-email/confirm", middleware.DenyImpersonation(), authController.ConfirmEmailChange)
 
 			// Customer routes
 			customers := protected.Group("/customers")
 			{
-				customers.POST("", customerController.CreateCustomer)
+				customers.POST("", middleware.Idempotency(cfg), customerController.CreateCustomer)
 				customers.GET("", customerController.GetCustomers)
 				customers.GET("/stats", customerController.GetCustomerStats)
 				customers.GET("/export", customerController.ExportCustomers)
+				customers.POST("/import", middleware.RoleMiddleware("finance", "admin"), customerController.ImportCustomers)
+				customers.POST("/bulk", middleware.DenyImpersonation(), customerController.BulkCustomers)
+				customers.GET("/bulk/:job_id", customerController.GetBulkJob)
+				customers.GET("/deleted", middleware.RoleMiddleware("admin"), customerController.GetDeletedCustomers)
 
 				// Customer by ID routes
 				customer := customers.Group("/:id")
@@ -95,28 +158,115 @@ func main() {
 					customer.GET("", customerController.GetCustomerByID)
 					customer.PUT("", customerController.UpdateCustomer)
 					customer.DELETE("", customerController.DeleteCustomer)
-					customer.PATCH("/balance", customerController.UpdateCustomerBalance)
+					customer.POST("/restore", middleware.RoleMiddleware("admin"), customerController.RestoreCustomer)
+					customer.PATCH("/balance", middleware.DenyImpersonation(), middleware.Idempotency(cfg), customerController.UpdateCustomerBalance)
 					customer.GET("/history", customerController.GetCustomerHistory)
+					customer.GET("/history/export", customerController.ExportCustomerHistory)
+					customer.GET("/ledger", customerController.GetCustomerLedger)
+					customer.GET("/statement.pdf", customerController.GetCustomerStatementPDF)
+				}
+			}
+
+			// Invoice routes (customer hanya bisa melihat invoice miliknya)
+			invoices := protected.Group("/invoices")
+			{
+				invoices.GET("", invoiceController.GetInvoices)
+				invoices.GET("/:id", invoiceController.GetInvoiceByID)
+				invoices.GET("/:id/pdf", invoiceController.GetInvoicePDF)
+
+				manage := invoices.Group("")
+				manage.Use(middleware.RoleMiddleware("finance", "admin"))
+				{
+					manage.POST("", middleware.Idempotency(cfg), invoiceController.CreateInvoice)
+					manage.PUT("/:id", invoiceController.UpdateInvoice)
+					manage.DELETE("/:id", invoiceController.DeleteInvoice)
+					manage.POST("/:id/issue", invoiceController.IssueInvoice)
+					manage.POST("/:id/void", invoiceController.VoidInvoice)
+				}
+			}
+
+			// Payment routes (uang masuk dan alokasinya ke invoice)
+			payments := protected.Group("/payments")
+			{
+				payments.GET("", paymentController.GetPayments)
+				payments.GET("/:id", paymentController.GetPaymentByID)
+				payments.POST("", middleware.RoleMiddleware("finance", "admin"), middleware.Idempotency(cfg), paymentController.CreatePayment)
+				payments.POST("/:id/allocate", middleware.RoleMiddleware("finance", "admin"), middleware.Idempotency(cfg), paymentController.AllocatePayment)
+			}
+
+			// Plan & subscription routes
+			protected.GET("/plans", subscriptionController.GetPlans)
+			protected.POST("/plans", middleware.RoleMiddleware("finance", "admin"), subscriptionController.CreatePlan)
+			protected.PUT("/plans/:id", middleware.RoleMiddleware("finance", "admin"), subscriptionController.UpdatePlan)
+
+			subscriptions := protected.Group("/subscriptions")
+			{
+				subscriptions.GET("", subscriptionController.GetSubscriptions)
+				subscriptions.GET("/:id", subscriptionController.GetSubscriptionByID)
+
+				manage := subscriptions.Group("")
+				manage.Use(middleware.RoleMiddleware("finance", "admin"))
+				{
+					manage.POST("", middleware.Idempotency(cfg), subscriptionController.CreateSubscription)
+					manage.POST("/:id/change-plan", middleware.Idempotency(cfg), subscriptionController.ChangeSubscriptionPlan)
+					manage.POST("/:id/pause", subscriptionController.PauseSubscription)
+					manage.POST("/:id/resume", subscriptionController.ResumeSubscription)
+					manage.POST("/:id/cancel", subscriptionController.CancelSubscription)
 				}
 			}
 
+			// Usage metering routes
+			protected.POST("/usage", middleware.RoleMiddleware("finance", "admin"), usageController.IngestUsage)
+			protected.GET("/usage", usageController.GetUsage)
+
+			// Balance approval routes (maker-checker)
+			approvals := protected.Group("/approvals")
+			approvals.Use(middleware.RoleMiddleware("finance", "admin"))
+			{
+				approvals.GET("", approvalController.GetApprovals)
+				approvals.GET("/:id", approvalController.GetApprovalByID)
+				approvals.POST("/:id/approve", approvalController.ApproveApproval)
+				approvals.POST("/:id/reject", approvalController.RejectApproval)
+				approvals.POST("/:id/cancel", approvalController.CancelApproval)
+			}
+
+			// Async export routes (file hanya bisa diunduh oleh user yang membuat job)
+			exportJobs := protected.Group("/exports")
+			{
+				exportJobs.POST("", exportController.CreateExportJob)
+				exportJobs.GET("", exportController.GetExportJobs)
+				exportJobs.GET("/:id", exportController.GetExportJob)
+				exportJobs.GET("/:id/download", exportController.DownloadExportJob)
+			}
+
+			// Tax routes
+			protected.GET("/tax/npwp/validate", taxController.ValidateNPWP)
+			protected.GET("/tax/rates", middleware.RoleMiddleware("finance", "admin"), taxController.GetTaxRates)
+			protected.POST("/tax/rates", middleware.RoleMiddleware("admin"), taxController.CreateTaxRate)
+
 			// Admin routes
 			admin := protected.Group("/admin")
 			admin.Use(middleware.RoleMiddleware("admin"))
 			{
 				admin.GET("/users", authController.AdminGetUsers)
+				admin.GET("/users/:id/logins", authController.AdminGetUserLogins)
+				admin.POST("/users/:id/impersonate", authController.AdminImpersonateUser)
+				admin.GET("/impersonation-logs", authController.AdminGetImpersonationLogs)
 			}
 
 			// Finance routes
 			finance := protected.Group("/finance")
 			finance.Use(middleware.RoleMiddleware("finance", "admin"))
 			{
-				// Add finance-specific routes here
-				finance.GET("/dashboard", func(c *gin.Context) {
-					utils.SuccessResponse(c, 200, gin.H{
-						"message": "Welcome to Finance Dashboard",
-					})
-				})
+				finance.GET("/dashboard", reportController.GetDashboard)
+				finance.GET("/reports/aging", reportController.GetAgingReport)
+				finance.GET("/reports/balance-movements", reportController.GetBalanceMovements)
+				finance.GET("/reports/top-customers", reportController.GetTopCustomers)
+				finance.GET("/reports/finance-users", reportController.GetFinanceUserTotals)
+				finance.GET("/tax-summary", taxController.GetTaxSummary)
+				finance.GET("/dunning", dunningController.GetDunningBoard)
+				finance.POST("/dunning/:customer_id/pause", dunningController.PauseDunning)
+				finance.POST("/dunning/:customer_id/resume", dunningController.ResumeDunning)
 			}
 		}
 	}
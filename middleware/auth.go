@@ -2,12 +2,17 @@ package middleware
 
 import (
 	"auth-api/config"
+	"auth-api/database"
+	"auth-api/models"
 	"auth-api/utils"
+	"fmt"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
 )
 
 func JWTAuth(cfg *config.Config) gin.HandlerFunc {
@@ -70,26 +75,129 @@ func JWTAuth(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		// Check if the user's sessions were revoked after this token was issued
+		revokedAt, err := database.GetSessionsRevokedAt(userID)
+		if err != nil {
+			utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+			c.Abort()
+			return
+		}
+		if revokedAt > 0 && issuedAtMilli(claims) < revokedAt {
+			utils.ErrorResponse(c, 401, gin.H{"message": "Session has been revoked, please login again"})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", userID)
 		c.Set("email", claims["email"])
 		c.Set("role", claims["role"])
+
+		// Token impersonation membawa claim act (admin yang melakukan impersonation)
+		if act, ok := claims["act"].(map[string]interface{}); ok {
+			actorID, ok := act["sub"].(float64)
+			if !ok {
+				utils.ErrorResponse(c, 401, gin.H{"message": "Invalid act claim in token"})
+				c.Abort()
+				return
+			}
+			c.Set("impersonator_id", uint(actorID))
+		}
+
 		c.Next()
 	}
 }
 
+// issuedAtMilli - Waktu token dibuat dalam milidetik. Token lama tanpa iat_ms memakai awal detik iat,
+// token tanpa iat dianggap dibuat sebelum revoke.
+func issuedAtMilli(claims jwt.MapClaims) int64 {
+	if iatMs, ok := claims["iat_ms"].(float64); ok {
+		return int64(iatMs)
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		return int64(iat) * 1000
+	}
+	return 0
+}
+
 func GenerateToken(userID uint, email, role string, cfg *config.Config) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"email":   email,
 		"role":    role,
 		"exp":     time.Now().Add(cfg.JWT.Expiry).Unix(),
-		"iat":     time.Now().Unix(),
+		"iat":     now.Unix(),
+		"iat_ms":  now.UnixMilli(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(cfg.JWT.Secret))
 }
 
+// GenerateImpersonationToken - Token berumur pendek atas nama user (sub) yang dibuat oleh admin (act)
+func GenerateImpersonationToken(subject models.User, actorID uint, actorEmail string, cfg *config.Config) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(cfg.JWT.ImpersonationExpiry)
+	claims := jwt.MapClaims{
+		"sub":     fmt.Sprintf("%d", subject.ID),
+		"user_id": subject.ID,
+		"email":   subject.Email,
+		"role":    subject.Role,
+		"act": map[string]interface{}{
+			"sub":   actorID,
+			"email": actorEmail,
+		},
+		"exp":    expiresAt.Unix(),
+		"iat":    now.Unix(),
+		"iat_ms": now.UnixMilli(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(cfg.JWT.Secret))
+	return signed, expiresAt, err
+}
+
+// DenyImpersonation - Blokir operasi sensitif jika request memakai token impersonation
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonated := c.Get("impersonator_id"); impersonated {
+			utils.ErrorResponse(c, 403, gin.H{"message": "Forbidden: this action is not allowed while impersonating"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ImpersonationAudit - Catat setiap request yang dilakukan dengan token impersonation
+func ImpersonationAudit(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, impersonated := c.Get("impersonator_id")
+		if !impersonated {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		subjectID, _ := c.Get("user_id")
+		entry := models.ImpersonationLog{
+			ActorID:   actorID.(uint),
+			SubjectID: subjectID.(uint),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			IPAddress: c.ClientIP(),
+			CreatedAt: time.Now(),
+		}
+		if err := db.Create(&entry).Error; err != nil {
+			log.Printf("⚠️ Failed to write impersonation audit log: %v", err)
+		}
+		log.Printf("👤 Impersonation: admin %d as user %d %s %s -> %d",
+			entry.ActorID, entry.SubjectID, entry.Method, entry.Path, entry.Status)
+	}
+}
+
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"auth-api/config"
+	"auth-api/database"
+	"auth-api/utils"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body,omitempty"`
+}
+
+// responseRecorder menyalin response body agar bisa disimpan untuk replay
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency - Request dengan header Idempotency-Key yang sama akan di-replay dari
+// response pertama. Key yang dipakai ulang dengan body berbeda ditolak dengan 409.
+func Idempotency(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			utils.ErrorResponse(c, 400, gin.H{"message": "Idempotency-Key must be at most 255 characters"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.ErrorResponse(c, 400, gin.H{"message": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Key di-scope per user agar tidak bentrok antar client
+		userID, _ := c.Get("user_id")
+		scopedKey := fmt.Sprintf("%v:%s", userID, key)
+
+		sum := sha256.Sum256([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n" + string(body)))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		pending := utils.ToJSON(idempotencyRecord{Fingerprint: fingerprint})
+		reserved, err := database.ReserveIdempotencyKey(scopedKey, pending, cfg.Idempotency.TTL)
+		if err != nil {
+			utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+			c.Abort()
+			return
+		}
+
+		if !reserved {
+			replayIdempotentResponse(c, scopedKey, fingerprint)
+			return
+		}
+
+		// Lepas key jika handler panic, agar tidak terkunci sampai TTL habis
+		defer func() {
+			if r := recover(); r != nil {
+				database.DeleteIdempotencyRecord(scopedKey)
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = recorder
+		c.Next()
+
+		// Error server tidak disimpan supaya client bisa retry dengan key yang sama
+		status := recorder.Status()
+		if status >= 500 {
+			database.DeleteIdempotencyRecord(scopedKey)
+			return
+		}
+
+		record := idempotencyRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.String(),
+		}
+		if err := database.SaveIdempotencyRecord(scopedKey, utils.ToJSON(record), cfg.Idempotency.TTL); err != nil {
+			log.Printf("⚠️ Failed to store idempotent response for key %s: %v", key, err)
+		}
+	}
+}
+
+func replayIdempotentResponse(c *gin.Context, scopedKey, fingerprint string) {
+	data, err := database.GetIdempotencyRecord(scopedKey)
+	if err != nil || data == "" {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+		c.Abort()
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		utils.ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+		c.Abort()
+		return
+	}
+
+	if record.Fingerprint != fingerprint {
+		utils.ErrorResponse(c, 409, gin.H{"message": "Idempotency-Key has already been used with a different request"})
+		c.Abort()
+		return
+	}
+
+	if !record.Completed {
+		utils.ErrorResponse(c, 409, gin.H{"message": "A request with this Idempotency-Key is still being processed"})
+		c.Abort()
+		return
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.Status, record.ContentType, []byte(record.Body))
+	c.Abort()
+}
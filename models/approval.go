@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BalanceApproval adalah perubahan saldo yang menunggu persetujuan (maker-checker).
+// Maker (RequestedBy) tidak boleh menjadi checker (ReviewedBy) untuk request yang sama.
+type BalanceApproval struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	CustomerID     uint       `gorm:"not null;index" json:"customer_id"`
+	Type           string     `gorm:"type:ENUM('deduct','adjustment');not null" json:"type"`
+	Amount         Money      `gorm:"type:decimal(15,2);not null" json:"amount"` // adjustment boleh negatif
+	Notes          string     `gorm:"size:255" json:"notes"`
+	Reason         string     `gorm:"type:ENUM('threshold','overdraft');not null" json:"reason"`
+	Status         string     `gorm:"type:ENUM('pending','approved','rejected','cancelled','expired');default:'pending';index" json:"status"`
+	RequestedBy    uint       `gorm:"not null" json:"requested_by"`
+	ReviewedBy     *uint      `json:"reviewed_by,omitempty"`
+	ReviewNote     string     `gorm:"size:255" json:"review_note,omitempty"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+	ExpiresAt      time.Time  `gorm:"not null;index" json:"expires_at"`
+	JournalEntryID *uint      `json:"journal_entry_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (a *BalanceApproval) BeforeCreate(tx *gorm.DB) error {
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = time.Now()
+	return nil
+}
+
+func (a *BalanceApproval) BeforeUpdate(tx *gorm.DB) error {
+	a.UpdatedAt = time.Now()
+	return nil
+}
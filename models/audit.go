@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ImpersonationLog mencatat setiap request yang dilakukan admin atas nama user lain
+type ImpersonationLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ActorID   uint      `gorm:"not null;index" json:"actor_id"`
+	SubjectID uint      `gorm:"not null;index" json:"subject_id"`
+	Method    string    `gorm:"size:10" json:"method"`
+	Path      string    `gorm:"size:255" json:"path"`
+	Status    int       `json:"status"`
+	IPAddress string    `gorm:"size:45" json:"ip_address"`
+	CreatedAt time.Time `json:"created_at"`
+}
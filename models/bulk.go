@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CustomerBulkJob adalah operasi massal pada customer yang dijalankan worker. Daftar customer
+// ditetapkan saat job dibuat (CustomerBulkItem), hasilnya dicatat per item.
+type CustomerBulkJob struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Action        string     `gorm:"type:ENUM('set_status','reassign','add_tag','delete');not null" json:"action"`
+	Params        string     `gorm:"type:json" json:"params"`
+	Status        string     `gorm:"type:ENUM('pending','running','completed','failed');default:'pending';index" json:"status"`
+	Total         int        `json:"total"`
+	Succeeded     int        `json:"succeeded"`
+	Skipped       int        `json:"skipped"`
+	Failed        int        `json:"failed"`
+	Error         string     `gorm:"size:500" json:"error,omitempty"`
+	RequestedBy   uint       `gorm:"not null;index" json:"requested_by"`
+	RequestedRole string     `gorm:"size:20;not null" json:"-"` // role saat request, menentukan aturan per customer
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (j *CustomerBulkJob) BeforeCreate(tx *gorm.DB) error {
+	j.CreatedAt = time.Now()
+	j.UpdatedAt = time.Now()
+	return nil
+}
+
+func (j *CustomerBulkJob) BeforeUpdate(tx *gorm.DB) error {
+	j.UpdatedAt = time.Now()
+	return nil
+}
+
+// CustomerBulkItem - Hasil operasi massal untuk satu customer. Skipped = tidak ada yang berubah.
+type CustomerBulkItem struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	JobID       uint       `gorm:"not null;index:idx_bulk_item_job_status" json:"job_id"`
+	CustomerID  uint       `gorm:"not null" json:"customer_id"`
+	Status      string     `gorm:"type:ENUM('pending','succeeded','skipped','failed');default:'pending';index:idx_bulk_item_job_status" json:"status"`
+	Error       string     `gorm:"size:255" json:"error,omitempty"`
+	ProcessedAt *time.Time `json:"processed_at,omitempty"`
+}
@@ -7,20 +7,54 @@ import (
 )
 
 type Customer struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	CustomerCode string    `gorm:"size:50;uniqueIndex;not null" json:"customer_code"`
-	CompanyName  string    `gorm:"size:200;not null" json:"company_name"`
-	ContactName  string    `gorm:"size:100" json:"contact_name"`
-	Email        string    `gorm:"size:100" json:"email"`
-	Phone        string    `gorm:"size:20" json:"phone"`
-	Address      string    `gorm:"type:text" json:"address"`
-	NPWP         string    `gorm:"size:25" json:"npwp"`
-	Balance      float64   `gorm:"type:decimal(15,2);default:0" json:"balance"`
-	Status       string    `gorm:"type:ENUM('active','suspended','terminated');default:'active'" json:"status"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	UserID       uint      `gorm:"not null" json:"user_id"`
-	User         User      `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
+	ID                  uint           `gorm:"primaryKey" json:"id"`
+	CustomerCode        string         `gorm:"size:50;uniqueIndex;not null" json:"customer_code"`
+	CompanyName         string         `gorm:"size:200;not null" json:"company_name"`
+	ContactName         string         `gorm:"size:100" json:"contact_name"`
+	Email               string         `gorm:"size:100" json:"email"`
+	Phone               string         `gorm:"size:20" json:"phone"`
+	Address             string         `gorm:"type:text" json:"address"`
+	NPWP                string         `gorm:"size:25" json:"npwp"`
+	TaxExempt           bool           `gorm:"default:false" json:"tax_exempt"`
+	TaxExemptReason     string         `gorm:"size:255" json:"tax_exempt_reason,omitempty"`
+	TaxExemptUntil      *time.Time     `gorm:"type:date" json:"tax_exempt_until,omitempty"`
+	Balance             Money          `gorm:"type:decimal(15,2);default:0" json:"balance"`
+	CreditLimit         Money          `gorm:"type:decimal(15,2);default:0" json:"credit_limit"`
+	OverdraftPolicy     string         `gorm:"type:ENUM('hard_stop','require_approval','allow_with_alert');default:'hard_stop'" json:"overdraft_policy"`
+	LowBalanceThreshold *Money         `gorm:"type:decimal(15,2)" json:"low_balance_threshold,omitempty"` // nil = tanpa notifikasi saldo rendah
+	LowBalanceAlertedAt *time.Time     `json:"low_balance_alerted_at,omitempty"`
+	Status              string         `gorm:"type:ENUM('active','suspended','terminated');default:'active'" json:"status"`
+	StatusReason        string         `gorm:"size:255" json:"status_reason,omitempty"`
+	StatusChangedAt     *time.Time     `json:"status_changed_at,omitempty"`
+	AutoSuspended       bool           `gorm:"default:false" json:"auto_suspended"` // disuspend oleh policy, aktif lagi otomatis setelah dilunasi
+	Version             uint           `gorm:"not null;default:1" json:"version"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+	PurgedAt            *time.Time     `json:"purged_at,omitempty"` // PII dianonimkan setelah masa retensi, tidak bisa di-restore lagi
+	UserID              uint           `gorm:"not null" json:"user_id"`
+	User                User           `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
+}
+
+// IsTaxExemptAt - Customer bebas PPN (mis. punya SKB), berlaku sampai TaxExemptUntil jika diisi
+func (c *Customer) IsTaxExemptAt(t time.Time) bool {
+	if !c.TaxExempt {
+		return false
+	}
+	return c.TaxExemptUntil == nil || t.Before(c.TaxExemptUntil.AddDate(0, 0, 1))
+}
+
+// AvailableCredit - Dana yang masih bisa dipakai: saldo ditambah credit line
+func (c *Customer) AvailableCredit() Money {
+	return c.Balance + c.CreditLimit
+}
+
+// CreditUsed - Bagian credit line yang sedang terpakai (saldo negatif)
+func (c *Customer) CreditUsed() Money {
+	if c.Balance >= 0 {
+		return 0
+	}
+	return -c.Balance
 }
 
 func (c *Customer) BeforeCreate(tx *gorm.DB) error {
@@ -38,8 +72,17 @@ func (c *Customer) BeforeUpdate(tx *gorm.DB) error {
 type CustomerHistory struct {
 	ID         uint      `gorm:"primaryKey" json:"id"`
 	CustomerID uint      `gorm:"not null" json:"customer_id"`
-	Action     string    `gorm:"type:ENUM('create','update','delete','status_change','balance_update')" json:"action"`
+	Action     string    `gorm:"type:ENUM('create','update','delete','status_change','balance_update','dunning','restore','purge')" json:"action"`
 	Changes    string    `gorm:"type:json" json:"changes"`
 	ChangedBy  uint      `gorm:"not null" json:"changed_by"`
 	CreatedAt  time.Time `json:"created_at"`
 }
+
+// CustomerTag - Label bebas pada customer (mis. "vip", "migrasi-2024"), satu tag unik per customer
+type CustomerTag struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CustomerID uint      `gorm:"not null;uniqueIndex:idx_customer_tag" json:"customer_id"`
+	Tag        string    `gorm:"size:50;not null;uniqueIndex:idx_customer_tag;index" json:"tag"`
+	CreatedBy  uint      `gorm:"not null" json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// DunningState menyimpan posisi customer dalam jadwal dunning. Stage adalah jumlah tahap
+// yang sudah dijalankan (0 = belum ada), sehingga setiap tahap hanya dijalankan sekali.
+type DunningState struct {
+	CustomerID     uint       `gorm:"primaryKey;autoIncrement:false" json:"customer_id"`
+	Stage          int        `gorm:"default:0" json:"stage"`
+	StageName      string     `gorm:"size:30" json:"stage_name"`
+	OverdueSince   *time.Time `gorm:"index" json:"overdue_since,omitempty"`
+	OverdrawnSince *time.Time `json:"overdrawn_since,omitempty"` // saldo pertama kali terlihat melewati credit limit
+	AmountDue      Money      `gorm:"type:decimal(15,2);default:0" json:"amount_due"`
+	LastStepAt     *time.Time `json:"last_step_at,omitempty"`
+	Paused         bool       `gorm:"default:false" json:"paused"` // mis. customer sedang dispute tagihan
+	PauseReason    string     `gorm:"size:255" json:"pause_reason,omitempty"`
+	PausedUntil    *time.Time `json:"paused_until,omitempty"`
+	PausedBy       *uint      `json:"paused_by,omitempty"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// IsPausedAt - Pause tanpa PausedUntil berlaku sampai di-resume manual
+func (d *DunningState) IsPausedAt(t time.Time) bool {
+	return d.Paused && (d.PausedUntil == nil || t.Before(*d.PausedUntil))
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExportJob adalah export asinkron. Worker menulis file ke direktori export lokal,
+// file dan job ditandai expired setelah ExpiresAt.
+type ExportJob struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Type          string     `gorm:"type:ENUM('customers','customer_history');not null" json:"type"`
+	Format        string     `gorm:"type:ENUM('csv','xlsx','ndjson');default:'csv'" json:"format"`
+	Filters       string     `gorm:"type:json" json:"filters"`
+	Columns       string     `gorm:"size:500" json:"columns,omitempty"`
+	BOM           bool       `gorm:"default:false" json:"bom"`
+	Status        string     `gorm:"type:ENUM('pending','running','completed','failed','expired');default:'pending';index" json:"status"`
+	RequestedBy   uint       `gorm:"not null;index" json:"requested_by"`
+	RequestedRole string     `gorm:"size:20;not null" json:"-"` // role saat request, menentukan scope data
+	FilePath      string     `gorm:"size:255" json:"-"`
+	FileName      string     `gorm:"size:100" json:"file_name,omitempty"`
+	FileSize      int64      `json:"file_size"`
+	RowCount      int64      `json:"row_count"`
+	Error         string     `gorm:"size:500" json:"error,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt     *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (j *ExportJob) BeforeCreate(tx *gorm.DB) error {
+	j.CreatedAt = time.Now()
+	j.UpdatedAt = time.Now()
+	return nil
+}
+
+func (j *ExportJob) BeforeUpdate(tx *gorm.DB) error {
+	j.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invoice mengikuti lifecycle draft -> issued -> paid/void.
+// Nomor invoice baru diberikan saat issued (berurutan per tahun).
+type Invoice struct {
+	ID            uint          `gorm:"primaryKey" json:"id"`
+	InvoiceNumber *string       `gorm:"size:30;uniqueIndex" json:"invoice_number"`
+	CustomerID    uint          `gorm:"not null;index" json:"customer_id"`
+	Status        string        `gorm:"type:ENUM('draft','issued','paid','void');default:'draft';index" json:"status"`
+	IssueDate     *time.Time    `gorm:"type:date" json:"issue_date"`
+	DueDate       time.Time     `gorm:"type:date;not null" json:"due_date"`
+	Subtotal      Money         `gorm:"type:decimal(15,2);default:0" json:"subtotal"`
+	TaxTotal      Money         `gorm:"type:decimal(15,2);default:0" json:"tax_total"`
+	Total         Money         `gorm:"type:decimal(15,2);default:0" json:"total"`
+	AmountPaid    Money         `gorm:"type:decimal(15,2);default:0" json:"amount_paid"`
+	Notes         string        `gorm:"type:text" json:"notes"`
+	VoidReason    string        `gorm:"size:255" json:"void_reason,omitempty"`
+	CreatedBy     uint          `gorm:"not null" json:"created_by"`
+	IssuedBy      *uint         `json:"issued_by,omitempty"`
+	IssuedAt      *time.Time    `json:"issued_at,omitempty"`
+	PaidAt        *time.Time    `json:"paid_at,omitempty"`
+	VoidedBy      *uint         `json:"voided_by,omitempty"`
+	VoidedAt      *time.Time    `json:"voided_at,omitempty"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	Lines         []InvoiceLine `gorm:"foreignKey:InvoiceID;constraint:OnDelete:CASCADE;" json:"lines,omitempty"`
+}
+
+// InvoiceLine adalah satu item tagihan. Amount = Quantity x UnitPrice (sebelum pajak).
+type InvoiceLine struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	InvoiceID   uint    `gorm:"not null;index" json:"invoice_id"`
+	Description string  `gorm:"size:255;not null" json:"description"`
+	Quantity    float64 `gorm:"type:decimal(15,4);not null" json:"quantity"`
+	UnitPrice   Money   `gorm:"type:decimal(15,2);not null" json:"unit_price"`
+	Amount      Money   `gorm:"type:decimal(15,2);not null" json:"amount"`
+	TaxRateBps  int     `gorm:"default:0" json:"tax_rate_bps"`
+	TaxAmount   Money   `gorm:"type:decimal(15,2);default:0" json:"tax_amount"`
+}
+
+// InvoiceSequence menyimpan nomor terakhir per tahun untuk penomoran berurutan
+type InvoiceSequence struct {
+	Year       int `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int `gorm:"not null;default:0"`
+}
+
+// OutstandingAmount - Sisa tagihan yang belum dibayar
+func (i *Invoice) OutstandingAmount() Money {
+	return i.Total - i.AmountPaid
+}
+
+func (i *Invoice) BeforeCreate(tx *gorm.DB) error {
+	i.CreatedAt = time.Now()
+	i.UpdatedAt = time.Now()
+	return nil
+}
+
+func (i *Invoice) BeforeUpdate(tx *gorm.DB) error {
+	i.UpdatedAt = time.Now()
+	return nil
+}
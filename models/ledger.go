@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrImmutableJournal = errors.New("journal entries are immutable")
+
+// JournalEntry adalah satu transaksi double-entry, tidak boleh diubah atau dihapus
+type JournalEntry struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	CustomerID  uint          `gorm:"not null;index" json:"customer_id"`
+	Type        string        `gorm:"size:30;not null" json:"type"`
+	Description string        `gorm:"size:255" json:"description"`
+	Reference   string        `gorm:"size:100;index" json:"reference,omitempty"`
+	CreatedBy   uint          `gorm:"not null" json:"created_by"`
+	CreatedAt   time.Time     `json:"created_at"`
+	Lines       []JournalLine `gorm:"foreignKey:JournalEntryID" json:"lines,omitempty"`
+}
+
+// JournalLine adalah satu baris debit atau credit pada sebuah akun
+type JournalLine struct {
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	JournalEntryID uint   `gorm:"not null;index" json:"journal_entry_id"`
+	Account        string `gorm:"size:50;not null;index:idx_account_customer" json:"account"`
+	CustomerID     *uint  `gorm:"index:idx_account_customer" json:"customer_id,omitempty"`
+	Debit          Money  `gorm:"type:decimal(15,2);default:0" json:"debit"`
+	Credit         Money  `gorm:"type:decimal(15,2);default:0" json:"credit"`
+}
+
+func (j *JournalEntry) BeforeUpdate(tx *gorm.DB) error {
+	return ErrImmutableJournal
+}
+
+func (j *JournalEntry) BeforeDelete(tx *gorm.DB) error {
+	return ErrImmutableJournal
+}
+
+func (l *JournalLine) BeforeUpdate(tx *gorm.DB) error {
+	return ErrImmutableJournal
+}
+
+func (l *JournalLine) BeforeDelete(tx *gorm.DB) error {
+	return ErrImmutableJournal
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// LoginHistory mencatat setiap percobaan login (password maupun OTP)
+type LoginHistory struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	UserID            *uint     `gorm:"index" json:"user_id,omitempty"`
+	Email             string    `gorm:"size:100;index" json:"email"`
+	Stage             string    `gorm:"type:ENUM('password','otp');default:'password'" json:"stage"`
+	Success           bool      `gorm:"default:false" json:"success"`
+	FailureReason     string    `gorm:"size:50" json:"failure_reason,omitempty"`
+	IPAddress         string    `gorm:"size:45" json:"ip_address"`
+	UserAgent         string    `gorm:"size:255" json:"user_agent"`
+	DeviceFingerprint string    `gorm:"size:64;index" json:"device_fingerprint"`
+	Country           string    `gorm:"size:2" json:"country,omitempty"`
+	City              string    `gorm:"size:100" json:"city,omitempty"`
+	Latitude          *float64  `json:"latitude,omitempty"`
+	Longitude         *float64  `json:"longitude,omitempty"`
+	IsNewDevice       bool      `gorm:"default:false" json:"is_new_device"`
+	Suspicious        bool      `gorm:"default:false" json:"suspicious"`
+	CreatedAt         time.Time `gorm:"index" json:"created_at"`
+}
+
+// KnownDevice adalah device yang pernah berhasil login (lolos OTP) untuk user
+type KnownDevice struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_user_device" json:"user_id"`
+	Fingerprint string    `gorm:"size:64;not null;uniqueIndex:idx_user_device" json:"fingerprint"`
+	UserAgent   string    `gorm:"size:255" json:"user_agent"`
+	LastIP      string    `gorm:"size:45" json:"last_ip"`
+	Country     string    `gorm:"size:2" json:"country,omitempty"`
+	City        string    `gorm:"size:100" json:"city,omitempty"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
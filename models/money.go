@@ -0,0 +1,208 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money adalah nominal rupiah dalam satuan sen (2 desimal), sesuai kolom decimal(15,2).
+// Semua aritmatika dilakukan dalam integer sehingga tidak ada rounding drift.
+type Money int64
+
+// MaxMoney adalah nilai absolut terbesar yang muat di kolom decimal(15,2)
+const MaxMoney Money = 999999999999999
+
+var (
+	ErrMoneyScale    = errors.New("amount must have at most 2 decimal places")
+	ErrMoneyFormat   = errors.New("amount is not a valid decimal number")
+	ErrMoneyOverflow = errors.New("amount is out of range")
+)
+
+// ParseMoney - Parse string desimal ("1500", "1500.5", "-20.25") tanpa melalui float
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrMoneyFormat
+	}
+
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	if intPart == "" || (hasFrac && fracPart == "") {
+		return 0, ErrMoneyFormat
+	}
+	if len(fracPart) > 2 {
+		// Angka nol di belakang (mis. "10.500") tidak mengubah nilai
+		if strings.TrimRight(fracPart[2:], "0") != "" {
+			return 0, ErrMoneyScale
+		}
+		fracPart = fracPart[:2]
+	}
+	for _, r := range intPart + fracPart {
+		if r < '0' || r > '9' {
+			return 0, ErrMoneyFormat
+		}
+	}
+	if len(intPart) > 13 {
+		return 0, ErrMoneyOverflow
+	}
+
+	units, _ := strconv.ParseInt(intPart, 10, 64)
+	cents := int64(0)
+	if fracPart != "" {
+		cents, _ = strconv.ParseInt((fracPart + "0")[:2], 10, 64)
+	}
+
+	m := Money(units*100 + cents)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// MoneyFromFloat - Hanya untuk data lama/eksternal, dibulatkan half away from zero ke sen
+func MoneyFromFloat(f float64) Money {
+	if f < 0 {
+		return -Money(int64(-f*100 + 0.5))
+	}
+	return Money(int64(f*100 + 0.5))
+}
+
+// String - Format "1234.50" (titik desimal, tanpa pemisah ribuan)
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/100, v%100)
+}
+
+// Float64 - Untuk keperluan tampilan/rasio saja, jangan dipakai untuk perhitungan uang
+func (m Money) Float64() float64 {
+	return float64(m) / 100
+}
+
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// MulRatio - m * num / den, dibulatkan half away from zero ke sen terdekat
+func (m Money) MulRatio(num, den int64) Money {
+	product := int64(m) * num
+	q, r := product/den, product%den
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= abs64(den) {
+		if (product < 0) != (den < 0) {
+			q--
+		} else {
+			q++
+		}
+	}
+	return Money(q)
+}
+
+// MulRatioChecked - Sama dengan MulRatio, tapi false jika hasil (atau perkalian di tengahnya)
+// melewati MaxMoney sehingga tidak pernah overflow diam-diam
+func (m Money) MulRatioChecked(num, den int64) (Money, bool) {
+	if den == 0 {
+		return 0, false
+	}
+	product := new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(num))
+	divisor := big.NewInt(den)
+	q, r := new(big.Int).QuoRem(product, divisor, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(new(big.Int).Abs(divisor)) >= 0 {
+		if product.Sign()*divisor.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if q.CmpAbs(big.NewInt(int64(MaxMoney))) > 0 {
+		return 0, false
+	}
+	return Money(q.Int64()), true
+}
+
+// Div - Bagi rata (mis. untuk average), dengan aturan pembulatan yang sama dengan MulRatio
+func (m Money) Div(n int64) Money {
+	if n == 0 {
+		return 0
+	}
+	return m.MulRatio(1, n)
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// MarshalJSON - Ditulis sebagai JSON number dengan tepat 2 desimal, mis. 150000.00
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON - Menerima number maupun string, menolak lebih dari 2 desimal dan notasi eksponen
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan - Membaca kolom decimal (MySQL mengirim []byte "123.45")
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		parsed, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case int64:
+		*m = Money(v * 100)
+		return nil
+	case float64:
+		*m = MoneyFromFloat(v)
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into Money", value)
+}
+
+// Value - Dikirim ke database sebagai string desimal agar tidak melewati float
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
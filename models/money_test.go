@@ -0,0 +1,203 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+		err  error
+	}{
+		{"1500", 150000, nil},
+		{"1500.5", 150050, nil},
+		{"1500.05", 150005, nil},
+		{"-20.25", -2025, nil},
+		{"+3.10", 310, nil},
+		{"-0.01", -1, nil},
+		{" 7 ", 700, nil},
+		{"10.500", 1050, nil},
+		{"9999999999999.99", MaxMoney, nil},
+		{"10.505", 0, ErrMoneyScale},
+		{"0.001", 0, ErrMoneyScale},
+		{"", 0, ErrMoneyFormat},
+		{"-", 0, ErrMoneyFormat},
+		{"1.", 0, ErrMoneyFormat},
+		{".5", 0, ErrMoneyFormat},
+		{"1e3", 0, ErrMoneyFormat},
+		{"1,000", 0, ErrMoneyFormat},
+		{"12345678901234", 0, ErrMoneyOverflow},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseMoney(%q) error = %v, want %v", tt.in, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyMulRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		num, den int64
+		want     Money
+	}{
+		{"exact", 1000, 1100, 10000, 110},
+		{"half cent rounds up", 1, 1, 2, 1},
+		{"half cent rounds away from zero", -1, 1, 2, -1},
+		{"PPN on 0.50", 50, 1100, 10000, 6},
+		{"PPN on -0.50", -50, 1100, 10000, -6},
+		{"below half rounds down", 1, 1, 3, 0},
+		{"above half rounds up", 2, 1, 3, 1},
+		{"negative below half", -1, 1, 3, 0},
+		{"negative denominator", 5, 1, -2, -3},
+		{"both negative", -5, 1, -2, 3},
+		{"zero", 0, 7, 9, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.m.MulRatio(tt.num, tt.den); got != tt.want {
+			t.Errorf("%s: Money(%d).MulRatio(%d, %d) = %d, want %d", tt.name, tt.m, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyDiv(t *testing.T) {
+	tests := []struct {
+		m    Money
+		n    int64
+		want Money
+	}{
+		{1000, 3, 333},
+		{1001, 2, 501},
+		{-1001, 2, -501},
+		{1000, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Div(tt.n); got != tt.want {
+			t.Errorf("Money(%d).Div(%d) = %d, want %d", tt.m, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{150000, "1500.00"},
+		{-123456, "-1234.56"},
+		{MaxMoney, "9999999999999.99"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.m, got, tt.want)
+		}
+		value, err := tt.m.Value()
+		if err != nil || value != tt.want {
+			t.Errorf("Money(%d).Value() = %v, %v, want %q", tt.m, value, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		in      interface{}
+		want    Money
+		wantErr bool
+	}{
+		{[]byte("123.45"), 12345, false},
+		{[]byte("-0.50"), -50, false},
+		{"99.90", 9990, false},
+		{int64(7), 700, false},
+		{int64(-3), -300, false},
+		{float64(19.99), 1999, false},
+		{float64(-19.99), -1999, false},
+		{nil, 0, false},
+		{[]byte("abc"), 0, true},
+		{true, 0, true},
+	}
+	for _, tt := range tests {
+		m := Money(42)
+		err := m.Scan(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Scan(%#v) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && m != tt.want {
+			t.Errorf("Scan(%#v) = %d, want %d", tt.in, m, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	encoded, err := json.Marshal(struct {
+		Amount   Money `json:"amount"`
+		Negative Money `json:"negative"`
+		Zero     Money `json:"zero"`
+	}{15000000, -5, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":150000.00,"negative":-0.05,"zero":0.00}`; string(encoded) != want {
+		t.Errorf("json.Marshal = %s, want %s", encoded, want)
+	}
+
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{`12.3`, 1230, false},
+		{`"12.30"`, 1230, false},
+		{`-0.05`, -5, false},
+		{`null`, 42, false},
+		{`1e3`, 0, true},
+		{`1.234`, 0, true},
+	}
+	for _, tt := range tests {
+		m := Money(42)
+		err := json.Unmarshal([]byte(tt.in), &m)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && m != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, m, tt.want)
+		}
+	}
+}
+
+func TestMoneyMulRatioChecked(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        Money
+		num, den int64
+		want     Money
+		ok       bool
+	}{
+		{"same as MulRatio", 50, 1100, 10000, 6, true},
+		{"negative half cent", -1, 1, 2, -1, true},
+		{"max money", MaxMoney, 1, 1, MaxMoney, true},
+		{"over max money", MaxMoney, 2, 1, 0, false},
+		{"product overflows int64", MaxMoney, 999999999999999, 10000, 0, false},
+		{"large product, small result", MaxMoney, 999999999999999, 999999999999999, MaxMoney, true},
+		{"zero denominator", 100, 1, 0, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.m.MulRatioChecked(tt.num, tt.den)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: MulRatioChecked = %d, %v, want %d, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Payment adalah uang masuk dari customer (mis. satu transfer bank).
+// Bagian yang belum dialokasikan ke invoice menjadi kredit di saldo customer.
+type Payment struct {
+	ID              uint                `gorm:"primaryKey" json:"id"`
+	CustomerID      uint                `gorm:"not null;index" json:"customer_id"`
+	Method          string              `gorm:"type:ENUM('bank_transfer','virtual_account','cash','e_wallet','other');not null" json:"method"`
+	Reference       string              `gorm:"size:100;index" json:"reference"`
+	Amount          Money               `gorm:"type:decimal(15,2);not null" json:"amount"`
+	UnappliedAmount Money               `gorm:"type:decimal(15,2);default:0" json:"unapplied_amount"`
+	ReceivedAt      time.Time           `gorm:"not null;index" json:"received_at"`
+	Notes           string              `gorm:"size:255" json:"notes"`
+	JournalEntryID  uint                `json:"journal_entry_id"`
+	CreatedBy       uint                `gorm:"not null" json:"created_by"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	Allocations     []PaymentAllocation `gorm:"foreignKey:PaymentID" json:"allocations,omitempty"`
+}
+
+// PaymentAllocation mencatat berapa bagian payment yang melunasi sebuah invoice
+type PaymentAllocation struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	PaymentID      uint      `gorm:"not null;index" json:"payment_id"`
+	InvoiceID      uint      `gorm:"not null;index" json:"invoice_id"`
+	Amount         Money     `gorm:"type:decimal(15,2);not null" json:"amount"`
+	JournalEntryID uint      `json:"journal_entry_id"`
+	CreatedBy      uint      `gorm:"not null" json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (p *Payment) BeforeCreate(tx *gorm.DB) error {
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+func (p *Payment) BeforeUpdate(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Plan adalah paket langganan dengan harga tetap per periode
+type Plan struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Code            string    `gorm:"size:50;uniqueIndex;not null" json:"code"`
+	Name            string    `gorm:"size:100;not null" json:"name"`
+	Description     string    `gorm:"size:255" json:"description"`
+	Price           Money     `gorm:"type:decimal(15,2);not null" json:"price"`
+	BillingInterval string    `gorm:"type:ENUM('monthly','yearly');default:'monthly'" json:"billing_interval"`
+	TaxRateBps      *int      `json:"tax_rate_bps"` // nil = tarif PPN yang berlaku pada awal setiap periode
+	IsActive        bool      `gorm:"default:true" json:"is_active"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Subscription menghubungkan customer ke plan. Tagihan dibuat di awal setiap periode
+// (bill in advance), lewat invoice atau potong saldo sesuai BillingMethod.
+type Subscription struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	CustomerID         uint       `gorm:"not null;index" json:"customer_id"`
+	PlanID             uint       `gorm:"not null;index" json:"plan_id"`
+	Plan               Plan       `gorm:"foreignKey:PlanID" json:"plan,omitempty"`
+	Status             string     `gorm:"type:ENUM('active','paused','cancelled');default:'active';index" json:"status"`
+	BillingMethod      string     `gorm:"type:ENUM('invoice','balance');default:'invoice'" json:"billing_method"`
+	CurrentPeriodStart time.Time  `gorm:"not null" json:"current_period_start"`
+	CurrentPeriodEnd   time.Time  `gorm:"not null;index" json:"current_period_end"`
+	BillingAnchor      *time.Time `json:"billing_anchor,omitempty"` // acuan tanggal tagih, nil (data lama) = CreatedAt
+	CancelAtPeriodEnd  bool       `gorm:"default:false" json:"cancel_at_period_end"`
+	PausedAt           *time.Time `json:"paused_at,omitempty"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+	CreatedBy          uint       `gorm:"not null" json:"created_by"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// SubscriptionCharge mencatat setiap tagihan subscription. ChargeKey unik sehingga
+// periode yang sama tidak pernah ditagih dua kali walau scheduler berjalan ulang.
+type SubscriptionCharge struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint      `gorm:"not null;index" json:"subscription_id"`
+	ChargeKey      string    `gorm:"size:100;uniqueIndex;not null" json:"charge_key"`
+	Kind           string    `gorm:"type:ENUM('renewal','proration','proration_credit','skipped');not null" json:"kind"`
+	PlanID         uint      `gorm:"not null" json:"plan_id"`
+	PeriodStart    time.Time `json:"period_start"`
+	PeriodEnd      time.Time `json:"period_end"`
+	Amount         Money     `gorm:"type:decimal(15,2);not null" json:"amount"`
+	InvoiceID      *uint     `json:"invoice_id,omitempty"`
+	JournalEntryID *uint     `json:"journal_entry_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (p *Plan) BeforeCreate(tx *gorm.DB) error {
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+func (p *Plan) BeforeUpdate(tx *gorm.DB) error {
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *Subscription) BeforeCreate(tx *gorm.DB) error {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *Subscription) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// Anchor - Tanggal acuan periode tagihan
+func (s *Subscription) Anchor() time.Time {
+	if s.BillingAnchor != nil {
+		return *s.BillingAnchor
+	}
+	return s.CreatedAt
+}
+
+// NextPeriodEnd - Akhir periode yang dimulai pada start. Dihitung dari anchor, bukan dari start,
+// agar tanggal tagih tidak bergeser permanen setelah di-clamp (31 Jan -> 28 Feb -> 31 Mar).
+func (p *Plan) NextPeriodEnd(anchor, start time.Time) time.Time {
+	step := 1
+	if p.BillingInterval == "yearly" {
+		step = 12
+	}
+
+	months := (start.Year()-anchor.Year())*12 + int(start.Month()) - int(anchor.Month())
+	n := months / step
+	if n < 1 {
+		n = 1
+	}
+	for {
+		if end := addMonths(anchor, n*step); end.After(start) {
+			return end
+		}
+		n++
+	}
+}
+
+// addMonths - Seperti AddDate tapi tanggal di-clamp ke akhir bulan (31 Jan + 1 bulan = 28/29 Feb)
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
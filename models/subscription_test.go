@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		from   time.Time
+		months int
+		want   time.Time
+	}{
+		{date(2025, time.January, 15), 1, date(2025, time.February, 15)},
+		{date(2025, time.January, 31), 1, date(2025, time.February, 28)},
+		{date(2024, time.January, 31), 1, date(2024, time.February, 29)},
+		{date(2025, time.January, 31), 2, date(2025, time.March, 31)},
+		{date(2025, time.March, 31), 1, date(2025, time.April, 30)},
+		{date(2025, time.August, 31), 6, date(2026, time.February, 28)},
+		{date(2025, time.December, 31), 2, date(2026, time.February, 28)},
+		{date(2024, time.February, 29), 12, date(2025, time.February, 28)},
+		{date(2024, time.February, 29), 48, date(2028, time.February, 29)},
+		{date(2025, time.March, 31), -1, date(2025, time.February, 28)},
+		{time.Date(2025, time.January, 31, 10, 30, 0, 0, time.UTC), 1, time.Date(2025, time.February, 28, 10, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := addMonths(tt.from, tt.months); !got.Equal(tt.want) {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.from.Format(time.DateTime), tt.months, got.Format(time.DateTime), tt.want.Format(time.DateTime))
+		}
+	}
+}
+
+func TestPlanNextPeriodEnd(t *testing.T) {
+	monthly := Plan{BillingInterval: "monthly"}
+	yearly := Plan{BillingInterval: "yearly"}
+
+	tests := []struct {
+		name   string
+		plan   Plan
+		anchor time.Time
+		start  time.Time
+		want   time.Time
+	}{
+		{"first period clamps to february", monthly, date(2025, time.January, 31), date(2025, time.January, 31), date(2025, time.February, 28)},
+		{"returns to the 31st after february", monthly, date(2025, time.January, 31), date(2025, time.February, 28), date(2025, time.March, 31)},
+		{"thirty day month", monthly, date(2025, time.January, 31), date(2025, time.March, 31), date(2025, time.April, 30)},
+		{"after thirty day month", monthly, date(2025, time.January, 31), date(2025, time.April, 30), date(2025, time.May, 31)},
+		{"start mid period", monthly, date(2025, time.January, 31), date(2025, time.March, 15), date(2025, time.March, 31)},
+		{"leap day yearly", yearly, date(2024, time.February, 29), date(2024, time.February, 29), date(2025, time.February, 28)},
+		{"leap day yearly after clamp", yearly, date(2024, time.February, 29), date(2027, time.February, 28), date(2028, time.February, 29)},
+	}
+	for _, tt := range tests {
+		if got := tt.plan.NextPeriodEnd(tt.anchor, tt.start); !got.Equal(tt.want) {
+			t.Errorf("%s: NextPeriodEnd(%s, %s) = %s, want %s", tt.name, tt.anchor.Format(time.DateOnly), tt.start.Format(time.DateOnly), got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestPlanNextPeriodEndDoesNotDrift(t *testing.T) {
+	plan := Plan{BillingInterval: "monthly"}
+	anchor := date(2025, time.January, 31)
+	want := []int{28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31, 31}
+
+	start := anchor
+	for i, day := range want {
+		end := plan.NextPeriodEnd(anchor, start)
+		if end.Day() != day {
+			t.Fatalf("period %d ends %s, want day %d", i+1, end.Format(time.DateOnly), day)
+		}
+		start = end
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TaxRate adalah tarif pajak yang berlaku mulai EffectiveFrom sampai ada tarif yang lebih baru
+type TaxRate struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Code          string    `gorm:"size:20;not null;uniqueIndex:idx_tax_rate_effective" json:"code"`
+	RateBps       int       `gorm:"not null" json:"rate_bps"`
+	EffectiveFrom time.Time `gorm:"type:date;not null;uniqueIndex:idx_tax_rate_effective" json:"effective_from"`
+	Description   string    `gorm:"size:255" json:"description"`
+	CreatedBy     uint      `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// UsageAggregate adalah total pemakaian per customer, metric dan jam.
+// Granularitas per jam cukup untuk query periode apa pun (harian, bulanan, periode invoice).
+type UsageAggregate struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	CustomerID  uint      `gorm:"not null;uniqueIndex:idx_usage_bucket" json:"customer_id"`
+	Metric      string    `gorm:"size:50;not null;uniqueIndex:idx_usage_bucket" json:"metric"`
+	PeriodStart time.Time `gorm:"not null;uniqueIndex:idx_usage_bucket;index" json:"period_start"`
+	Quantity    float64   `gorm:"type:decimal(20,6);not null;default:0" json:"quantity"`
+	EventCount  int64     `gorm:"not null;default:0" json:"event_count"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UsageRollupBatch menandai batch Redis yang sudah masuk ke aggregate,
+// sehingga batch yang diproses ulang setelah crash tidak terhitung dua kali.
+type UsageRollupBatch struct {
+	BatchID     string    `gorm:"primaryKey;size:30"`
+	EventCount  int       `gorm:"not null"`
+	ProcessedAt time.Time `gorm:"not null"`
+}
@@ -18,6 +18,11 @@ type User struct {
 	LastLogin  *time.Time `gorm:"null" json:"last_login,omitempty"`
 	CreatedAt  time.Time  `json:"created_at"`
 	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Permintaan hapus akun (UU PDP), dieksekusi setelah grace period
+	DeletionRequestedAt *time.Time `gorm:"null" json:"deletion_requested_at,omitempty"`
+	DeletionScheduledAt *time.Time `gorm:"null;index" json:"deletion_scheduled_at,omitempty"`
+	AnonymizedAt        *time.Time `gorm:"null" json:"anonymized_at,omitempty"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfirmPageData - Isi halaman konfirmasi untuk link di email. Aksinya baru dijalankan
+// lewat POST dari form ini, sehingga link yang dibuka oleh preview/scanner email tidak mengubah apa pun.
+type ConfirmPageData struct {
+	Title   string
+	Message string
+	Action  string // path POST
+	Token   string
+	Button  string
+}
+
+const confirmPageTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <title>{{.Title}}</title>
+    <style>
+        body { font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; line-height: 1.6; color: #333; background-color: #f4f4f4; margin: 0; padding: 0; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); color: white; padding: 30px; text-align: center; border-radius: 10px 10px 0 0; }
+        .content { background: white; padding: 40px; border-radius: 0 0 10px 10px; box-shadow: 0 4px 6px rgba(0, 0, 0, 0.1); text-align: center; }
+        .btn { display: inline-block; padding: 12px 30px; background: #f5576c; color: white; border: none; border-radius: 5px; font-size: 16px; cursor: pointer; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>{{.Title}}</h1>
+            <p>Sistem Autentikasi API</p>
+        </div>
+        <div class="content">
+            <p>{{.Message}}</p>
+            <form method="POST" action="{{.Action}}">
+                <input type="hidden" name="token" value="{{.Token}}">
+                <button class="btn" type="submit">{{.Button}}</button>
+            </form>
+        </div>
+    </div>
+</body>
+</html>`
+
+var confirmPage = template.Must(template.New("confirm_page").Parse(confirmPageTemplate))
+
+// RenderConfirmPage - Kirim halaman konfirmasi HTML. Token ada di URL, jadi halaman tidak di-cache
+// dan tidak mengirim Referer ke resource lain.
+func RenderConfirmPage(c *gin.Context, data ConfirmPageData) {
+	var body bytes.Buffer
+	if err := confirmPage.Execute(&body, data); err != nil {
+		ErrorResponse(c, 500, gin.H{"message": "Internal server error"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Data(200, "text/html; charset=utf-8", body.Bytes())
+}
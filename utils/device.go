@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeviceFingerprint - Hash dari user agent, bahasa dan header X-Device-ID (jika dikirim client).
+// IP sengaja tidak dipakai karena sering berubah untuk device yang sama.
+func DeviceFingerprint(c *gin.Context) string {
+	parts := []string{
+		c.GetHeader("User-Agent"),
+		c.GetHeader("Accept-Language"),
+		c.GetHeader("X-Device-ID"),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
@@ -346,3 +346,289 @@ func SendPasswordResetEmail(cfg *config.Config, to, name, otp string, minutes in
 
 	return nil
 }
+
+// sendHTMLEmail - Render template lalu kirim sebagai email HTML
+func sendHTMLEmail(cfg *config.Config, to, subject, name, emailTemplate string, data interface{}) error {
+	tmpl, err := template.New(name).Parse(emailTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse email template: %v", err)
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return fmt.Errorf("failed to execute email template: %v", err)
+	}
+
+	from := cfg.SMTP.From
+	auth := smtp.PlainAuth("", from, cfg.SMTP.Password, cfg.SMTP.Host)
+
+	headers := make(map[string]string)
+	headers["From"] = fmt.Sprintf("Authentication System <%s>", from)
+	headers["To"] = to
+	headers["Subject"] = subject
+	headers["MIME-Version"] = "1.0"
+	headers["Content-Type"] = "text/html; charset=UTF-8"
+
+	var msg strings.Builder
+	for k, v := range headers {
+		msg.WriteString(fmt.Sprintf("%s: %s\r\n", k, v))
+	}
+	msg.WriteString("\r\n")
+	msg.WriteString(body.String())
+
+	err = smtp.SendMail(
+		fmt.Sprintf("%s:%d", cfg.SMTP.Host, cfg.SMTP.Port),
+		auth,
+		from,
+		[]string{to},
+		[]byte(msg.String()),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
+
+// simpleEmailLayout - Layout dasar untuk email notifikasi, {{.Content}} diisi oleh pemanggil
+const simpleEmailLayout = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Title}}</title>
+    <style>
+        body { font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; line-height: 1.6; color: #333; background-color: #f4f4f4; margin: 0; padding: 0; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background: linear-gradient(135deg, #667eea 0%, #764ba2 100%); color: white; padding: 30px; text-align: center; border-radius: 10px 10px 0 0; }
+        .content { background: white; padding: 40px; border-radius: 0 0 10px 10px; box-shadow: 0 4px 6px rgba(0, 0, 0, 0.1); }
+        .otp-code { font-size: 36px; letter-spacing: 10px; font-weight: bold; color: #667eea; text-align: center; margin: 20px 0; }
+        .btn { display: inline-block; padding: 12px 30px; background: #f5576c; color: white; text-decoration: none; border-radius: 5px; margin: 10px 0; }
+        .footer { margin-top: 40px; padding-top: 20px; border-top: 1px solid #eee; color: #666; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>{{.Title}}</h1>
+            <p>Sistem Autentikasi API</p>
+        </div>
+        <div class="content">
+            <h2>Halo {{.Name}},</h2>
+            {{template "content" .}}
+            <div class="footer">
+                <p>Email ini dikirim secara otomatis, harap tidak membalas email ini.</p>
+                <p>© 2024 Sistem Autentikasi API. All rights reserved.</p>
+            </div>
+        </div>
+    </div>
+</body>
+</html>{{define "content"}}{{end}}`
+
+type EmailChangeOTPEmailData struct {
+	Title    string
+	Name     string
+	OTP      string
+	Minutes  int
+	NewEmail string
+	IsNew    bool
+}
+
+func SendEmailChangeOTPEmail(cfg *config.Config, to, name, otp, newEmail string, isNewAddress bool, minutes int) error {
+	emailTemplate := simpleEmailLayout + `{{define "content"}}
+            {{if .IsNew}}<p>Alamat email ini didaftarkan sebagai email baru untuk akun Anda. Gunakan kode OTP berikut untuk mengonfirmasi alamat ini:</p>
+            {{else}}<p>Kami menerima permintaan untuk mengganti email akun Anda menjadi <strong>{{.NewEmail}}</strong>. Gunakan kode OTP berikut untuk mengonfirmasi perubahan:</p>{{end}}
+            <div class="otp-code">{{.OTP}}</div>
+            <p style="text-align: center; color: #666;">Berlaku selama {{.Minutes}} menit</p>
+            <p>Jika Anda tidak melakukan permintaan ini, abaikan email ini dan segera ganti password Anda.</p>
+{{end}}`
+
+	data := EmailChangeOTPEmailData{
+		Title:    "Konfirmasi Perubahan Email",
+		Name:     name,
+		OTP:      otp,
+		Minutes:  minutes,
+		NewEmail: newEmail,
+		IsNew:    isNewAddress,
+	}
+
+	subject := fmt.Sprintf("[%s] Kode OTP Perubahan Email", otp)
+	return sendHTMLEmail(cfg, to, subject, "email_change_otp", emailTemplate, data)
+}
+
+type EmailChangedEmailData struct {
+	Title     string
+	Name      string
+	NewEmail  string
+	CancelURL string
+}
+
+func SendEmailChangedNotification(cfg *config.Config, to, name, newEmail, cancelURL string) error {
+	emailTemplate := simpleEmailLayout + `{{define "content"}}
+            <p>Email akun Anda telah diganti menjadi <strong>{{.NewEmail}}</strong>. Semua sesi login Anda telah diakhiri.</p>
+            <p>Jika Anda tidak melakukan perubahan ini, batalkan segera melalui tombol berikut:</p>
+            <p style="text-align: center;"><a class="btn" href="{{.CancelURL}}">Batalkan Perubahan Email</a></p>
+{{end}}`
+
+	data := EmailChangedEmailData{
+		Title:     "Email Akun Diganti",
+		Name:      name,
+		NewEmail:  newEmail,
+		CancelURL: cancelURL,
+	}
+
+	return sendHTMLEmail(cfg, to, "Email Akun Anda Telah Diganti", "email_changed", emailTemplate, data)
+}
+
+type LoginAlertEmailData struct {
+	Title      string
+	Name       string
+	Time       string
+	IPAddress  string
+	Location   string
+	UserAgent  string
+	Suspicious bool
+	RevokeURL  string
+}
+
+func SendLoginAlertEmail(cfg *config.Config, to string, data LoginAlertEmailData) error {
+	emailTemplate := simpleEmailLayout + `{{define "content"}}
+            <p>Akun Anda baru saja digunakan untuk login dari device baru:</p>
+            <ul>
+                <li><strong>Waktu:</strong> {{.Time}}</li>
+                <li><strong>IP:</strong> {{.IPAddress}}</li>
+                <li><strong>Lokasi:</strong> {{.Location}}</li>
+                <li><strong>Device:</strong> {{.UserAgent}}</li>
+            </ul>
+            {{if .Suspicious}}<p><strong>⚠️ Lokasi login ini tidak wajar dibandingkan login terakhir Anda.</strong></p>{{end}}
+            <p>Jika ini bukan Anda, akhiri semua sesi login sekarang lalu segera ganti password Anda:</p>
+            <p style="text-align: center;"><a class="btn" href="{{.RevokeURL}}">Bukan Saya, Akhiri Semua Sesi</a></p>
+{{end}}`
+
+	data.Title = "Login dari Device Baru"
+	return sendHTMLEmail(cfg, to, "Apakah ini Anda? Login dari device baru", "login_alert", emailTemplate, data)
+}
+
+type BalanceAlertEmailData struct {
+	Title        string
+	Name         string
+	CustomerCode string
+	CompanyName  string
+	Balance      string
+	Threshold    string
+	CreditLimit  string
+	Overdraft    string
+}
+
+func SendLowBalanceEmail(cfg *config.Config, to string, data BalanceAlertEmailData) error {
+	emailTemplate := simpleEmailLayout + `{{define "content"}}
+            <p>Saldo akun <strong>{{.CompanyName}}</strong> ({{.CustomerCode}}) telah turun di bawah batas minimum yang ditentukan.</p>
+            <ul>
+                <li><strong>Saldo saat ini:</strong> {{.Balance}}</li>
+                <li><strong>Batas minimum:</strong> {{.Threshold}}</li>
+                <li><strong>Credit limit:</strong> {{.CreditLimit}}</li>
+            </ul>
+            <p>Segera lakukan top up agar layanan tidak terganggu.</p>
+{{end}}`
+
+	data.Title = "Saldo Rendah"
+	subject := fmt.Sprintf("Saldo %s di bawah batas minimum", data.CustomerCode)
+	return sendHTMLEmail(cfg, to, subject, "low_balance", emailTemplate, data)
+}
+
+func SendOverdraftAlertEmail(cfg *config.Config, to string, data BalanceAlertEmailData) error {
+	emailTemplate := simpleEmailLayout + `{{define "content"}}
+            <p>Pemotongan saldo untuk <strong>{{.CompanyName}}</strong> ({{.CustomerCode}}) melebihi credit limit dan tetap diproses sesuai kebijakan overdraft customer.</p>
+            <ul>
+                <li><strong>Saldo saat ini:</strong> {{.Balance}}</li>
+                <li><strong>Credit limit:</strong> {{.CreditLimit}}</li>
+                <li><strong>Melebihi limit:</strong> {{.Overdraft}}</li>
+            </ul>
+{{end}}`
+
+	data.Title = "Overdraft Melebihi Credit Limit"
+	subject := fmt.Sprintf("Overdraft %s melebihi credit limit", data.CustomerCode)
+	return sendHTMLEmail(cfg, to, subject, "overdraft_alert", emailTemplate, data)
+}
+
+type ApprovalRequestEmailData struct {
+	Title        string
+	Name         string
+	ApprovalID   uint
+	CustomerCode string
+	CompanyName  string
+	Type         string
+	Amount       string
+	Reason       string
+	RequestedBy  string
+	Notes        string
+	ExpiresAt    string
+	ReviewURL    string
+}
+
+func SendApprovalRequestEmail(cfg *config.Config, to string, data ApprovalRequestEmailData) error {
+	emailTemplate := simpleEmailLayout + `{{define "content"}}
+            <p><strong>{{.RequestedBy}}</strong> mengajukan perubahan saldo yang memerlukan persetujuan Anda:</p>
+            <ul>
+                <li><strong>Customer:</strong> {{.CompanyName}} ({{.CustomerCode}})</li>
+                <li><strong>Jenis:</strong> {{.Type}}</li>
+                <li><strong>Nominal:</strong> {{.Amount}}</li>
+                <li><strong>Alasan approval:</strong> {{.Reason}}</li>
+                {{if .Notes}}<li><strong>Catatan:</strong> {{.Notes}}</li>{{end}}
+                <li><strong>Berlaku sampai:</strong> {{.ExpiresAt}}</li>
+            </ul>
+            <p style="text-align: center;"><a class="btn" href="{{.ReviewURL}}">Tinjau Permintaan</a></p>
+{{end}}`
+
+	data.Title = "Permintaan Persetujuan Saldo"
+	subject := fmt.Sprintf("Approval #%d: perubahan saldo %s", data.ApprovalID, data.CustomerCode)
+	return sendHTMLEmail(cfg, to, subject, "approval_request", emailTemplate, data)
+}
+
+type DunningInvoiceLine struct {
+	Number      string
+	DueDate     string
+	Outstanding string
+}
+
+type DunningEmailData struct {
+	Title        string
+	Name         string
+	Step         string // reminder, warning, suspension
+	CustomerCode string
+	CompanyName  string
+	DaysOverdue  int
+	AmountDue    string
+	Overdraft    string
+	Invoices     []DunningInvoiceLine
+	Sender       string
+}
+
+func SendDunningEmail(cfg *config.Config, to string, data DunningEmailData) error {
+	emailTemplate := simpleEmailLayout + `{{define "content"}}
+            {{if eq .Step "reminder"}}<p>Kami ingin mengingatkan bahwa terdapat tagihan untuk <strong>{{.CompanyName}}</strong> ({{.CustomerCode}}) yang telah melewati tanggal jatuh tempo.</p>
+            {{else if eq .Step "warning"}}<p><strong>Peringatan:</strong> tagihan untuk <strong>{{.CompanyName}}</strong> ({{.CustomerCode}}) telah lewat jatuh tempo {{.DaysOverdue}} hari. Mohon segera lakukan pembayaran untuk menghindari penangguhan layanan.</p>
+            {{else}}<p>Karena tagihan untuk <strong>{{.CompanyName}}</strong> ({{.CustomerCode}}) belum dibayar setelah {{.DaysOverdue}} hari, <strong>layanan Anda kami tangguhkan</strong>. Layanan akan aktif kembali secara otomatis setelah seluruh tunggakan dilunasi.</p>{{end}}
+            {{if .Invoices}}<table style="width: 100%; border-collapse: collapse;">
+                <tr><th style="text-align: left;">Invoice</th><th style="text-align: left;">Jatuh Tempo</th><th style="text-align: right;">Sisa Tagihan</th></tr>
+                {{range .Invoices}}<tr><td>{{.Number}}</td><td>{{.DueDate}}</td><td style="text-align: right;">{{.Outstanding}}</td></tr>{{end}}
+            </table>{{end}}
+            {{if .Overdraft}}<p>Saldo akun melewati credit limit sebesar <strong>{{.Overdraft}}</strong>.</p>{{end}}
+            <p><strong>Total yang harus dibayar:</strong> {{.AmountDue}}</p>
+            <p>Jika Anda sudah melakukan pembayaran atau tidak setuju dengan tagihan ini, silakan hubungi {{.Sender}}.</p>
+{{end}}`
+
+	subjects := map[string]string{
+		"reminder":   "Pengingat: tagihan %s telah jatuh tempo",
+		"warning":    "Peringatan: tagihan %s lewat jatuh tempo",
+		"suspension": "Pemberitahuan penangguhan layanan %s",
+	}
+	subject, ok := subjects[data.Step]
+	if !ok {
+		subject = "Tagihan %s lewat jatuh tempo"
+	}
+
+	data.Title = "Pemberitahuan Tagihan"
+	return sendHTMLEmail(cfg, to, fmt.Sprintf(subject, data.CustomerCode), "dunning_"+data.Step, emailTemplate, data)
+}
@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var bulanIndonesia = [...]string{
+	"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+}
+
+// FormatThousandsID - Format bilangan bulat dengan titik sebagai pemisah ribuan (1234567 -> "1.234.567")
+func FormatThousandsID(n int64) string {
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	digits := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	return sign + b.String()
+}
+
+// FormatRupiah - Format nominal dalam sen ke "Rp 1.234.567,89"
+func FormatRupiah(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%sRp %s,%02d", sign, FormatThousandsID(cents/100), cents%100)
+}
+
+// FormatDecimalID - Format angka desimal dengan koma (1.5 -> "1,5")
+func FormatDecimalID(f float64) string {
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	n, _ := strconv.ParseInt(intPart, 10, 64)
+	if !hasFrac {
+		return sign + FormatThousandsID(n)
+	}
+	return sign + FormatThousandsID(n) + "," + fracPart
+}
+
+// FormatTanggal - Format tanggal Indonesia, mis. "18 Oktober 2026"
+func FormatTanggal(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), bulanIndonesia[t.Month()-1], t.Year())
+}
+
+// FormatNPWP - Format NPWP 15 digit menjadi 99.999.999.9-999.999, selain itu dikembalikan apa adanya
+func FormatNPWP(npwp string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, npwp)
+
+	if len(digits) != 15 {
+		return npwp
+	}
+	return fmt.Sprintf("%s.%s.%s.%s-%s.%s", digits[0:2], digits[2:5], digits[5:8], digits[8:9], digits[9:12], digits[12:15])
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"math"
+	"net"
+
+	"github.com/oschwald/geoip2-golang"
+)
+
+var geoDB *geoip2.Reader
+
+type GeoLocation struct {
+	Country   string
+	City      string
+	Latitude  float64
+	Longitude float64
+	Found     bool
+}
+
+// InitGeoIP - Membuka database GeoIP lokal (format MaxMind .mmdb)
+func InitGeoIP(path string) error {
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return err
+	}
+	geoDB = db
+	return nil
+}
+
+// LookupGeo - Lokasi kasar (negara/kota) dari IP, kosong jika GeoIP tidak aktif
+func LookupGeo(ip string) GeoLocation {
+	parsed := net.ParseIP(ip)
+	if geoDB == nil || parsed == nil {
+		return GeoLocation{}
+	}
+
+	record, err := geoDB.City(parsed)
+	if err != nil || record.Country.IsoCode == "" {
+		return GeoLocation{}
+	}
+
+	return GeoLocation{
+		Country:   record.Country.IsoCode,
+		City:      record.City.Names["en"],
+		Latitude:  record.Location.Latitude,
+		Longitude: record.Location.Longitude,
+		Found:     true,
+	}
+}
+
+// DistanceKm - Jarak great-circle (haversine) antara dua koordinat
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNPWPFormat   = errors.New("NPWP may only contain digits, dots, dashes and spaces")
+	ErrNPWPLength   = errors.New("NPWP must be 15 or 16 digits")
+	ErrNPWPChecksum = errors.New("NPWP check digit is invalid")
+	ErrNPWPNIK      = errors.New("NPWP is not a valid NIK (16 digits)")
+)
+
+// NormalizeNPWP - Buang tanda baca/spasi, "01.234.567.8-901.000" -> "012345678901000".
+// Karakter selain digit dan pemisah umum membuat hasilnya tidak valid.
+func NormalizeNPWP(npwp string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r
+		case r == '.' || r == '-' || r == ' ':
+			return -1
+		}
+		return 'x'
+	}, npwp)
+}
+
+// ValidateNPWP - Validasi NPWP 15 digit (check digit Luhn pada 9 digit pertama) atau
+// 16 digit (NPWP badan dengan prefix 0, atau NIK untuk orang pribadi sesuai PMK 112/2022).
+// Mengembalikan NPWP yang sudah dinormalisasi.
+func ValidateNPWP(npwp string) (string, error) {
+	digits := NormalizeNPWP(npwp)
+	if strings.Contains(digits, "x") {
+		return "", ErrNPWPFormat
+	}
+
+	switch len(digits) {
+	case 15:
+		return digits, validateNPWP15(digits)
+	case 16:
+		if digits[0] == '0' {
+			return digits, validateNPWP15(digits[1:])
+		}
+		return digits, validateNIK(digits)
+	}
+	return "", ErrNPWPLength
+}
+
+// validateNPWP15 - 2 digit jenis WP, 6 digit nomor urut, 1 check digit, 3 digit KPP, 3 digit status cabang
+func validateNPWP15(digits string) error {
+	if strings.Trim(digits[:9], "0") == "" {
+		return ErrNPWPChecksum
+	}
+	if !luhnValid(digits[:9]) {
+		return ErrNPWPChecksum
+	}
+	return nil
+}
+
+// validateNIK - 6 digit kode wilayah, 6 digit tanggal lahir (DDMMYY, DD+40 untuk perempuan), 4 digit nomor urut
+func validateNIK(digits string) error {
+	province, _ := strconv.Atoi(digits[0:2])
+	if province < 11 || province > 94 {
+		return ErrNPWPNIK
+	}
+
+	day, _ := strconv.Atoi(digits[6:8])
+	month, _ := strconv.Atoi(digits[8:10])
+	if day > 40 {
+		day -= 40
+	}
+	if day < 1 || day > 31 || month < 1 || month > 12 {
+		return ErrNPWPNIK
+	}
+
+	if digits[12:16] == "0000" {
+		return ErrNPWPNIK
+	}
+	return nil
+}
+
+// luhnValid - Algoritma Luhn (mod 10), digit terakhir adalah check digit
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
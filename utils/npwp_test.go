@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateNPWP(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+		err  error
+	}{
+		{"15 digits formatted", "01.234.567.4-901.000", "012345674901000", nil},
+		{"15 digits plain", "012345674901000", "012345674901000", nil},
+		{"15 digits with spaces", "01 234 567 4 901 000", "012345674901000", nil},
+		{"16 digits with 0 prefix", "0012345674901000", "0012345674901000", nil},
+		{"NIK", "3201014508900001", "3201014508900001", nil},
+		{"NIK male birth date", "3201010508900001", "3201010508900001", nil},
+		{"wrong check digit", "01.234.567.8-901.000", "", ErrNPWPChecksum},
+		{"wrong check digit with 0 prefix", "0012345678901000", "", ErrNPWPChecksum},
+		{"all zero", "000000000901000", "", ErrNPWPChecksum},
+		{"NIK invalid province", "1001014508900001", "", ErrNPWPNIK},
+		{"NIK invalid day", "3201017208900001", "", ErrNPWPNIK},
+		{"NIK invalid month", "3201011513900001", "", ErrNPWPNIK},
+		{"NIK zero sequence", "3201014508900000", "", ErrNPWPNIK},
+		{"too short", "12345", "", ErrNPWPLength},
+		{"too long", "01234567490100012", "", ErrNPWPLength},
+		{"letters", "01.234.567.4-901.00A", "", ErrNPWPFormat},
+		{"other separators", "01/234/567/4/901/000", "", ErrNPWPFormat},
+		{"empty", "", "", ErrNPWPLength},
+	}
+	for _, tt := range tests {
+		got, err := ValidateNPWP(tt.in)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: ValidateNPWP(%q) error = %v, want %v", tt.name, tt.in, err, tt.err)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("%s: ValidateNPWP(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}